@@ -0,0 +1,53 @@
+package treeduction
+
+import "log/slog"
+
+// Abort stops the tree without a result, for when the caller no longer cares
+// about it: the inputs are no longer read, the values inside the tree and
+// the results not read yet are discarded, and the output is closed. It
+// returns once every goroutine of the tree exited, with the error of the
+// tree if it failed before. A function passed to Go must return on its own.
+func (t *tree[T]) Abort() error {
+	if !t.markFinished() {
+		return t.finishedBefore()
+	}
+	defer t.settle()
+	t.aborted.Store(true)
+	t.log(slog.LevelInfo, "aborted")
+	t.Resume()
+	t.cancel()
+	t.abortSubtrees()
+	t.abortClassified()
+
+	// A result may be on its way to an output that nobody reads anymore
+	for _, out := range append([]chan T{t.output}, t.partitions...) {
+		t.spawn(func() {
+			for range out {
+			}
+		})
+	}
+	if t.pool == nil {
+		t.mu.Lock()
+		t.sealOpen()
+		t.mu.Unlock()
+		// With waitForAll the ordered runs are only read by Finish
+		if t.ordered && t.waitForAll {
+			for _, r := range t.runs {
+				t.spawn(func() {
+					for range r.c {
+					}
+				})
+			}
+		}
+	}
+
+	t.wg.Wait()
+	t.epochs.Wait()
+	t.closeOutput()
+	// Report a goroutine that doesn't exit rather than hang on it
+	if t.opts.leakGrace > 0 {
+		return t.checkLeaks(t.error())
+	}
+	t.running.Wait()
+	return t.error()
+}
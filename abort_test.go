@@ -0,0 +1,73 @@
+package treeduction_test
+
+import (
+	"errors"
+	"testing"
+	"treeduction"
+)
+
+// TestAbort tests that Abort returns with inputs that are never closed and
+// an output that is never read, and leaves no goroutine behind.
+func TestAbort(t *testing.T) {
+	options := [][]treeduction.Option{
+		nil,
+		{treeduction.WithMaxWorkers(2)},
+		{treeduction.WithStrategy(treeduction.Flat)},
+		{treeduction.WithTumblingWindow(3)},
+	}
+	for _, waitForAll := range []bool{false, true} {
+		for _, ordered := range []bool{false, true} {
+			for _, opts := range options {
+				tree := treeduction.New(func(a, b int) int {
+					return a + b
+				}, 1, waitForAll, ordered, opts...)
+
+				for range 5 {
+					c := make(chan int) // Never closed
+					tree.Add(c)
+					go func() {
+						for i := 0; ; i++ {
+							select {
+							case c <- i:
+							case <-tree.Output():
+								return
+							}
+						}
+					}()
+				}
+				tree.AddValues(1, 2, 3, 4, 5, 6, 7, 8)
+
+				if err := tree.Abort(); err != nil {
+					t.Fatal(err)
+				}
+				if _, ok := <-tree.Output(); ok {
+					t.Error("Expected the output to be closed and empty")
+				}
+				if n := tree.Stats().Goroutines; n != 0 {
+					t.Errorf("Expected no goroutines after Abort, got %d", n)
+				}
+				if err := tree.Finish(); !errors.Is(err, treeduction.ErrFinished) {
+					t.Errorf("Expected ErrFinished, got %v", err)
+				}
+			}
+		}
+	}
+}
+
+// TestAbortReset tests that an aborted tree can be reused.
+func TestAbortReset(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	tree.Add(make(chan int))
+	tree.Abort()
+	if err := tree.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	tree.AddValues(1, 2, 3)
+	tree.Finish()
+	if v := <-tree.Output(); v != 6 {
+		t.Errorf("Expected 6, got %d", v)
+	}
+}
@@ -0,0 +1,61 @@
+package treeduction
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithAdaptiveBatching sizes the batches of the nodes, see WithBatchSize,
+// from the cost of the combiner, measured on a sample of the combines while
+// the tree runs. A cheap combiner (like int addition) gets large batches, to
+// save most of the channel operations, and an expensive one (like merging
+// sketches) none, so that the combines run in parallel across the nodes.
+// Only the batches adapt: the arity and the buffers of the tree are set by
+// the other options (see WithAutoBuffer for the buffers). An explicit
+// WithBatchSize takes precedence.
+func WithAdaptiveBatching() Option {
+	return func(o *options) {
+		o.adaptiveBatch = true
+	}
+}
+
+// The cost of a combine below which the batches are large or small
+const (
+	cheapCombine = time.Microsecond
+	slowCombine  = 50 * time.Microsecond
+)
+
+// cost is a moving average of the duration of a sample of the combines.
+type cost struct {
+	n   atomic.Int64
+	avg atomic.Int64
+}
+
+// sample reports whether the next combine should be timed.
+func (c *cost) sample() bool {
+	return c.n.Add(1)%16 == 1
+}
+
+func (c *cost) observe(d time.Duration) {
+	avg := c.avg.Load()
+	if avg == 0 {
+		c.avg.Store(int64(d))
+		return
+	}
+	c.avg.Store(avg + (int64(d)-avg)/8)
+}
+
+// batchSize is the number of values a node folds at once, see batch.
+func (t *tree[T]) batchSize() int {
+	if t.opts.batchSize > 0 || !t.opts.adaptiveBatch {
+		return t.opts.batchSize
+	}
+	switch avg := time.Duration(t.cost.avg.Load()); {
+	case avg == 0 || avg >= slowCombine:
+		return 0
+	case avg < cheapCombine:
+		return 64
+	default:
+		return 8
+	}
+}
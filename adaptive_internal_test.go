@@ -0,0 +1,50 @@
+package treeduction
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAdaptiveBatchSize tests that the batches follow the measured cost of
+// the combiner.
+func TestAdaptiveBatchSize(t *testing.T) {
+	for _, tc := range []struct {
+		combine time.Duration
+		size    int
+	}{
+		{0, 0},
+		{100 * time.Nanosecond, 64},
+		{10 * time.Microsecond, 8},
+		{time.Millisecond, 0},
+	} {
+		tr := newTree[int](10, true, false, []Option{WithAdaptiveBatching()}, nil)
+		if tc.combine > 0 {
+			tr.cost.observe(tc.combine)
+		}
+		if size := tr.batchSize(); size != tc.size {
+			t.Errorf("Expected a batch size of %d for a combine of %v, got %d", tc.size, tc.combine, size)
+		}
+		tr.Finish()
+	}
+}
+
+// TestAdaptiveBatching tests that an adaptive tree measures the combiner.
+func TestAdaptiveBatching(t *testing.T) {
+	tr := New(func(a, b int) int {
+		return a + b
+	}, 100, true, false, WithAdaptiveBatching()).(*tree[int])
+	for range 16 {
+		s := make([]int, 100)
+		for i := range s {
+			s[i] = 1
+		}
+		tr.AddSlice(s)
+	}
+	tr.Finish()
+	if v := <-tr.Output(); v != 1600 {
+		t.Errorf("Expected 1600, got %d", v)
+	}
+	if tr.cost.avg.Load() == 0 {
+		t.Error("Expected the combines to be measured")
+	}
+}
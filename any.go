@@ -0,0 +1,43 @@
+package treeduction
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// AddAny reduces the values of an untyped channel converted by convert,
+// like a producer passed to Go, for the pipelines that pass values around
+// as any. The values convert reports false for are dropped and counted in
+// Stats, and logged as warnings with WithLogger.
+func (t *tree[T]) AddAny(ch <-chan any, convert func(any) (T, bool)) error {
+	return t.Go(convertAll(t, ch, convert))
+}
+
+// AddAny reduces the values of an untyped channel converted to T, see
+// Tree.AddAny.
+func (f *Folder[T, A]) AddAny(ch <-chan any, convert func(any) (T, bool)) error {
+	return f.Go(convertAll(f.tree, ch, convert))
+}
+
+// convertAll returns a producer of the values of ch that convert.
+func convertAll[T, A any](t *tree[A], ch <-chan any, convert func(any) (T, bool)) func(emit func(T)) error {
+	return func(emit func(T)) error {
+		for {
+			select {
+			case v, ok := <-ch:
+				if !ok {
+					return nil
+				}
+				x, ok := convert(v)
+				if !ok {
+					t.rejected.Add(1)
+					t.log(slog.LevelWarn, "value rejected", "type", fmt.Sprintf("%T", v))
+					continue
+				}
+				emit(x)
+			case <-t.ctx.Done():
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package treeduction_test
+
+import (
+	"testing"
+	"treeduction"
+)
+
+// TestAddAny tests that the values of an untyped channel are converted, and
+// the other ones counted.
+func TestAddAny(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	ch := make(chan any, 4)
+	ch <- 1
+	ch <- "2"
+	ch <- 3
+	ch <- nil
+	close(ch)
+	tree.AddAny(ch, func(v any) (int, bool) {
+		i, ok := v.(int)
+		return i, ok
+	})
+	tree.Finish()
+	if r := <-tree.Output(); r != 4 {
+		t.Errorf("Expected 4, got %d", r)
+	}
+	if n := tree.Stats().Rejected; n != 2 {
+		t.Errorf("Expected 2 values rejected, got %d", n)
+	}
+}
@@ -0,0 +1,109 @@
+package treeduction
+
+// WithAutoBuffer sizes the buffer each node reads its children through from
+// how full it is while the tree runs, instead of the buffer size for every
+// level: the buffer of a node whose children often find it full is doubled,
+// up to 1024 values, and the one of a node that is always empty is halved,
+// down to a single value. A buffer is resized by swapping in a new channel
+// once the node read the old one out, which may pass a value on alone
+// instead of paired. The buffers between the nodes keep their size. It only
+// applies to binary unordered trees without WithMaxWorkers or
+// WithNodeFactory.
+func WithAutoBuffer() Option {
+	return func(o *options) {
+		o.autoBuffer = true
+	}
+}
+
+const (
+	minAutoBuffer = 1
+	maxAutoBuffer = 1024
+	// The values sent to a buffer between two decisions
+	autoSample = 64
+)
+
+// sizer decides the size of a buffer from its fill level when values are
+// sent to it.
+type sizer struct {
+	sends, full, empty int
+}
+
+// observe records a send to a buffer of size n that holds l values, and
+// returns its new size, n if it stays the same.
+func (s *sizer) observe(l, n int) int {
+	s.sends++
+	switch {
+	case l >= n:
+		s.full++
+	case l == 0:
+		s.empty++
+	}
+	if s.sends < autoSample {
+		return n
+	}
+	full, empty := s.full, s.empty
+	*s = sizer{}
+	switch {
+	case full*4 > autoSample && n < maxAutoBuffer:
+		return min(max(2*n, minAutoBuffer), maxAutoBuffer)
+	case empty == autoSample && n > minAutoBuffer:
+		return max(n/2, minAutoBuffer)
+	}
+	return n
+}
+
+// resizable is the buffer of a node, see WithAutoBuffer. The node reads the
+// channels in the order they are swapped in, each until it is closed.
+type resizable[T any] struct {
+	c     chan T
+	next  chan chan T
+	sizer sizer
+}
+
+func (t *tree[T]) newResizable(size int) *resizable[T] {
+	r := &resizable[T]{c: make(chan T, size)}
+	if t.opts.autoBuffer {
+		r.next = make(chan chan T, 1)
+	}
+	t.track(r.c, "")
+	return r
+}
+
+// fill sends v to the buffer, swapping in a channel of another size first if
+// its fill level calls for it.
+func (t *tree[T]) fill(r *resizable[T], v T) {
+	if r.next != nil {
+		if n := r.sizer.observe(len(r.c), cap(r.c)); n != cap(r.c) {
+			// Handed over before the old one is closed, so that the node
+			// finds it once it read the old one out
+			c := make(chan T, n)
+			select {
+			case r.next <- c:
+				t.track(c, "")
+				t.untrack(r.c)
+				close(r.c)
+				r.c = c
+			case <-t.life.Done():
+			}
+		}
+	}
+	t.forward(r.c, v)
+}
+
+func (t *tree[T]) closeResizable(r *resizable[T]) {
+	t.untrack(r.c)
+	close(r.c)
+}
+
+// drain calls read with each channel of the buffer in turn, from first.
+func (r *resizable[T]) drain(first <-chan T, read func(<-chan T)) {
+	for c := first; c != nil; {
+		read(c)
+		select {
+		case next := <-r.next:
+			c = next
+		default:
+			c = nil
+		}
+	}
+}
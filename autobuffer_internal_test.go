@@ -0,0 +1,28 @@
+package treeduction
+
+import "testing"
+
+// TestSizer tests that a buffer found full grows, and that one found empty
+// shrinks.
+func TestSizer(t *testing.T) {
+	var s sizer
+	n := 4
+	for range 3 * autoSample {
+		n = s.observe(n, n)
+	}
+	if n != 32 {
+		t.Errorf("Expected a full buffer to grow to 32, got %d", n)
+	}
+	for range 10 * autoSample {
+		n = s.observe(0, n)
+	}
+	if n != minAutoBuffer {
+		t.Errorf("Expected an empty buffer to shrink to %d, got %d", minAutoBuffer, n)
+	}
+	for range autoSample {
+		n = s.observe(n/2, n)
+	}
+	if n != minAutoBuffer {
+		t.Errorf("Expected a buffer half full to keep its size, got %d", n)
+	}
+}
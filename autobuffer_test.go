@@ -0,0 +1,50 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestAutoBuffer tests that the buffers resized while the tree runs don't
+// lose any value.
+func TestAutoBuffer(t *testing.T) {
+	for _, waitForAll := range []bool{true, false} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 0, waitForAll, false, treeduction.WithAutoBuffer())
+		for range 8 {
+			c := make(chan int)
+			go func() {
+				for i := range 5000 {
+					c <- i
+				}
+				close(c)
+			}()
+			tree.Add(c)
+		}
+
+		want := 8 * 5000 * 4999 / 2
+		sum := 0
+		if !waitForAll {
+			// Finish stops reading the inputs of a streaming tree
+			for sum < want {
+				select {
+				case v := <-tree.Output():
+					sum += v
+				case <-time.After(5 * time.Second):
+					t.Fatalf("Expected %d, got %d", want, sum)
+				}
+			}
+		}
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		for v := range tree.Output() {
+			sum += v
+		}
+		if sum != want {
+			t.Errorf("Expected %d, got %d", want, sum)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package treeduction
+
+import "log/slog"
+
+// Backpressure decides what happens to a result when the output is full.
+type Backpressure int
+
+const (
+	// Block waits for the consumer, stalling the tree (the default).
+	Block Backpressure = iota
+	// DropOldest discards the oldest result waiting in the output.
+	DropOldest
+	// FailWhenFull discards the result and fails the tree with ErrOutputFull.
+	FailWhenFull
+)
+
+// WithNodeBuffer sets the buffer size of the channels inside the tree,
+// instead of bufferSize.
+func WithNodeBuffer(n int) Option {
+	if n < 0 {
+		panic("treeduction: buffer size can't be negative")
+	}
+	return func(o *options) {
+		o.nodeBuffer = n
+	}
+}
+
+// WithBufferPolicy sets the buffer size of the channels of each level of the
+// tree to size(level), instead of one size for all, e.g. to give the leaves
+// large buffers and the few nodes near the root small ones. The inputs are
+// read into the level 0, and the nodes that combine them are on level 1 and
+// up. The channels outside of the levels (like the one of the output) keep
+// their size. It has no effect with WithMaxWorkers, whose nodes queue the
+// values instead.
+func WithBufferPolicy(size func(level int) int) Option {
+	return func(o *options) {
+		o.bufferPolicy = size
+	}
+}
+
+// bufAt returns the buffer size of the channels of a level.
+func (t *tree[T]) bufAt(level int) int {
+	if t.opts.bufferPolicy == nil {
+		return t.bufSize
+	}
+	n := t.opts.bufferPolicy(level)
+	if n < 0 {
+		panic("treeduction: buffer size can't be negative")
+	}
+	return n
+}
+
+// WithOutputBuffer sets the buffer size of the output channel, instead of
+// bufferSize.
+func WithOutputBuffer(n int) Option {
+	if n < 0 {
+		panic("treeduction: buffer size can't be negative")
+	}
+	return func(o *options) {
+		o.outputBuffer = n
+	}
+}
+
+// WithBackpressure sets what happens when a result is ready but the output
+// is full, see Backpressure.
+func WithBackpressure(p Backpressure) Option {
+	return func(o *options) {
+		o.backpressure = p
+	}
+}
+
+// WithDropWhenFull drops the values read from an input when the buffer of
+// its leaf is full (or, with WithMaxWorkers, every worker is busy), instead
+// of waiting for the tree to make room, for a reduction that is lossy but
+// keeps up with its inputs, e.g. of monitoring data. A full node fills the
+// buffers below it, so the values are dropped before they enter the tree,
+// where they can still be counted per input by Stats. The inputs of a
+// Folder aren't read by the leaves, so a Folder never drops values.
+func WithDropWhenFull() Option {
+	return func(o *options) {
+		o.dropWhenFull = true
+	}
+}
+
+// offer puts a value read from an input into its leaf, or drops it if the
+// leaf is full with WithDropWhenFull.
+func (t *tree[T]) offer(c chan<- T, v T, src *source) {
+	if !t.opts.dropWhenFull || src.internal {
+		t.put(c, v)
+		return
+	}
+	select {
+	case c <- v:
+		if m := t.opts.metrics; m != nil {
+			m.QueueDepth(len(c))
+		}
+	default:
+		t.drop(src)
+		t.unhold(t.weight(v))
+	}
+}
+
+// drop forgets a value read from an input that didn't fit in the tree.
+func (t *tree[T]) drop(src *source) {
+	src.dropped.Add(1)
+	t.leave()
+	t.log(slog.LevelDebug, "value dropped")
+}
+
+// send puts a result on the output according to the backpressure policy.
+func (t *tree[T]) send(v T) {
+	// A result that is dropped leaves a gap in the sequence numbers
+	if t.stamp != nil {
+		t.seqMu.Lock()
+		defer t.seqMu.Unlock()
+		t.seq++
+		v = t.stamp(v, t.seq)
+	}
+	if t.opts.backpressure == Block {
+		// Nobody reads the output of a cancelled tree anymore, but what
+		// fits is still sent
+		select {
+		case t.output <- v:
+			t.sent(v)
+		default:
+			select {
+			case t.output <- v:
+				t.sent(v)
+			case <-t.life.Done():
+			}
+		}
+		return
+	}
+
+	for {
+		select {
+		case t.output <- v:
+			t.sent(v)
+			return
+		default:
+		}
+
+		if t.opts.backpressure == FailWhenFull {
+			t.dropped.Add(1)
+			t.fail(ErrOutputFull)
+			return
+		}
+		// Without a buffer there is no oldest result, so this one is dropped
+		if cap(t.output) == 0 {
+			t.dropped.Add(1)
+			t.log(slog.LevelWarn, "result dropped")
+			return
+		}
+		select {
+		case <-t.output:
+			t.dropped.Add(1)
+			t.log(slog.LevelWarn, "result dropped")
+		default:
+		}
+	}
+}
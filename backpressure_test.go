@@ -0,0 +1,239 @@
+package treeduction_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestBuffers tests that the output buffer can be sized separately.
+func TestBuffers(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithNodeBuffer(100), treeduction.WithOutputBuffer(3))
+	defer tree.Finish()
+
+	if c := cap(tree.Output()); c != 3 {
+		t.Errorf("Expected output capacity to be 3, got %d", c)
+	}
+}
+
+// TestDropOldest tests that a full output keeps the newest results.
+func TestDropOldest(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithOutputBuffer(2), treeduction.WithBackpressure(treeduction.DropOldest))
+
+	ch := make(chan int, 5)
+	for i := 1; i <= 5; i++ {
+		ch <- i
+	}
+	close(ch)
+	tree.Add(ch)
+
+	// Nobody reads the output while the values come in
+	time.Sleep(50 * time.Millisecond)
+	if err := tree.Finish(); err != nil {
+		t.Errorf("Unexpected error from Finish(): %v", err)
+	}
+
+	results := []int{}
+	for v := range tree.Output() {
+		results = append(results, v)
+	}
+	if !equal(results, []int{4, 5}) {
+		t.Errorf("Expected results to be %v, got %v", []int{4, 5}, results)
+	}
+}
+
+// TestFailWhenFull tests that a full output fails the tree.
+func TestFailWhenFull(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithOutputBuffer(1), treeduction.WithBackpressure(treeduction.FailWhenFull))
+
+	ch := make(chan int, 3)
+	for i := 1; i <= 3; i++ {
+		ch <- i
+	}
+	close(ch)
+	tree.Add(ch)
+
+	time.Sleep(50 * time.Millisecond)
+	if err := tree.Finish(); !errors.Is(err, treeduction.ErrOutputFull) {
+		t.Errorf("Expected ErrOutputFull from Finish(), got %v", err)
+	}
+	if result := <-tree.Output(); result != 1 {
+		t.Errorf("Expected result to be 1, got %d", result)
+	}
+}
+
+// TestBackpressureWaitForAll tests that the policies only apply to the
+// results emitted to the consumer, not to the partials inside a waitForAll
+// tree.
+func TestBackpressureWaitForAll(t *testing.T) {
+	for _, opts := range [][]treeduction.Option{
+		{treeduction.WithOutputBuffer(1), treeduction.WithBackpressure(treeduction.DropOldest)},
+		{treeduction.WithOutputBuffer(1), treeduction.WithBackpressure(treeduction.FailWhenFull)},
+		{treeduction.WithOutputBuffer(0)},
+	} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false, opts...)
+
+		for _, v := range []int{1, 2, 4} {
+			ch := make(chan int, 1)
+			ch <- v
+			close(ch)
+			tree.Add(ch)
+		}
+
+		if err := tree.Finish(); err != nil {
+			t.Errorf("Expected no error from Finish(), got %v", err)
+		}
+		if result := <-tree.Output(); result != 7 {
+			t.Errorf("Expected result to be 7, got %d", result)
+		}
+	}
+}
+
+// TestDropWhenFull tests that the values that don't fit are dropped and
+// counted instead of blocking the input.
+func TestDropWhenFull(t *testing.T) {
+	for _, opts := range [][]treeduction.Option{nil, {treeduction.WithMaxWorkers(2)}} {
+		gate := make(chan struct{})
+		tree := treeduction.New(func(a, b int) int {
+			<-gate
+			return a + b
+		}, 1, true, false, append(opts, treeduction.WithDropWhenFull())...)
+
+		in := make(chan int, 100)
+		for range 100 {
+			in <- 1
+		}
+		tree.Add(in)
+		s := tree.Stats()
+		for deadline := time.Now().Add(time.Second); s.Sources[in].Read < 100 && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+			s = tree.Stats()
+		}
+		dropped := s.Sources[in].Dropped
+		if s.Sources[in].Read != 100 || dropped == 0 {
+			t.Errorf("Expected the input to be read with values dropped, got %+v", s.Sources[in])
+		}
+
+		close(gate)
+		close(in)
+		tree.Finish()
+		if r := <-tree.Output(); r != 100-int(dropped) {
+			t.Errorf("Expected the sum of the values kept to be %d, got %d", 100-dropped, r)
+		}
+	}
+}
+
+// TestUnbuffered tests that a tree without buffers reduces every value, in
+// all of its modes.
+func TestUnbuffered(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []treeduction.Option
+	}{
+		{"default", nil},
+		{"pool", []treeduction.Option{treeduction.WithMaxWorkers(2)}},
+		{"batch", []treeduction.Option{treeduction.WithBatchSize(4)}},
+		{"concurrency", []treeduction.Option{treeduction.WithCombinerConcurrency(3)}},
+		{"in flight", []treeduction.Option{treeduction.WithMaxInFlight(2)}},
+		{"pairing timeout", []treeduction.Option{treeduction.WithPairingTimeout(time.Millisecond)}},
+	} {
+		for _, waitForAll := range []bool{true, false} {
+			for _, ordered := range []bool{true, false} {
+				tree := treeduction.New(func(a, b int) int {
+					return a + b
+				}, 0, waitForAll, ordered, tt.opts...)
+
+				// Without waitForAll, the output has to be read while the
+				// values flow
+				const sum = 140 * 139 / 2
+				got := make(chan int)
+				all := make(chan struct{})
+				go func() {
+					n := 0
+					for v := range tree.Output() {
+						if n += v; n == sum {
+							close(all)
+						}
+					}
+					got <- n
+				}()
+
+				for i := range 7 {
+					ch := make(chan int)
+					tree.Add(ch)
+					go func() {
+						for j := range 20 {
+							ch <- i*20 + j
+						}
+						close(ch)
+					}()
+				}
+
+				// Finish discards what didn't reach the output yet, so the
+				// streaming trees are only finished once everything did
+				if !waitForAll {
+					select {
+					case <-all:
+					case <-time.After(5 * time.Second):
+						t.Fatalf("%s (ordered %t): the values didn't reach the output", tt.name, ordered)
+					}
+				}
+				tree.Finish()
+				if n := <-got; n != sum {
+					t.Errorf("%s (waitForAll %t, ordered %t): expected %d, got %d", tt.name, waitForAll, ordered, sum, n)
+				}
+			}
+		}
+	}
+}
+
+// TestNegativeBuffer tests that a negative buffer size is rejected.
+func TestNegativeBuffer(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+	treeduction.New(func(a, b int) int { return a + b }, -1, true, false)
+}
+
+// TestBufferPolicy tests that the channels of each level get the size set
+// by the policy.
+func TestBufferPolicy(t *testing.T) {
+	for _, s := range []treeduction.Strategy{treeduction.Binary, treeduction.KAry(4)} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, false, false, treeduction.WithStrategy(s), treeduction.WithBufferPolicy(func(level int) int {
+			return 7 - 2*level
+		}))
+		inputs := make([]chan int, 4)
+		for i := range inputs {
+			inputs[i] = make(chan int)
+			tree.Add(inputs[i])
+		}
+
+		var b strings.Builder
+		if err := tree.Dump(&b); err != nil {
+			t.Fatal(err)
+		}
+		for _, label := range []string{"input 0/7", "unordered 0/5", "unordered 0/3"} {
+			if !strings.Contains(b.String(), label) {
+				t.Errorf("Expected %q with strategy %d in %s", label, s, b.String())
+			}
+		}
+		for _, c := range inputs {
+			close(c)
+		}
+		tree.Finish()
+	}
+}
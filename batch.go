@@ -0,0 +1,33 @@
+package treeduction
+
+// WithBatchSize lets an unordered node fold up to n values that are already
+// waiting in its fan-in into one result, instead of combining them in pairs
+// and sending every intermediate value on. This saves most of the channel
+// operations for cheap combiners like int addition. It has no effect in
+// ordered mode or with the options that need every value on its own, like
+// WithScan and the count windows.
+func WithBatchSize(n int) Option {
+	if n < 2 {
+		panic("treeduction: batch size must be at least 2")
+	}
+	return func(o *options) {
+		o.batchSize = n
+	}
+}
+
+// batch folds into acc the values already buffered in c, up to a batch of n
+// values in total. n counts the values already folded into acc.
+func (t *tree[T]) batch(acc T, c <-chan T, n int) T {
+	for size := t.batchSize(); n < size; n++ {
+		select {
+		case v, ok := <-c:
+			if !ok {
+				return acc
+			}
+			acc = t.combine(acc, v)
+		default:
+			return acc
+		}
+	}
+	return acc
+}
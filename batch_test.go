@@ -0,0 +1,54 @@
+package treeduction_test
+
+import (
+	"testing"
+	"treeduction"
+)
+
+// TestBatchSize tests that batching gives the same result with waitForAll.
+func TestBatchSize(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 100, true, false, treeduction.WithBatchSize(16))
+
+	for range 8 {
+		tree.AddValues(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	}
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 440 {
+		t.Errorf("Expected 440, got %d", v)
+	}
+}
+
+// TestBatchSizeStreaming tests that every value is counted once without
+// waitForAll.
+func TestBatchSizeStreaming(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 100, false, false, treeduction.WithBatchSize(8))
+
+	inputs := make([]<-chan int, 16)
+	for i := range inputs {
+		c := make(chan int, 100)
+		for range 100 {
+			c <- 1
+		}
+		close(c)
+		inputs[i] = c
+	}
+	tree.Add(inputs...)
+	sum, n := 0, 0
+	for sum < 1600 {
+		sum += <-tree.Output()
+		n++
+	}
+	tree.Finish()
+	if sum != 1600 {
+		t.Errorf("Expected 1600, got %d", sum)
+	}
+	if n >= 800 {
+		t.Errorf("Expected the values to be combined, got %d outputs", n)
+	}
+}
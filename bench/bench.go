@@ -0,0 +1,188 @@
+// Package bench measures reduction trees on synthetic workloads, against a
+// mutex-guarded accumulator, and recommends the settings that suit a
+// workload. The benchmarks of the package sweep the fan-in, the cost of the
+// combiner, the buffer size and the arity of the tree:
+//
+//	go test -bench . treeduction/bench
+package bench
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"treeduction"
+)
+
+// Workload describes a reduction: Inputs channels of Values values each,
+// combined by a combiner that keeps the CPU busy for Cost.
+type Workload struct {
+	Inputs int
+	Values int
+	Cost   time.Duration
+}
+
+// Config is a way to build the tree, with a name to report it by.
+type Config struct {
+	Name    string
+	Buffer  int
+	Options []treeduction.Option
+}
+
+// Result is the time a tree took to reduce a workload.
+type Result struct {
+	Config   Config
+	Duration time.Duration
+}
+
+// combiner returns a sum that spins for the cost of the workload.
+func (w Workload) combiner() func(a, b int) int {
+	return func(a, b int) int {
+		if w.Cost > 0 {
+			for start := time.Now(); time.Since(start) < w.Cost; {
+			}
+		}
+		return a + b
+	}
+}
+
+// inputs returns the channels of the workload, filled and closed.
+func (w Workload) inputs() []<-chan int {
+	inputs := make([]<-chan int, w.Inputs)
+	for i := range inputs {
+		c := make(chan int, w.Values)
+		for range w.Values {
+			c <- 1
+		}
+		close(c)
+		inputs[i] = c
+	}
+	return inputs
+}
+
+// Run reduces the workload once with a waitForAll tree built from c, and
+// returns the time it took from the first Add to the result. The channels
+// are filled beforehand, so only the tree is measured.
+func Run(w Workload, c Config) (time.Duration, error) {
+	inputs := w.inputs()
+	tree := treeduction.New(w.combiner(), c.Buffer, true, false, c.Options...)
+
+	start := time.Now()
+	if err := tree.Add(inputs...); err != nil {
+		return 0, err
+	}
+	if err := tree.Finish(); err != nil {
+		return 0, err
+	}
+	v := <-tree.Output()
+	d := time.Since(start)
+	if want := w.Inputs * w.Values; v != want {
+		return d, fmt.Errorf("bench: expected %d, got %d", want, v)
+	}
+	return d, nil
+}
+
+// Mutex reduces the workload with a goroutine per input folding its values
+// into an accumulator guarded by a mutex, the baseline a tree should beat.
+func Mutex(w Workload) time.Duration {
+	inputs := w.inputs()
+	combiner := w.combiner()
+
+	start := time.Now()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	acc := 0
+	for _, in := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range in {
+				mu.Lock()
+				acc = combiner(acc, v)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return time.Since(start)
+}
+
+// Configs returns the settings that Recommend compares.
+func Configs() []Config {
+	workers := runtime.GOMAXPROCS(0)
+	return []Config{
+		{Name: "default", Buffer: 10},
+		{Name: "unbuffered", Buffer: 0},
+		{Name: "buffer 256", Buffer: 256},
+		{Name: "4-ary", Buffer: 10, Options: []treeduction.Option{treeduction.WithStrategy(treeduction.KAry(4))}},
+		{Name: "16-ary", Buffer: 10, Options: []treeduction.Option{treeduction.WithStrategy(treeduction.KAry(16))}},
+		{Name: "flat", Buffer: 10, Options: []treeduction.Option{treeduction.WithStrategy(treeduction.Flat)}},
+		{Name: "batch 64", Buffer: 10, Options: []treeduction.Option{treeduction.WithBatchSize(64)}},
+		{Name: "adaptive batching", Buffer: 10, Options: []treeduction.Option{treeduction.WithAdaptiveBatching()}},
+		{Name: fmt.Sprintf("%d workers", workers), Buffer: 10, Options: []treeduction.Option{treeduction.WithMaxWorkers(workers)}},
+	}
+}
+
+// Report compares the settings of Configs on a workload.
+type Report struct {
+	Workload Workload
+	Results  []Result
+	Mutex    time.Duration
+}
+
+// Recommend runs the workload rounds times with every setting of Configs and
+// with the mutex baseline, keeping the best time of each.
+func Recommend(w Workload, rounds int) (Report, error) {
+	r := Report{Workload: w}
+	for _, c := range Configs() {
+		best := time.Duration(0)
+		for range rounds {
+			d, err := Run(w, c)
+			if err != nil {
+				return r, fmt.Errorf("%s: %w", c.Name, err)
+			}
+			if best == 0 || d < best {
+				best = d
+			}
+		}
+		r.Results = append(r.Results, Result{Config: c, Duration: best})
+	}
+	for range rounds {
+		if d := Mutex(w); r.Mutex == 0 || d < r.Mutex {
+			r.Mutex = d
+		}
+	}
+	return r, nil
+}
+
+// Best returns the fastest setting.
+func (r Report) Best() Result {
+	var best Result
+	for _, res := range r.Results {
+		if best.Duration == 0 || res.Duration < best.Duration {
+			best = res
+		}
+	}
+	return best
+}
+
+// Print writes the times of the report and the recommended setting to w.
+func (r Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "%d inputs of %d values, combiner cost %v\n", r.Workload.Inputs, r.Workload.Values, r.Workload.Cost)
+	for _, res := range r.Results {
+		fmt.Fprintf(tw, "%s\t%v\n", res.Config.Name, res.Duration)
+	}
+	fmt.Fprintf(tw, "mutex\t%v\n", r.Mutex)
+
+	best := r.Best()
+	if best.Duration < r.Mutex {
+		fmt.Fprintf(tw, "recommended: %s, %.1fx faster than the mutex\n", best.Config.Name, float64(r.Mutex)/float64(best.Duration))
+	} else {
+		fmt.Fprintf(tw, "recommended: a mutex-guarded accumulator, the best tree (%s) is %.1fx slower\n", best.Config.Name, float64(best.Duration)/float64(r.Mutex))
+	}
+	return tw.Flush()
+}
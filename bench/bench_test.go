@@ -0,0 +1,71 @@
+package bench_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"treeduction"
+	"treeduction/bench"
+)
+
+// TestRecommend tests that every setting reduces the workload correctly and
+// that the report names one of them.
+func TestRecommend(t *testing.T) {
+	r, err := bench.Recommend(bench.Workload{Inputs: 8, Values: 10}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Results) != len(bench.Configs()) {
+		t.Errorf("Expected a result per setting, got %d", len(r.Results))
+	}
+
+	var b strings.Builder
+	if err := r.Print(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "recommended: ") {
+		t.Errorf("Expected a recommendation, got %q", b.String())
+	}
+}
+
+func BenchmarkTree(b *testing.B) {
+	strategies := []struct {
+		name     string
+		strategy treeduction.Strategy
+	}{
+		{"binary", treeduction.Binary},
+		{"8-ary", treeduction.KAry(8)},
+		{"flat", treeduction.Flat},
+	}
+	for _, inputs := range []int{4, 64, 1024} {
+		for _, cost := range []time.Duration{0, 10 * time.Microsecond} {
+			for _, buffer := range []int{0, 16} {
+				for _, s := range strategies {
+					w := bench.Workload{Inputs: inputs, Values: 100, Cost: cost}
+					c := bench.Config{Buffer: buffer, Options: []treeduction.Option{treeduction.WithStrategy(s.strategy)}}
+					b.Run(fmt.Sprintf("inputs=%d/cost=%v/buffer=%d/%s", inputs, cost, buffer, s.name), func(b *testing.B) {
+						for range b.N {
+							if _, err := bench.Run(w, c); err != nil {
+								b.Fatal(err)
+							}
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkMutex(b *testing.B) {
+	for _, inputs := range []int{4, 64, 1024} {
+		for _, cost := range []time.Duration{0, 10 * time.Microsecond} {
+			w := bench.Workload{Inputs: inputs, Values: 100, Cost: cost}
+			b.Run(fmt.Sprintf("inputs=%d/cost=%v", inputs, cost), func(b *testing.B) {
+				for range b.N {
+					bench.Mutex(w)
+				}
+			})
+		}
+	}
+}
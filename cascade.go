@@ -0,0 +1,65 @@
+package treeduction
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// AddTree reduces the results of sub like the values of an input, and links
+// the lifecycle of sub to the one of the tree, for multi-stage reductions
+// (per host, then per rack, then global): Finish and FinishContext finish
+// sub first, Abort aborts it, and so does the failure of the tree. The
+// error sub finishes with is handled like the error of an input added with
+// AddWithErr, see SourcePolicy.
+func (t *tree[T]) AddTree(sub Tree[T]) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished.Load() {
+		t.fail(ErrFinished)
+		return ErrFinished
+	}
+	t.add([]<-chan T{sub.Output()}, 0)
+	t.subtrees = append(t.subtrees, sub)
+
+	t.spawn(func() {
+		<-t.ctx.Done()
+		if t.life.Err() != nil {
+			sub.Abort()
+		}
+	})
+	return nil
+}
+
+// finishSubtrees finishes the trees added with AddTree, which stop reading
+// their inputs if the tree is cancelled meanwhile.
+func (t *tree[T]) finishSubtrees() {
+	t.mu.Lock()
+	subs := t.subtrees
+	t.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, sub := range subs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := sub.FinishContext(t.ctx)
+			// Stopping sub early isn't a failure of its own
+			if err == nil || errors.Is(err, ErrFinished) || t.ctx.Err() != nil && errors.Is(err, context.Canceled) {
+				return
+			}
+			t.sourceFailed(err, func() {})
+		}()
+	}
+	wg.Wait()
+}
+
+// abortSubtrees aborts the trees added with AddTree.
+func (t *tree[T]) abortSubtrees() {
+	t.mu.Lock()
+	subs := t.subtrees
+	t.mu.Unlock()
+	for _, sub := range subs {
+		sub.Abort()
+	}
+}
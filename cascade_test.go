@@ -0,0 +1,89 @@
+package treeduction_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+func sum(a, b int) int {
+	return a + b
+}
+
+// TestAddTree tests that finishing a tree finishes the trees added to it,
+// and reduces their results.
+func TestAddTree(t *testing.T) {
+	global := treeduction.New(sum, 10, true, false)
+	racks := make([]treeduction.Tree[int], 3)
+	for i := range racks {
+		racks[i] = treeduction.New(sum, 10, true, false)
+		racks[i].AddValues(1, 2, 3)
+		global.AddTree(racks[i])
+	}
+
+	if err := global.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-global.Output(); v != 18 {
+		t.Errorf("Expected 18, got %d", v)
+	}
+	for _, rack := range racks {
+		if err := rack.Finish(); !errors.Is(err, treeduction.ErrFinished) {
+			t.Errorf("Expected the rack to be finished, got %v", err)
+		}
+	}
+}
+
+// TestAddTreeError tests that the error of a tree added to another one is
+// reported by the other one.
+func TestAddTreeError(t *testing.T) {
+	errRack := errors.New("rack failed")
+	global := treeduction.New(sum, 10, true, false)
+	rack := treeduction.NewFallible(func(a, b int) (int, error) {
+		return 0, errRack
+	}, 10, true, false)
+	rack.AddValues(1, 2)
+	global.AddTree(rack)
+
+	if err := global.Finish(); !errors.Is(err, errRack) {
+		t.Errorf("Expected %v, got %v", errRack, err)
+	}
+}
+
+// TestAddTreeAbort tests that aborting a tree aborts the trees added to it.
+func TestAddTreeAbort(t *testing.T) {
+	global := treeduction.New(sum, 10, true, false)
+	rack := treeduction.New(sum, 10, true, false)
+	rack.Add(make(chan int))
+	global.AddTree(rack)
+
+	if err := global.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-rack.Output(); ok {
+		t.Error("Expected the output of the rack to be closed")
+	}
+}
+
+// TestAddTreeCancel tests that cancelling a tree aborts the trees added to
+// it.
+func TestAddTreeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	global := treeduction.New(sum, 10, true, false, treeduction.WithContext(ctx))
+	rack := treeduction.New(sum, 10, true, false)
+	rack.Add(make(chan int))
+	global.AddTree(rack)
+
+	cancel()
+	select {
+	case _, ok := <-rack.Output():
+		if ok {
+			t.Error("Expected the output of the rack to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the rack to be aborted")
+	}
+	global.Finish()
+}
@@ -0,0 +1,92 @@
+package treeduction
+
+import (
+	"io"
+	"time"
+)
+
+// Checkpoint writes the partial result of a waitForAll tree to w, so that a
+// job that crashed can Restore it instead of reading every input again. It
+// pauses the tree and waits for the values read so far to reach the root,
+// so the checkpoint covers exactly the values read from each input: pause
+// the tree first to read Stats().Consumed (e.g. the offsets to resume the
+// inputs from) before it is resumed. Otherwise Checkpoint resumes the tree
+// when it is done. Only unordered trees without WithMaxWorkers, where the
+// nodes can pass a value on alone, can be checkpointed, ErrNoCheckpoint is
+// returned for the others.
+func (t *tree[T]) Checkpoint(w io.Writer, codec Codec[T]) error {
+	if !t.checkpointable() {
+		return ErrNoCheckpoint
+	}
+	t.mu.Lock()
+	t.replayWAL()
+	t.mu.Unlock()
+	if t.paused() == nil {
+		t.Pause()
+		defer t.Resume()
+	}
+
+	if !t.quiesce() {
+		return t.error()
+	}
+
+	acc, ok := t.final()
+	if !ok {
+		if _, err := w.Write([]byte{0}); err != nil {
+			return err
+		}
+	} else {
+		if _, err := w.Write([]byte{1}); err != nil {
+			return err
+		}
+		if err := codec.Encode(w, acc); err != nil {
+			return err
+		}
+	}
+	// The values logged so far are covered by the checkpoint
+	if t.wal != nil {
+		return t.wal.truncate()
+	}
+	return nil
+}
+
+// Restore reads a checkpoint written by Checkpoint from r, and folds its
+// partial result into the tree.
+func (t *tree[T]) Restore(r io.Reader, codec Codec[T]) error {
+	if !t.checkpointable() {
+		return ErrNoCheckpoint
+	}
+
+	var ok [1]byte
+	if _, err := io.ReadFull(r, ok[:]); err != nil {
+		return err
+	}
+	if ok[0] == 0 {
+		return nil
+	}
+	v, err := codec.Decode(r)
+	if err != nil {
+		return err
+	}
+	t.fold(v)
+	return nil
+}
+
+// quiesce waits for the readers of a paused tree to park and for the values
+// read so far to reach the root. It reports false if the tree is done first.
+func (t *tree[T]) quiesce() bool {
+	tick := time.NewTicker(time.Millisecond)
+	defer tick.Stop()
+	for t.parked.Load() < t.readers.Load() || t.alive.Load() > 0 {
+		select {
+		case <-tick.C:
+		case <-t.life.Done():
+			return false
+		}
+	}
+	return true
+}
+
+func (t *tree[T]) checkpointable() bool {
+	return t.waitForAll && !t.ordered && t.pool == nil
+}
@@ -0,0 +1,87 @@
+package treeduction_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"treeduction"
+)
+
+// TestCheckpoint tests restoring the partial result of a running tree into
+// a new one.
+func TestCheckpoint(t *testing.T) {
+	sum := func(a, b int) int {
+		return a + b
+	}
+	tree := treeduction.New(sum, 10, true, false)
+	c1 := make(chan int)
+	c2 := make(chan int)
+	tree.Add(c1, c2)
+	for i := range 5 {
+		c1 <- i + 1
+		c2 <- 10
+	}
+
+	var buf bytes.Buffer
+	if err := tree.Checkpoint(&buf, treeduction.GobCodec[int]{}); err != nil {
+		t.Fatal(err)
+	}
+	c1 <- 100
+	close(c1)
+	close(c2)
+	tree.Finish()
+	if v := <-tree.Output(); v != 165 {
+		t.Errorf("Expected 165, got %d", v)
+	}
+
+	restored := treeduction.New(sum, 10, true, false)
+	if err := restored.Restore(&buf, treeduction.GobCodec[int]{}); err != nil {
+		t.Fatal(err)
+	}
+	restored.AddValues(100)
+	restored.Finish()
+	if v := <-restored.Output(); v != 165 {
+		t.Errorf("Expected the restored tree to give 165, got %d", v)
+	}
+}
+
+// TestCheckpointFolder tests checkpointing a tree with accumulators.
+func TestCheckpointFolder(t *testing.T) {
+	tree := treeduction.NewCounted(func(a, b int) int {
+		return a + b
+	}, 0, true, false)
+	c := make(chan int)
+	tree.Add(c)
+	for _, v := range []int{1, 2, 10, 10, 10} {
+		c <- v
+	}
+
+	var buf bytes.Buffer
+	codec := treeduction.GobCodec[treeduction.Reduced[int]]{}
+	if err := tree.Checkpoint(&buf, codec); err != nil {
+		t.Fatal(err)
+	}
+	close(c)
+	tree.Finish()
+
+	restored := treeduction.NewCounted(func(a, b int) int {
+		return a + b
+	}, 0, true, false)
+	restored.Restore(&buf, codec)
+	restored.Finish()
+	if r := <-restored.Output(); r.Value != 33 || r.Count != 5 {
+		t.Errorf("Expected 33 over 5 values, got %v", r)
+	}
+}
+
+// TestCheckpointOrdered tests that ordered trees can't be checkpointed.
+func TestCheckpointOrdered(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, true)
+	var buf bytes.Buffer
+	if err := tree.Checkpoint(&buf, treeduction.GobCodec[int]{}); !errors.Is(err, treeduction.ErrNoCheckpoint) {
+		t.Errorf("Expected ErrNoCheckpoint, got %v", err)
+	}
+	tree.Finish()
+}
@@ -0,0 +1,200 @@
+package treeduction
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// WithClassifier routes the values read from the inputs to a reduction per
+// class, as returned by classify, each with an output of its own (see
+// OutputFor) instead of the output of the tree, which stays empty: e.g. a
+// result per log level out of the same inputs, without a tree per level to
+// manage. The reduction of a class is a tree made with the combiner, buffer
+// size, mode and options of the tree, created on the first value of the
+// class or the first call to OutputFor, and finished or aborted with the
+// tree. Finish returns the errors of the classes as well. WithSink,
+// WithOutputPartitioner and WithWAL have no effect.
+func WithClassifier[T any](classify func(T) string) TypedOption[T] {
+	return func(_ *options, ty *typed[T]) {
+		ty.classify = classify
+	}
+}
+
+// classes are the reductions of a tree with WithClassifier.
+type classes[T any] struct {
+	mu      sync.Mutex
+	m       map[string]*class[T]
+	closed  bool
+	routers sync.WaitGroup
+}
+
+// class is the reduction of a class, fed by a single input.
+type class[T any] struct {
+	tree *tree[T]
+	in   chan T
+}
+
+// class returns the reduction of a class, created if it doesn't exist yet.
+// Once the tree finished, a new class gets a tree without inputs.
+func (t *tree[T]) class(name string) *class[T] {
+	cs := t.classes
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if c, ok := cs.m[name]; ok {
+		return c
+	}
+	o := t.opts
+	o.walDir = ""
+	o.deferredStart = false
+	o.ctx = t.life
+	o.nodeBuffer, o.outputBuffer = t.bufSize, cap(t.output)
+	// The typed options of the tree, but the ones that route its results
+	ty := typed[T]{
+		filter:    t.filter,
+		dedupKey:  t.dedupKey,
+		equal:     t.equal,
+		hook:      t.hook,
+		newNode:   t.newNode,
+		satisfied: t.satisfied,
+		padding:   t.padding,
+		weigh:     t.weigh,
+		beat:      t.beat,
+	}
+	sub := newTree(t.bufSize, t.waitForAll, t.ordered, []Option{func(so *options) {
+		*so = o
+	}}, []TypedOption[T]{func(_ *options, sty *typed[T]) {
+		*sty = ty
+	}})
+	sub.combiner = t.combiner
+	c := &class[T]{tree: sub, in: make(chan T)}
+	if cs.closed {
+		sub.Finish()
+	} else {
+		sub.Add(c.in)
+	}
+	cs.m[name] = c
+	return c
+}
+
+// OutputFor returns the output of the reduction of a class, see
+// WithClassifier. It returns nil without WithClassifier.
+func (t *tree[T]) OutputFor(class string) <-chan T {
+	if t.classes == nil {
+		return nil
+	}
+	return t.class(class).tree.Output()
+}
+
+// Classes returns the classes seen so far, sorted, see WithClassifier.
+func (t *tree[T]) Classes() []string {
+	if t.classes == nil {
+		return nil
+	}
+	t.classes.mu.Lock()
+	defer t.classes.mu.Unlock()
+	names := make([]string, 0, len(t.classes.m))
+	for name := range t.classes.m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// addClassified starts a goroutine per input routing its values to their
+// classes.
+func (t *tree[T]) addClassified(out []<-chan T) {
+	for _, o := range out {
+		src := t.attach(o)
+		t.classes.routers.Add(1)
+		t.spawn(func() {
+			defer t.classes.routers.Done()
+			defer t.forget(o, src)
+			for {
+				select {
+				case v, ok := <-o:
+					if !ok {
+						return
+					}
+					t.consume(src)
+					t.route(v)
+				case <-src.detach:
+					// The values buffered in a removed input are still
+					// routed
+					for {
+						select {
+						case v, ok := <-o:
+							if !ok {
+								return
+							}
+							t.consume(src)
+							t.route(v)
+						default:
+							return
+						}
+					}
+				case <-t.ctx.Done():
+					return
+				}
+			}
+		}, "role", "router", "input", strconv.Itoa(src.n))
+	}
+}
+
+// route hands a value over to the reduction of its class.
+func (t *tree[T]) route(v T) {
+	c := t.class(t.classify(v))
+	select {
+	case c.in <- v:
+	case <-c.tree.ctx.Done():
+	}
+	t.leave()
+}
+
+// finishClassified finishes the reductions of the classes once the inputs
+// are done, then the tree.
+func (t *tree[T]) finishClassified() error {
+	if !t.waitForAll {
+		t.cancel()
+	}
+	t.classes.routers.Wait()
+	errs := []error{nil}
+	for _, c := range t.closeClasses() {
+		close(c.in)
+		errs = append(errs, c.tree.Finish())
+	}
+	t.closeOutput()
+	errs[0] = t.error()
+	return errors.Join(errs...)
+}
+
+// abortClassified aborts the reductions of the classes, which lets the
+// routers go.
+func (t *tree[T]) abortClassified() {
+	if t.classes == nil {
+		return
+	}
+	subs := t.closeClasses()
+	for _, c := range subs {
+		c.tree.Abort()
+	}
+	t.classes.routers.Wait()
+	for _, c := range subs {
+		close(c.in)
+	}
+}
+
+// closeClasses makes the classes created from now on start finished, and
+// returns the others.
+func (t *tree[T]) closeClasses() []*class[T] {
+	cs := t.classes
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.closed = true
+	subs := make([]*class[T], 0, len(cs.m))
+	for _, c := range cs.m {
+		subs = append(subs, c)
+	}
+	return subs
+}
@@ -0,0 +1,67 @@
+package treeduction_test
+
+import (
+	"slices"
+	"testing"
+	"treeduction"
+)
+
+// TestClassifier tests that the values of the inputs are reduced per
+// class, each on an output of its own.
+func TestClassifier(t *testing.T) {
+	tree := treeduction.With(treeduction.WithClassifier(func(v int) string {
+		if v%2 == 0 {
+			return "even"
+		}
+		return "odd"
+	})).New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	tree.AddValues(1, 2, 3, 4)
+	tree.AddValues(5, 6, 7)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if classes := tree.Classes(); !slices.Equal(classes, []string{"even", "odd"}) {
+		t.Errorf("Expected even and odd, got %v", classes)
+	}
+	if v := <-tree.OutputFor("even"); v != 12 {
+		t.Errorf("Expected 12 for even, got %d", v)
+	}
+	if v := <-tree.OutputFor("odd"); v != 16 {
+		t.Errorf("Expected 16 for odd, got %d", v)
+	}
+	if _, ok := <-tree.Output(); ok {
+		t.Error("Expected an empty output")
+	}
+	// A class without values is finished already
+	if _, ok := <-tree.OutputFor("none"); ok {
+		t.Error("Expected an empty output for a class without values")
+	}
+}
+
+// TestClassifierStreaming tests that the results of a class come out while
+// the tree runs, and that Abort stops the classes.
+func TestClassifierStreaming(t *testing.T) {
+	tree := treeduction.With(treeduction.WithClassifier(func(s string) string {
+		return s[:1]
+	})).New(func(a, b string) string {
+		return a + b
+	}, 0, false, false)
+	c := make(chan string)
+	tree.Add(c)
+	c <- "a1"
+	if v := <-tree.OutputFor("a"); v != "a1" {
+		t.Errorf("Expected a1, got %q", v)
+	}
+	c <- "b1"
+	if v := <-tree.OutputFor("b"); v != "b1" {
+		t.Errorf("Expected b1, got %q", v)
+	}
+	// Nobody reads the output of c
+	c <- "c1"
+	if err := tree.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	<-tree.Done()
+}
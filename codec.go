@@ -0,0 +1,44 @@
+package treeduction
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+)
+
+// Codec encodes the values of a tree wherever they leave the process:
+// Checkpoint and Restore, WithWAL, and the streams of package remote. Decode
+// may read r past the value Encode wrote, the callers frame the values that
+// follow each other. GobCodec and JSONCodec are built in, any other encoding
+// (e.g. protobuf) only needs these two methods.
+type Codec[T any] interface {
+	Encode(w io.Writer, v T) error
+	Decode(r io.Reader) (T, error)
+}
+
+// GobCodec is a Codec with encoding/gob.
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(w io.Writer, v T) error {
+	return gob.NewEncoder(w).Encode(v)
+}
+
+func (GobCodec[T]) Decode(r io.Reader) (T, error) {
+	var v T
+	err := gob.NewDecoder(r).Decode(&v)
+	return v, err
+}
+
+// JSONCodec is a Codec with encoding/json, for values read by other
+// languages or by people.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(w io.Writer, v T) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (JSONCodec[T]) Decode(r io.Reader) (T, error) {
+	var v T
+	err := json.NewDecoder(r).Decode(&v)
+	return v, err
+}
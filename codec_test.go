@@ -0,0 +1,52 @@
+package treeduction_test
+
+import (
+	"bytes"
+	"testing"
+	"treeduction"
+)
+
+type point struct {
+	X, Y int
+}
+
+func addPoints(a, b point) point {
+	return point{a.X + b.X, a.Y + b.Y}
+}
+
+// TestCodecs tests that the built-in codecs restore a checkpoint and replay
+// a log of several values.
+func TestCodecs(t *testing.T) {
+	for name, codec := range map[string]treeduction.Codec[point]{
+		"gob":  treeduction.GobCodec[point]{},
+		"json": treeduction.JSONCodec[point]{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			tree := treeduction.With(treeduction.WithWAL(dir, codec)).New(addPoints, 10, true, false)
+			tree.AddValues(point{1, 2}, point{3, 4})
+			var buf bytes.Buffer
+			if err := tree.Checkpoint(&buf, codec); err != nil {
+				t.Fatal(err)
+			}
+			tree.AddValues(point{10, 20}, point{30, 40})
+			tree.Finish()
+			want := <-tree.Output()
+
+			// The log holds the values read after the checkpoint
+			vs, err := treeduction.ReadWAL(dir, codec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			restored := treeduction.New(addPoints, 10, true, false)
+			if err := restored.Restore(&buf, codec); err != nil {
+				t.Fatal(err)
+			}
+			restored.AddSlice(vs)
+			restored.Finish()
+			if v := <-restored.Output(); v != want || v != (point{44, 66}) {
+				t.Errorf("Expected %v, got %v", want, v)
+			}
+		})
+	}
+}
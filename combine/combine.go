@@ -0,0 +1,108 @@
+// Package combine has ready-made combiners for reduction trees. Each one
+// documents its identity, the value that leaves the other side unchanged.
+package combine
+
+import (
+	"cmp"
+	"treeduction"
+)
+
+// Number is a type that can be added.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Sum adds a and b. Its identity is 0.
+func Sum[T Number](a, b T) T {
+	return a + b
+}
+
+// Min returns the smaller of a and b. Its identity is the largest value of T.
+func Min[T cmp.Ordered](a, b T) T {
+	return min(a, b)
+}
+
+// Max returns the larger of a and b. Its identity is the smallest value of T.
+func Max[T cmp.Ordered](a, b T) T {
+	return max(a, b)
+}
+
+// TopK returns a combiner that keeps the k largest values of two slices
+// sorted in descending order, in O(k). Every input value is a slice (e.g. of
+// a single value), and the result is sorted in descending order as well. Its
+// identity is nil.
+func TopK[T cmp.Ordered](k int) func(a, b []T) []T {
+	if k <= 0 {
+		panic("combine: k must be positive")
+	}
+	return func(a, b []T) []T {
+		r := make([]T, 0, min(k, len(a)+len(b)))
+		for len(r) < k && (len(a) > 0 || len(b) > 0) {
+			if len(b) == 0 || (len(a) > 0 && a[0] >= b[0]) {
+				r, a = append(r, a[0]), a[1:]
+			} else {
+				r, b = append(r, b[0]), b[1:]
+			}
+		}
+		return r
+	}
+}
+
+// MergeSortedSlices merges two slices sorted in ascending order into a new
+// sorted slice, in O(len(a)+len(b)). Its identity is nil.
+func MergeSortedSlices[T cmp.Ordered](a, b []T) []T {
+	r := make([]T, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if b[0] < a[0] {
+			r, b = append(r, b[0]), b[1:]
+		} else {
+			r, a = append(r, a[0]), a[1:]
+		}
+	}
+	r = append(r, a...)
+	return append(r, b...)
+}
+
+// SetUnion adds the smaller set to the larger one and returns it, so that
+// the cost is O(min(len(a), len(b))). The arguments are modified, so they
+// must not be used afterwards. Its identity is nil.
+func SetUnion[K comparable](a, b map[K]struct{}) map[K]struct{} {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	if a == nil {
+		return b
+	}
+	for k := range b {
+		a[k] = struct{}{}
+	}
+	return a
+}
+
+// HistogramMerge adds the counts of the smaller histogram to the larger one
+// and returns it, so that the cost is O(min(len(a), len(b))). The arguments
+// are modified, so they must not be used afterwards. Its identity is nil.
+func HistogramMerge[K comparable, N Number](a, b map[K]N) map[K]N {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	if a == nil {
+		return b
+	}
+	for k, n := range b {
+		a[k] += n
+	}
+	return a
+}
+
+// WeightedMean averages a and b in proportion to their weights, for a tree
+// created with treeduction.NewWeighted. It has no identity, but a value of
+// weight 0 leaves the other side unchanged.
+func WeightedMean(a, b treeduction.Weighted[float64]) float64 {
+	if a.Weight+b.Weight == 0 {
+		return 0
+	}
+	return (a.Value*a.Weight + b.Value*b.Weight) / (a.Weight + b.Weight)
+}
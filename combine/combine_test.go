@@ -0,0 +1,74 @@
+package combine_test
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"treeduction"
+	"treeduction/combine"
+)
+
+func reduce[T any](combiner func(a, b T) T, values ...T) T {
+	tree := treeduction.New(combiner, 10, true, false)
+	for _, v := range values {
+		tree.AddValues(v)
+	}
+	tree.Finish()
+	return <-tree.Output()
+}
+
+// TestNumbers tests the combiners of single values.
+func TestNumbers(t *testing.T) {
+	if r := reduce(combine.Sum[int], 1, 2, 3, 4); r != 10 {
+		t.Errorf("Expected the sum to be 10, got %d", r)
+	}
+	if r := reduce(combine.Min[float64], 3, 1.5, 2); r != 1.5 {
+		t.Errorf("Expected the min to be 1.5, got %v", r)
+	}
+	if r := reduce(combine.Max[string], "b", "c", "a"); r != "c" {
+		t.Errorf("Expected the max to be %q, got %q", "c", r)
+	}
+}
+
+// TestTopK tests keeping the largest values.
+func TestTopK(t *testing.T) {
+	r := reduce(combine.TopK[int](3), []int{5}, []int{9, 1}, []int{7}, []int{3}, nil)
+	if !slices.Equal(r, []int{9, 7, 5}) {
+		t.Errorf("Expected the top 3 to be [9 7 5], got %v", r)
+	}
+}
+
+// TestMergeSortedSlices tests merging sorted slices.
+func TestMergeSortedSlices(t *testing.T) {
+	r := reduce(combine.MergeSortedSlices[int], []int{1, 4}, []int{2, 3, 9}, []int{0}, nil)
+	if !slices.Equal(r, []int{0, 1, 2, 3, 4, 9}) {
+		t.Errorf("Expected the merge to be [0 1 2 3 4 9], got %v", r)
+	}
+}
+
+// TestMaps tests the set and histogram combiners.
+func TestMaps(t *testing.T) {
+	set := reduce(combine.SetUnion[string],
+		map[string]struct{}{"a": {}},
+		map[string]struct{}{"b": {}, "a": {}},
+		nil)
+	if keys := slices.Sorted(maps.Keys(set)); !slices.Equal(keys, []string{"a", "b"}) {
+		t.Errorf("Expected the union to be [a b], got %v", keys)
+	}
+
+	hist := reduce(combine.HistogramMerge[string, int],
+		map[string]int{"a": 1},
+		map[string]int{"a": 2, "b": 1},
+		nil)
+	if !maps.Equal(hist, map[string]int{"a": 3, "b": 1}) {
+		t.Errorf("Expected the histogram to be map[a:3 b:1], got %v", hist)
+	}
+}
+
+// TestWeightedMean tests averaging weighted values.
+func TestWeightedMean(t *testing.T) {
+	r := combine.WeightedMean(treeduction.Weighted[float64]{Value: 2, Weight: 3}, treeduction.Weighted[float64]{Value: 6, Weight: 1})
+	if r != 3 {
+		t.Errorf("Expected the mean to be 3, got %v", r)
+	}
+}
@@ -0,0 +1,86 @@
+package combine_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"treeduction"
+	"treeduction/combine"
+)
+
+// TestAddSlices tests adding slices of any length, around the blocks.
+func TestAddSlices(t *testing.T) {
+	r := reduce(combine.AddSlices[int], []int{1, 2, 3}, nil, []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, []int{10})
+	if want := []int{12, 3, 4, 1, 1, 1, 1, 1, 1, 1, 1}; !slices.Equal(r, want) {
+		t.Errorf("Expected %v, got %v", want, r)
+	}
+
+	tree := treeduction.NewInPlace(combine.AddSlicesInto[float64], 10, true, false)
+	for i := range 20 {
+		v := make([]float64, i)
+		for j := range v {
+			v[j] = 1
+		}
+		tree.AddValues(v)
+	}
+	tree.Finish()
+	r2 := <-tree.Output()
+	for j, v := range r2 {
+		if v != float64(19-j) {
+			t.Fatalf("Expected %v at %d, got %v", 19-j, j, r2)
+		}
+	}
+	if len(r2) != 19 {
+		t.Errorf("Expected 19 elements, got %d", len(r2))
+	}
+}
+
+// addLoop is the plain combiner AddSlices is compared with, which adds in
+// place as well.
+func addLoop(a, b []float64) []float64 {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	for i, v := range b {
+		a[i] += v
+	}
+	return a
+}
+
+// BenchmarkAddSlices compares AddSlices with a plain loop on histograms of
+// n buckets, alone and reduced by a tree of 64 shards.
+func BenchmarkAddSlices(b *testing.B) {
+	for _, n := range []int{64, 4096, 1 << 16} {
+		for _, c := range []struct {
+			name    string
+			combine func(a, b []float64) []float64
+		}{
+			{"loop", addLoop},
+			{"blocks", combine.AddSlices[float64]},
+		} {
+			b.Run(fmt.Sprintf("%s/%d", c.name, n), func(b *testing.B) {
+				x, y := make([]float64, n), make([]float64, n)
+				b.SetBytes(int64(n) * 8)
+				for range b.N {
+					x = c.combine(x, y)
+				}
+			})
+			b.Run(fmt.Sprintf("tree/%s/%d", c.name, n), func(b *testing.B) {
+				shards := make([][]float64, 64)
+				for range b.N {
+					b.StopTimer()
+					for i := range shards {
+						shards[i] = make([]float64, n)
+					}
+					tree := treeduction.New(c.combine, 10, true, false)
+					b.StartTimer()
+					for _, s := range shards {
+						tree.AddValues(s)
+					}
+					tree.Finish()
+					<-tree.Output()
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package treeduction
+
+import (
+	"slices"
+	"time"
+)
+
+// Combined is a result with the inputs whose values were combined into it
+// and the time it was put on the output.
+type Combined[T any] struct {
+	Value T
+	// Sources are the numbers of the inputs, from 0 in the order they were
+	// added to the tree, sorted
+	Sources []int
+	At      time.Time
+
+	// Whether Value holds a value yet, for the local folds
+	full bool
+}
+
+// NewCombined is like New, but every result comes in a Combined, so that the
+// results can be routed by the inputs they come from (e.g. the tenant of a
+// partial) without carrying them in T. Like with NewEnveloped, the values of
+// a call to AddValues or AddSlice count as one input.
+func NewCombined[T any](combiner func(f T, s T) T, bufferSize int, waitForAll bool, ordered bool, opts ...Option) *Folder[T, Combined[T]] {
+	f := Fold(Combined[T]{}, func(c Combined[T], v T) Combined[T] {
+		if c.full {
+			c.Value = combiner(c.Value, v)
+		} else {
+			c.Value, c.full = v, true
+		}
+		return c
+	}, func(f Combined[T], s Combined[T]) Combined[T] {
+		sources := slices.Concat(f.Sources, s.Sources)
+		slices.Sort(sources)
+		return Combined[T]{Value: combiner(f.Value, s.Value), Sources: slices.Compact(sources), full: true}
+	}, bufferSize, waitForAll, ordered, opts...)
+
+	f.indexes = make(map[<-chan Combined[T]]int)
+	f.tag = func(c Combined[T], input int) Combined[T] {
+		c.Sources = []int{input}
+		return c
+	}
+	f.stamp = func(c Combined[T], _ uint64) Combined[T] {
+		c.At = time.Now()
+		return c
+	}
+	return f
+}
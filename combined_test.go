@@ -0,0 +1,54 @@
+package treeduction_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestCombined tests that the results carry the inputs they come from.
+func TestCombined(t *testing.T) {
+	tree := treeduction.NewCombined(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	start := time.Now()
+	tree.AddValues(1, 2)
+	tree.AddValues(3)
+	tree.AddValues(4)
+	tree.Finish()
+	c := <-tree.Output()
+	if c.Value != 10 {
+		t.Errorf("Expected 10, got %d", c.Value)
+	}
+	if !slices.Equal(c.Sources, []int{0, 1, 2}) {
+		t.Errorf("Expected inputs 0 to 2, got %v", c.Sources)
+	}
+	if c.At.Before(start) || c.At.After(time.Now()) {
+		t.Errorf("Expected the time of the result, got %v", c.At)
+	}
+}
+
+// TestCombinedStreaming tests the inputs of the results of an ordered tree
+// as they come.
+func TestCombinedStreaming(t *testing.T) {
+	tree := treeduction.NewCombined(func(a, b string) string {
+		return a + b
+	}, 10, false, true)
+
+	a, b := make(chan string, 2), make(chan string, 1)
+	a <- "a"
+	a <- "c"
+	b <- "b"
+	close(a)
+	close(b)
+	tree.Add(a, b)
+	for _, want := range []treeduction.Combined[string]{{Value: "ab", Sources: []int{0, 1}}, {Value: "c", Sources: []int{0}}} {
+		c := <-tree.Output()
+		if c.Value != want.Value || !slices.Equal(c.Sources, want.Sources) {
+			t.Errorf("Expected %q from %v, got %q from %v", want.Value, want.Sources, c.Value, c.Sources)
+		}
+	}
+	tree.Finish()
+}
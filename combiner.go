@@ -0,0 +1,40 @@
+package treeduction
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SetCombiner replaces the combiner of the tree, e.g. when the formula of an
+// aggregation changes on a configuration reload, without dropping the values
+// of inputs that can't be read again. Like Checkpoint, it pauses the tree
+// (unless it is paused already) and waits for the values read so far to
+// reach the root, so that every combine after it uses combiner and none
+// before it does; the partial result at the root is combined with the new
+// combiner from then on. combiner can't fail, and doesn't get the context
+// of NewContext. For a Folder it replaces merge, the step of the local folds
+// is kept. Only unordered trees without WithMaxWorkers, whose nodes can pass
+// a value on alone, can be waited for: errors.ErrUnsupported is returned for
+// the others.
+func (t *tree[T]) SetCombiner(combiner func(f T, s T) T) error {
+	if t.ordered || t.opts.pooled() {
+		return fmt.Errorf("treeduction: the combiner of an ordered or pooled tree can't be replaced: %w", errors.ErrUnsupported)
+	}
+	if t.finished.Load() {
+		return ErrFinished
+	}
+	if t.paused() == nil {
+		t.Pause()
+		defer t.Resume()
+	}
+	if !t.quiesce() {
+		return t.error()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.combiner = t.guard(func(f T, s T) (T, error) {
+		return combiner(f, s), nil
+	})
+	return nil
+}
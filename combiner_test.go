@@ -0,0 +1,50 @@
+package treeduction_test
+
+import (
+	"errors"
+	"testing"
+	"treeduction"
+)
+
+// TestSetCombiner tests that the values read after SetCombiner are combined
+// with the new combiner, and the ones read before with the old one.
+func TestSetCombiner(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	ch1 := make(chan int)
+	ch2 := make(chan int)
+	tree.Add(ch1, ch2)
+	ch1 <- 1
+	ch2 <- 2
+	if err := tree.SetCombiner(func(a, b int) int {
+		return a * b
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ch1 <- 4
+	ch2 <- 5
+	close(ch1)
+	close(ch2)
+
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 60 {
+		t.Errorf("Expected 3 * 4 * 5 = 60, got %d", v)
+	}
+}
+
+// TestSetCombinerUnsupported tests that the combiner of an ordered tree
+// can't be replaced.
+func TestSetCombinerUnsupported(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, true)
+	defer tree.Finish()
+
+	if err := tree.SetCombiner(func(a, b int) int { return a * b }); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected %v, got %v", errors.ErrUnsupported, err)
+	}
+}
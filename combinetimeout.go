@@ -0,0 +1,63 @@
+package treeduction
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// TimeoutPolicy decides what the tree does with a combine that takes longer
+// than the timeout of WithCombineTimeout.
+type TimeoutPolicy int
+
+const (
+	// LogSlowCombines logs a warning with the logger of WithLogger, and lets
+	// the combine go on.
+	LogSlowCombines TimeoutPolicy = iota
+	// CancelSlowCombines also cancels the context passed to the combiner of
+	// NewContext, with ErrCombineTimeout as its cause, so that it can give up.
+	// The combiners that don't take a context aren't cancelled.
+	CancelSlowCombines
+	// FailSlowCombines also fails the tree with ErrCombineTimeout, like a
+	// combiner error.
+	FailSlowCombines
+)
+
+// WithCombineTimeout watches the combines that take longer than d, e.g. a
+// combiner that hangs on a lock now and then, which would otherwise only show
+// as a Finish that never returns, and handles them with p. The tree can't
+// take a value back from a combine that never returns, so its goroutine is
+// still held, and Finish waits for it.
+func WithCombineTimeout(d time.Duration, p TimeoutPolicy) Option {
+	if d <= 0 {
+		panic("treeduction: combine timeout must be positive")
+	}
+	return func(o *options) {
+		o.combineTimeout = d
+		o.timeoutPolicy = p
+	}
+}
+
+// watchCombine starts the timer of a combine, the returned function stops it.
+func (t *tree[T]) watchCombine() func() {
+	d := t.opts.combineTimeout
+	if d <= 0 {
+		return func() {}
+	}
+	timer := time.AfterFunc(d, func() {
+		t.log(slog.LevelWarn, "combine timed out", "timeout", d)
+		if t.opts.timeoutPolicy == FailSlowCombines {
+			t.fail(ErrCombineTimeout)
+			t.kill()
+		}
+	})
+	return func() { timer.Stop() }
+}
+
+// combineContext returns the context of a combine of NewContext.
+func (t *tree[T]) combineContext() (context.Context, context.CancelFunc) {
+	if t.opts.combineTimeout <= 0 || t.opts.timeoutPolicy != CancelSlowCombines {
+		return t.life, func() {}
+	}
+	return context.WithTimeoutCause(t.life, t.opts.combineTimeout, ErrCombineTimeout)
+}
@@ -0,0 +1,97 @@
+package treeduction_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestCombineTimeout tests every policy with a combine that is slow once.
+func TestCombineTimeout(t *testing.T) {
+	slowOnce := func() func(a, b int) int {
+		var once sync.Once
+		return func(a, b int) int {
+			once.Do(func() { time.Sleep(50 * time.Millisecond) })
+			return a + b
+		}
+	}
+
+	t.Run("log", func(t *testing.T) {
+		var buf lockedBuffer
+		logger := slog.New(slog.NewTextHandler(&buf, nil))
+		tree := treeduction.New(slowOnce(), 10, true, false,
+			treeduction.WithCombineTimeout(10*time.Millisecond, treeduction.LogSlowCombines),
+			treeduction.WithLogger(logger))
+		tree.AddValues(1, 2, 3)
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		if v := <-tree.Output(); v != 6 {
+			t.Errorf("Expected 6, got %d", v)
+		}
+		if !strings.Contains(buf.String(), "combine timed out") {
+			t.Errorf("Expected a warning, got %q", buf.String())
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		var mu sync.Mutex
+		var causes []error
+		tree := treeduction.NewContext(func(ctx context.Context, a, b int) int {
+			select {
+			case <-ctx.Done():
+				mu.Lock()
+				causes = append(causes, context.Cause(ctx))
+				mu.Unlock()
+				return max(a, b)
+			case <-time.After(5 * time.Millisecond):
+				return a + b
+			}
+		}, 10, true, false, treeduction.WithCombineTimeout(time.Millisecond, treeduction.CancelSlowCombines))
+		tree.AddValues(1, 2)
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		if v := <-tree.Output(); v != 2 {
+			t.Errorf("Expected 2, got %d", v)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(causes) != 1 || !errors.Is(causes[0], treeduction.ErrCombineTimeout) {
+			t.Errorf("Expected the combine to be cancelled with %v, got %v", treeduction.ErrCombineTimeout, causes)
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		tree := treeduction.New(slowOnce(), 10, true, false,
+			treeduction.WithCombineTimeout(10*time.Millisecond, treeduction.FailSlowCombines))
+		tree.AddValues(1, 2, 3)
+		if err := tree.Finish(); !errors.Is(err, treeduction.ErrCombineTimeout) {
+			t.Errorf("Expected %v, got %v", treeduction.ErrCombineTimeout, err)
+		}
+	})
+}
+
+// lockedBuffer is a buffer written by the timers of the tree.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+func (l *lockedBuffer) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
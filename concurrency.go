@@ -0,0 +1,63 @@
+package treeduction
+
+import (
+	"runtime"
+	"sync"
+)
+
+// WithCombinerConcurrency lets every unordered node run up to n combines at
+// the same time, instead of one after the other, so that a node doesn't
+// become a bottleneck when the combiner takes milliseconds (like merging
+// large sorted runs). Each of them pairs the values it takes from the
+// children of the node. It has no effect in ordered mode, which combines the
+// values of a node in order, nor with WithMaxWorkers, whose workers already
+// run the combines of a node in parallel.
+func WithCombinerConcurrency(n int) Option {
+	if n <= 0 {
+		panic("treeduction: combiner concurrency must be positive")
+	}
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// WithParallelism caps the number of combines the tree runs at the same
+// time to n, or to runtime.GOMAXPROCS(0) if n is 0, so that a tree with a
+// large fan-in doesn't keep more goroutines runnable than there are CPUs to
+// run them, at the expense of the tail latency of the rest of the process.
+// The other goroutines of the tree are parked meanwhile. There is no cap by
+// default, as a combiner that waits (e.g. on I/O) would then hold up the
+// others.
+func WithParallelism(n int) Option {
+	if n < 0 {
+		panic("treeduction: parallelism can't be negative")
+	}
+	return func(o *options) {
+		o.parallelism = n
+		if n == 0 {
+			o.parallelism = runtime.GOMAXPROCS(0)
+		}
+	}
+}
+
+// combineNode runs the combines of an unordered node, until fanIn is closed.
+// idle, if not nil, returns a channel closed while no child of the node is
+// open.
+func (t *tree[T]) combineNode(fanIn <-chan T, c chan<- T, idle func() <-chan struct{}) {
+	n := t.opts.concurrency
+	if n <= 1 {
+		t.combinePairs(fanIn, c, idle)
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(n - 1)
+	for range n - 1 {
+		t.spawn(func() {
+			defer wg.Done()
+			t.combinePairs(fanIn, c, idle)
+		})
+	}
+	t.combinePairs(fanIn, c, idle)
+	wg.Wait()
+}
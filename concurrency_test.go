@@ -0,0 +1,64 @@
+package treeduction_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestCombinerConcurrency tests that a node runs several combines at once,
+// and still counts every value once.
+func TestCombinerConcurrency(t *testing.T) {
+	var running, peak atomic.Int64
+	tree := treeduction.New(func(a, b int) int {
+		n := running.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		return a + b
+	}, 100, true, false, treeduction.WithCombinerConcurrency(4), treeduction.WithStrategy(treeduction.Flat))
+
+	s := make([]int, 64)
+	for i := range s {
+		s[i] = 1
+	}
+	tree.AddSlice(s)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 64 {
+		t.Errorf("Expected 64, got %d", v)
+	}
+	// The results of the node are folded at the root meanwhile
+	if p := peak.Load(); p < 2 || p > 5 {
+		t.Errorf("Expected 2 to 5 combines at once, got %d", p)
+	}
+}
+
+// TestParallelism tests that the combines of the tree are capped.
+func TestParallelism(t *testing.T) {
+	var running, peak atomic.Int64
+	tree := treeduction.New(func(a, b int) int {
+		n := running.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		return a + b
+	}, 100, true, false, treeduction.WithParallelism(2), treeduction.WithCombinerConcurrency(4))
+
+	for range 32 {
+		tree.AddValues(1, 1)
+	}
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 64 {
+		t.Errorf("Expected 64, got %d", v)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("Expected at most 2 combines at once, got %d", p)
+	}
+}
@@ -0,0 +1,49 @@
+package treeduction
+
+import "context"
+
+// NewContext is like New, but the combiner also receives a context, so that
+// expensive merges can bail out early. The context is cancelled when the
+// tree is done, or when the parent context (see WithContext) is cancelled.
+func NewContext[T any](combiner func(ctx context.Context, f T, s T) T, bufferSize int, waitForAll bool, ordered bool, opts ...Option) Tree[T] {
+	return Typed[T]{}.NewContext(combiner, bufferSize, waitForAll, ordered, opts...)
+}
+
+// NewContext is NewContext with the typed options.
+func (w Typed[T]) NewContext(combiner func(ctx context.Context, f T, s T) T, bufferSize int, waitForAll bool, ordered bool, opts ...Option) Tree[T] {
+	t := newTree(bufferSize, waitForAll, ordered, opts, w.opts)
+	t.combiner = t.guard(func(f T, s T) (T, error) {
+		ctx, cancel := t.combineContext()
+		defer cancel()
+		return combiner(ctx, f, s), nil
+	})
+	return t
+}
+
+// WithContext derives the tree from ctx: cancelling it stops reading the
+// inputs and cancels the context passed to NewContext combiners. The values
+// inside the tree that can't move on are then lost, so that Finish returns
+// even if the output isn't read anymore.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// FinishContext is like Finish, but when ctx is done before the inputs are
+// closed, the tree stops reading them and reduces what it already read. With
+// waitForAll this partial result is put on the output, and the cause of ctx
+// is returned.
+func (t *tree[T]) FinishContext(ctx context.Context) error {
+	if !t.markFinished() {
+		return t.finishedBefore()
+	}
+	defer t.settle()
+
+	stop := context.AfterFunc(ctx, func() {
+		t.fail(context.Cause(ctx))
+		t.cancel()
+	})
+	defer stop()
+	return t.checkLeaks(t.finish())
+}
@@ -0,0 +1,178 @@
+package treeduction_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestNewContext tests that a context-aware combiner reduces like a plain one.
+func TestNewContext(t *testing.T) {
+	tree := treeduction.NewContext(func(ctx context.Context, a, b int) int {
+		if ctx.Err() != nil {
+			t.Error("Expected the combiner context to be alive")
+		}
+		return a + b
+	}, 10, true, false)
+
+	for i := 1; i <= 4; i++ {
+		ch := make(chan int, 1)
+		ch <- i
+		close(ch)
+		tree.Add(ch)
+	}
+
+	tree.Finish()
+	if result := <-tree.Output(); result != 10 {
+		t.Errorf("Expected result to be 10, got %d", result)
+	}
+}
+
+// TestNewContextCancel tests that cancelling the parent context reaches the combiner.
+func TestNewContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+
+	tree := treeduction.NewContext(func(ctx context.Context, a, b int) int {
+		close(started)
+		// An expensive merge that only stops on cancellation
+		<-ctx.Done()
+		return a
+	}, 10, false, false, treeduction.WithContext(ctx))
+
+	ch1 := make(chan int, 1)
+	ch2 := make(chan int, 1)
+	ch1 <- 1
+	ch2 <- 2
+	tree.Add(ch1, ch2)
+
+	<-started
+	cancel()
+	tree.Finish()
+
+	for range tree.Output() {
+	}
+}
+
+// TestNewContextFailure tests that a failing combiner cancels the context of
+// the other combiners.
+func TestNewContextFailure(t *testing.T) {
+	tree := treeduction.NewContext(func(ctx context.Context, a, b int) int {
+		if a == 1 {
+			panic("boom")
+		}
+		<-ctx.Done()
+		return a
+	}, 10, false, true)
+
+	var channels []<-chan int
+	for i := 1; i <= 4; i++ {
+		ch := make(chan int, 1)
+		ch <- i
+		close(ch)
+		channels = append(channels, ch)
+	}
+	tree.Add(channels...)
+
+	for range tree.Output() {
+		break
+	}
+	if err := tree.Finish(); err == nil {
+		t.Error("Expected an error from Finish()")
+	}
+}
+
+// TestFinishContext tests that a timeout stops waiting for an input that is
+// never closed, and that the values read so far are reduced.
+func TestFinishContext(t *testing.T) {
+	for _, opts := range [][]treeduction.Option{nil, {treeduction.WithMaxWorkers(2)}} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false, opts...)
+
+		ch1 := make(chan int, 1)
+		ch2 := make(chan int, 1) // Never closed
+		ch1 <- 1
+		close(ch1)
+		tree.Add(ch1, ch2)
+		ch2 <- 2
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := tree.FinishContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded from FinishContext(), got %v", err)
+		}
+		if result := <-tree.Output(); result != 3 {
+			t.Errorf("Expected result to be 3, got %d", result)
+		}
+	}
+}
+
+// TestCancelBlocked tests that cancelling the parent context stops a tree
+// whose output nobody reads anymore, without leaving goroutines blocked on
+// its full buffers.
+func TestCancelBlocked(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		ordered bool
+		opts    []treeduction.Option
+	}{
+		{"binary", false, nil},
+		{"kary", false, []treeduction.Option{treeduction.WithStrategy(treeduction.KAry(3))}},
+		{"concurrency", false, []treeduction.Option{treeduction.WithCombinerConcurrency(2)}},
+		{"scan", false, []treeduction.Option{treeduction.WithScan()}},
+		{"ordered", true, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			opts := append([]treeduction.Option{
+				treeduction.WithContext(ctx),
+				treeduction.WithOutputBuffer(0),
+				treeduction.WithLeakCheck(time.Second),
+			}, tt.opts...)
+			tree := treeduction.New(func(a, b int) int {
+				return a + b
+			}, 0, false, tt.ordered, opts...)
+
+			// Endless inputs fill every buffer of the tree
+			stop := make(chan struct{})
+			defer close(stop)
+			var inputs []<-chan int
+			for range 5 {
+				c := make(chan int)
+				go func() {
+					for {
+						select {
+						case c <- 1:
+						case <-stop:
+							return
+						}
+					}
+				}()
+				inputs = append(inputs, c)
+			}
+			tree.Add(inputs...)
+			time.Sleep(20 * time.Millisecond)
+
+			cancel()
+			done := make(chan error)
+			go func() {
+				done <- tree.Finish()
+			}()
+			select {
+			case err := <-done:
+				var leak *treeduction.LeakError
+				if errors.As(err, &leak) {
+					t.Errorf("Expected no goroutine left, got %v", leak.Stages)
+				}
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("Expected context.Canceled from Finish(), got %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Expected Finish() to return once the tree was cancelled")
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package treeduction
+
+// Reduced is a result with the number of input values folded into it.
+type Reduced[T any] struct {
+	Value T
+	Count int64
+}
+
+// NewCounted is like New, but every result comes with the number of input
+// values that were combined into it, e.g. to turn a sum into a mean without
+// carrying the count in T.
+func NewCounted[T any](combiner func(f T, s T) T, bufferSize int, waitForAll bool, ordered bool, opts ...Option) *Folder[T, Reduced[T]] {
+	return Fold(Reduced[T]{}, func(r Reduced[T], v T) Reduced[T] {
+		if r.Count == 0 {
+			return Reduced[T]{Value: v, Count: 1}
+		}
+		return Reduced[T]{Value: combiner(r.Value, v), Count: r.Count + 1}
+	}, func(f Reduced[T], s Reduced[T]) Reduced[T] {
+		return Reduced[T]{Value: combiner(f.Value, s.Value), Count: f.Count + s.Count}
+	}, bufferSize, waitForAll, ordered, opts...)
+}
@@ -0,0 +1,22 @@
+package treeduction_test
+
+import (
+	"testing"
+	"treeduction"
+)
+
+// TestCounted tests that the results count the values folded into them.
+func TestCounted(t *testing.T) {
+	tree := treeduction.NewCounted(func(a, b float64) float64 {
+		return a + b
+	}, 10, true, false)
+
+	tree.AddValues(1, 2, 3)
+	tree.AddSlice([]float64{4, 5})
+	tree.Finish()
+
+	r := <-tree.Output()
+	if r.Value != 15 || r.Count != 5 {
+		t.Errorf("Expected 15 over 5 values, got %v", r)
+	}
+}
@@ -0,0 +1,187 @@
+package treeduction
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DebugState is what DebugHandler serves about a tree.
+type DebugState struct {
+	Nodes      int   `json:"nodes"`
+	Goroutines int   `json:"goroutines"`
+	Depth      int   `json:"depth"`
+	Pending    int   `json:"pending"`
+	Consumed   int64 `json:"consumed"`
+	Emitted    int64 `json:"emitted"`
+	// ConsumedRate and EmittedRate are the values read from the inputs and
+	// the results put on the output per second over the last 10 seconds,
+	// whichever client asks (or since DebugHandler at first).
+	ConsumedRate float64 `json:"consumed_rate"`
+	EmittedRate  float64 `json:"emitted_rate"`
+	// Sources are the inputs that are still being read, the ones the tree
+	// waited the longest for first, named by their key if they were added
+	// with AddMap and by the address of their channel otherwise.
+	Sources []DebugSource `json:"sources"`
+	// Errors are the last errors of the tree, see Errors.
+	Errors []string `json:"errors"`
+	// Topology is the dump of the tree, in DOT format.
+	Topology string `json:"topology"`
+}
+
+// DebugSource is the reading of an input, see DebugState.
+type DebugSource struct {
+	Name string `json:"name"`
+	SourceStats
+}
+
+// debugErrors is the number of errors served by DebugHandler.
+const debugErrors = 10
+
+// debugWindow is the time over which DebugHandler measures the rates, and
+// debugSamples the number of counters it keeps within it.
+const (
+	debugWindow  = 10 * time.Second
+	debugSamples = 10
+)
+
+// InputNames names the inputs of a tree, like the KeyedInputs of AddMap.
+type InputNames[T any] interface {
+	Name(in <-chan T) (string, bool)
+}
+
+// DebugHandler serves a live view of t for debugging a reduction that
+// stalls, like the handlers of net/http/pprof: its shape with the fill level
+// of every buffer, its throughput, its inputs and its last errors, as an
+// HTML page, or as a DebugState in JSON with ?format=json. The inputs are
+// named by the first of names that knows them.
+//
+//	http.Handle("/debug/treeduction", treeduction.DebugHandler(tree, inputs))
+func DebugHandler[T any](t Tree[T], names ...InputNames[T]) http.Handler {
+	s := t.Stats()
+	d := &debugger[T]{t: t, names: names}
+	d.samples = append(d.samples, debugSample{at: time.Now(), consumed: totalConsumed(s), emitted: s.Emitted})
+	return d
+}
+
+type debugger[T any] struct {
+	t     Tree[T]
+	names []InputNames[T]
+
+	// The counters over the last debugWindow, the oldest first
+	mu      sync.Mutex
+	samples []debugSample
+}
+
+type debugSample struct {
+	at                time.Time
+	consumed, emitted int64
+}
+
+func totalConsumed[T any](s Stats[T]) int64 {
+	var n int64
+	for _, c := range s.Consumed {
+		n += c
+	}
+	return n
+}
+
+func (d *debugger[T]) state() DebugState {
+	s := d.t.Stats()
+	state := DebugState{
+		Nodes:      s.Nodes,
+		Goroutines: s.Goroutines,
+		Depth:      s.Depth,
+		Pending:    s.Pending,
+		Consumed:   totalConsumed(s),
+		Emitted:    s.Emitted,
+		Sources:    make([]DebugSource, 0, len(s.Sources)),
+	}
+
+	d.mu.Lock()
+	now := time.Now()
+	// The last sample before the window is where it starts
+	for len(d.samples) > 1 && now.Sub(d.samples[1].at) >= debugWindow {
+		d.samples = d.samples[1:]
+	}
+	first := d.samples[0]
+	if elapsed := now.Sub(first.at).Seconds(); elapsed > 0 {
+		state.ConsumedRate = float64(state.Consumed-first.consumed) / elapsed
+		state.EmittedRate = float64(state.Emitted-first.emitted) / elapsed
+	}
+	if now.Sub(d.samples[len(d.samples)-1].at) >= debugWindow/debugSamples {
+		d.samples = append(d.samples, debugSample{at: now, consumed: state.Consumed, emitted: state.Emitted})
+	}
+	d.mu.Unlock()
+
+	for in, ss := range s.Sources {
+		state.Sources = append(state.Sources, DebugSource{Name: d.name(in), SourceStats: ss})
+	}
+	slices.SortFunc(state.Sources, func(a, b DebugSource) int {
+		return cmp.Or(cmp.Compare(b.Waited, a.Waited), strings.Compare(a.Name, b.Name))
+	})
+	errs := d.t.Errors()
+	for _, err := range errs[max(0, len(errs)-debugErrors):] {
+		state.Errors = append(state.Errors, err.Error())
+	}
+	var b strings.Builder
+	if err := d.t.Dump(&b); err == nil {
+		state.Topology = b.String()
+	}
+	return state
+}
+
+// name returns the name of an input in names, or the address of its channel.
+func (d *debugger[T]) name(in <-chan T) string {
+	for _, names := range d.names {
+		if name, ok := names.Name(in); ok {
+			return name
+		}
+	}
+	return fmt.Sprintf("%p", in)
+}
+
+func (d *debugger[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	state := d.state()
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(state)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	debugPage.Execute(w, state)
+}
+
+var debugPage = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head><title>treeduction</title></head>
+<body>
+<h1>treeduction</h1>
+<table>
+<tr><td>nodes</td><td>{{.Nodes}}</td></tr>
+<tr><td>goroutines</td><td>{{.Goroutines}}</td></tr>
+<tr><td>depth</td><td>{{.Depth}}</td></tr>
+<tr><td>pending</td><td>{{.Pending}}</td></tr>
+<tr><td>consumed</td><td>{{.Consumed}} ({{printf "%.1f" .ConsumedRate}}/s)</td></tr>
+<tr><td>emitted</td><td>{{.Emitted}} ({{printf "%.1f" .EmittedRate}}/s)</td></tr>
+</table>
+<h2>Inputs</h2>
+<table>
+<tr><th>input</th><th>read</th><th>last</th><th>waited</th><th>dropped</th></tr>
+{{range .Sources}}<tr><td>{{.Name}}</td><td>{{.Read}}</td><td>{{.Last.Format "15:04:05.000"}}</td><td>{{.Waited}}</td><td>{{.Dropped}}</td></tr>
+{{end}}</table>
+<h2>Errors</h2>
+<ul>
+{{range .Errors}}<li>{{.}}</li>
+{{end}}</ul>
+<h2>Topology</h2>
+<pre>{{.Topology}}</pre>
+</body>
+</html>
+`))
@@ -0,0 +1,91 @@
+package treeduction_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestDebugHandler tests the JSON and HTML views of a running tree.
+func TestDebugHandler(t *testing.T) {
+	errBad := errors.New("bad source")
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithSourcePolicy(treeduction.CollectErrors))
+	h := treeduction.DebugHandler(tree)
+	ch := make(chan int)
+	errs := make(chan error, 1)
+	errs <- errBad
+	tree.AddWithErr(ch, errs)
+	ch <- 1
+	ch <- 2
+	<-tree.Output()
+	for len(tree.Errors()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/debug?format=json", nil))
+	var state treeduction.DebugState
+	if err := json.NewDecoder(rec.Body).Decode(&state); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Sources) != 1 || state.Consumed < 1 || state.Emitted < 1 {
+		t.Errorf("Expected an input with values read and emitted, got %+v", state)
+	}
+	if len(state.Errors) != 1 || state.Errors[0] != errBad.Error() {
+		t.Errorf("Expected %v, got %v", errBad, state.Errors)
+	}
+	if !strings.HasPrefix(state.Topology, "digraph") {
+		t.Errorf("Expected the dump of the tree, got %q", state.Topology)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/debug", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "bad source") || !strings.Contains(body, "digraph") {
+		t.Errorf("Expected an HTML page with the errors and the dump, got %q", body)
+	}
+
+	close(ch)
+	tree.Finish()
+}
+
+// TestDebugHandlerKeys tests that the inputs added with AddMap are named by
+// key, and that a request doesn't reset the rates of the next one.
+func TestDebugHandlerKeys(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false)
+	ch := make(chan int)
+	inputs, err := treeduction.AddMap(tree, map[string]<-chan int{"shard-1": ch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := treeduction.DebugHandler(tree, inputs)
+	ch <- 1
+	<-tree.Output()
+
+	state := func() treeduction.DebugState {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/debug?format=json", nil))
+		var state treeduction.DebugState
+		if err := json.NewDecoder(rec.Body).Decode(&state); err != nil {
+			t.Fatal(err)
+		}
+		return state
+	}
+	first := state()
+	if len(first.Sources) != 1 || first.Sources[0].Name != "shard-1" {
+		t.Errorf("Expected the input shard-1, got %+v", first.Sources)
+	}
+	if second := state(); second.EmittedRate == 0 {
+		t.Errorf("Expected the rate of the first request to stand, got %+v", second)
+	}
+
+	close(ch)
+	tree.Finish()
+}
@@ -0,0 +1,60 @@
+package treeduction
+
+import "sync"
+
+// WithDedup drops the values read from the inputs whose key is the one of a
+// value among the last window distinct values read, so that the values
+// published on several inputs for reliability are only reduced once. Keys
+// are compared, not values, so key should be a hash wide enough for
+// collisions not to matter. The duplicates are counted in
+// Stats().Duplicates. In ordered mode a duplicate doesn't take its place in
+// its round, like a value dropped by WithFilter.
+func WithDedup[T any](key func(T) uint64, window int) TypedOption[T] {
+	if window <= 0 {
+		panic("treeduction: dedup window must be positive")
+	}
+	return func(o *options, ty *typed[T]) {
+		ty.dedupKey = key
+		o.dedupWindow = window
+	}
+}
+
+// seen is the window of the keys of the last distinct values, see WithDedup.
+type seen struct {
+	mu   sync.Mutex
+	keys map[uint64]struct{}
+	ring []uint64
+	next int
+}
+
+func newSeen(window int) *seen {
+	return &seen{keys: make(map[uint64]struct{}, window), ring: make([]uint64, 0, window)}
+}
+
+// add reports whether k is new, and remembers it in place of the oldest key
+// if the window is full.
+func (s *seen) add(k uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[k]; ok {
+		return false
+	}
+	if len(s.ring) < cap(s.ring) {
+		s.ring = append(s.ring, k)
+	} else {
+		delete(s.keys, s.ring[s.next])
+		s.ring[s.next] = k
+		s.next = (s.next + 1) % len(s.ring)
+	}
+	s.keys[k] = struct{}{}
+	return true
+}
+
+// duplicate reports whether v was read already, counting it if so.
+func (t *tree[T]) duplicate(v T) bool {
+	if t.seen == nil || t.seen.add(t.dedupKey(v)) {
+		return false
+	}
+	t.duplicates.Add(1)
+	return true
+}
@@ -0,0 +1,41 @@
+package treeduction_test
+
+import (
+	"testing"
+	"treeduction"
+)
+
+// TestDedup tests that a value published on two inputs is only reduced
+// once, and that the keys older than the window are forgotten.
+func TestDedup(t *testing.T) {
+	tree := treeduction.With(treeduction.WithDedup(func(v int) uint64 {
+		return uint64(v)
+	}, 8)).New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	tree.AddValues(1, 2, 3, 4)
+	tree.AddValues(1, 2, 3, 4)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 10 {
+		t.Errorf("Expected 10, got %d", v)
+	}
+	if n := tree.Stats().Duplicates; n != 4 {
+		t.Errorf("Expected 4 duplicates, got %d", n)
+	}
+
+	// With a window of 1, only a key right after the same one is dropped
+	tree = treeduction.With(treeduction.WithDedup(func(v int) uint64 {
+		return uint64(v)
+	}, 1)).New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	tree.AddValues(1, 1, 2, 1)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 4 {
+		t.Errorf("Expected 4, got %d", v)
+	}
+}
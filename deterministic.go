@@ -0,0 +1,63 @@
+package treeduction
+
+import "slices"
+
+// WithDeterministicOrder makes the stream of results of an unordered tree
+// reproducible: each node waits for a value from both of its children and
+// combines them in turn, like the nodes of an ordered tree, passing the rest
+// of a child through once the other is closed, and the roots of the tree are
+// read in turn, the one of the first inputs first, by a single goroutine.
+// The results are then the same on every run as long as the inputs are added
+// before their values flow and send the same values, at the cost of a slow
+// input holding back its siblings. Unlike an ordered tree, the shape is still
+// the one grown by Add, with the inputs of later calls closer to the root.
+// It only applies to binary unordered trees without WithMaxWorkers,
+// WithSequentialFallback, WithLocalGroups, WithInline, WithNodeFactory or
+// WithBatchIsolation, and the priorities of the inputs and
+// WithExpectedInputs are ignored.
+func WithDeterministicOrder() Option {
+	return func(o *options) {
+		o.deterministic = true
+	}
+}
+
+// lockstep reports whether the nodes of an unordered tree combine their
+// children in turn, see WithDeterministicOrder.
+func (o options) lockstep() bool {
+	return o.deterministic && o.arity() == 2 && !o.pooled() && !o.sequential &&
+		o.localSize == 0 && !o.inline && !o.nodeFactory && !o.batchIsolation
+}
+
+// collectInTurn starts the single collector of a lockstep tree, which reads
+// a value from every root in turn, from the highest level.
+func (t *tree[T]) collectInTurn() {
+	roots := t.levels.roots()
+	if len(roots) == 0 {
+		return
+	}
+	slices.Reverse(roots)
+
+	t.wg.Add(1)
+	stop := t.stop
+	t.spawn(func() {
+		defer t.wg.Done()
+		if !t.waitBatch(stop) {
+			return
+		}
+		for len(roots) > 0 {
+			for i := 0; i < len(roots); {
+				select {
+				case <-stop:
+					return
+				case v, ok := <-roots[i]:
+					if !ok {
+						roots = slices.Delete(roots, i, i+1)
+						continue
+					}
+					t.deliver(v, nil)
+				}
+				i++
+			}
+		}
+	}, "role", "collector")
+}
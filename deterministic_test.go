@@ -0,0 +1,69 @@
+package treeduction_test
+
+import (
+	"slices"
+	"testing"
+	"treeduction"
+)
+
+// TestDeterministicOrder tests that an unordered tree with
+// WithDeterministicOrder emits the same stream of results on every run,
+// with a non-commutative combiner and inputs of different lengths.
+func TestDeterministicOrder(t *testing.T) {
+	want := []string{"adgj", "m", "behk", "n", "cfil", "o", "p", "q"}
+	for range 20 {
+		tree := treeduction.New(func(a, b string) string {
+			return a + b
+		}, 10, false, false, treeduction.WithDeterministicOrder())
+		var inputs []<-chan string
+		for _, in := range []string{"abc", "def", "ghi", "jkl", "mnopq"} {
+			ch := make(chan string, len(in))
+			for _, r := range in {
+				ch <- string(r)
+			}
+			close(ch)
+			inputs = append(inputs, ch)
+		}
+		tree.Add(inputs...)
+
+		var stream []string
+		for range want {
+			stream = append(stream, <-tree.Output())
+		}
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := <-tree.Output(); ok {
+			t.Error("Expected no other result")
+		}
+		if !slices.Equal(stream, want) {
+			t.Fatalf("Expected the stream %v, got %v", want, stream)
+		}
+	}
+}
+
+// TestDeterministicOrderWaitForAll tests that the result of a waitForAll
+// tree is reproducible with a non-commutative combiner.
+func TestDeterministicOrderWaitForAll(t *testing.T) {
+	for range 20 {
+		tree := treeduction.New(func(a, b string) string {
+			return a + b
+		}, 10, true, false, treeduction.WithDeterministicOrder())
+		var inputs []<-chan string
+		for _, in := range []string{"ab", "cd", "ef"} {
+			ch := make(chan string, 2)
+			ch <- in[:1]
+			ch <- in[1:]
+			close(ch)
+			inputs = append(inputs, ch)
+		}
+		tree.Add(inputs...)
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		// The root of the first two inputs, then the third one, in turn
+		if v := <-tree.Output(); v != "acebdf" {
+			t.Fatalf("Expected %q, got %q", "acebdf", v)
+		}
+	}
+}
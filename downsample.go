@@ -0,0 +1,45 @@
+package treeduction
+
+import (
+	"context"
+	"time"
+)
+
+// Sample is a value of a time series.
+type Sample[T any] struct {
+	Time  time.Time
+	Value T
+}
+
+// Downsample reduces time series into buckets of width, aligned on the zero
+// time (see time.Time.Truncate): the values of the samples of all the inputs
+// that fall in the same bucket are combined, and a sample per bucket, at the
+// start of the bucket, is emitted in time order. Every input must be sorted
+// by time. Like with MergeSorted, which it is built on, each merge holds its
+// last bucket until the next one starts, so a bucket is emitted once the
+// inputs moved a few buckets past it or are closed, and the output is
+// closed once the inputs are all closed, or when ctx is done.
+func Downsample[T any](ctx context.Context, width time.Duration, combine func(a, b T) T, bufferSize int, inputs ...<-chan Sample[T]) <-chan Sample[T] {
+	if width <= 0 {
+		panic("treeduction: bucket width must be positive")
+	}
+	merged := MergeSorted(ctx, func(a, b Sample[T]) int {
+		return a.Time.Truncate(width).Compare(b.Time.Truncate(width))
+	}, func(a, b Sample[T]) Sample[T] {
+		return Sample[T]{a.Time, combine(a.Value, b.Value)}
+	}, bufferSize, inputs...)
+
+	out := make(chan Sample[T], bufferSize)
+	go func() {
+		defer close(out)
+		for s := range merged {
+			s.Time = s.Time.Truncate(width)
+			select {
+			case out <- s:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,71 @@
+package treeduction_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestDownsample tests that the samples of the same bucket are combined
+// across inputs, and the buckets emitted in time order.
+func TestDownsample(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := func(offsets ...int) <-chan treeduction.Sample[int] {
+		c := make(chan treeduction.Sample[int], len(offsets))
+		for _, s := range offsets {
+			c <- treeduction.Sample[int]{Time: start.Add(time.Duration(s) * time.Second), Value: 1}
+		}
+		close(c)
+		return c
+	}
+	out := treeduction.Downsample(context.Background(), 10*time.Second, func(a, b int) int {
+		return a + b
+	}, 10, series(1, 5, 12, 31), series(0, 19, 20), series(9, 35, 39))
+
+	var got []treeduction.Sample[int]
+	for s := range out {
+		got = append(got, s)
+	}
+	want := []treeduction.Sample[int]{
+		{Time: start, Value: 4},
+		{Time: start.Add(10 * time.Second), Value: 2},
+		{Time: start.Add(20 * time.Second), Value: 1},
+		{Time: start.Add(30 * time.Second), Value: 3},
+	}
+	if !slices.EqualFunc(got, want, func(a, b treeduction.Sample[int]) bool {
+		return a.Time.Equal(b.Time) && a.Value == b.Value
+	}) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestDownsampleStreaming tests that a bucket is emitted once the inputs
+// moved past it, before they are closed.
+func TestDownsampleStreaming(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start := time.Unix(0, 0)
+	a := make(chan treeduction.Sample[int])
+	b := make(chan treeduction.Sample[int])
+	out := treeduction.Downsample(ctx, time.Minute, func(x, y int) int {
+		return x + y
+	}, 0, a, b)
+
+	send := func(c chan<- treeduction.Sample[int], offsets ...time.Duration) {
+		for _, d := range offsets {
+			c <- treeduction.Sample[int]{Time: start.Add(d), Value: 1}
+		}
+	}
+	go send(a, 0, 2*time.Minute, 3*time.Minute, 4*time.Minute)
+	go send(b, time.Second, 2*time.Minute, 3*time.Minute, 4*time.Minute)
+	select {
+	case s := <-out:
+		if !s.Time.Equal(start) || s.Value != 2 {
+			t.Errorf("Expected 2 at %v, got %d at %v", start, s.Value, s.Time)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The first bucket wasn't emitted")
+	}
+}
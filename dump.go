@@ -0,0 +1,105 @@
+package treeduction
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Dump writes the current structure of the tree in DOT format: the nodes
+// that are still open with the fill level of their buffers (and their level
+// for the roots of the levels, see Stats.Levels), and the output.
+// Without WithMaxWorkers the inputs are written as well. It can be rendered
+// with Graphviz.
+func (t *tree[T]) Dump(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph treeduction {\n")
+	fmt.Fprintf(&b, "\toutput [shape=box label=\"output %d/%d\"];\n", len(t.output), cap(t.output))
+	if t.pool != nil {
+		t.dumpPooled(&b)
+	} else {
+		t.dumpChannels(&b)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (t *tree[T]) dumpChannels(b *strings.Builder) {
+	t.mu.Lock()
+	levelOf := make(map[<-chan T]int)
+	for _, i := range t.levels.occupied() {
+		levelOf[t.levels.at[i].root] = i
+	}
+	t.mu.Unlock()
+
+	t.bufMu.Lock()
+	defer t.bufMu.Unlock()
+
+	ids := make(map[<-chan T]string)
+	for c, buf := range t.buffers {
+		if buf.label != "" {
+			ids[c] = fmt.Sprintf("n%d", len(ids))
+		}
+	}
+
+	// The nodes nobody reads from are the roots, read by the emitter if
+	// there is one
+	root := "output"
+	if id, ok := ids[t.rootIn]; ok && t.rootIn != t.output {
+		root = id
+	}
+	read := make(map[<-chan T]bool)
+	for c, id := range ids {
+		buf := t.buffers[c]
+		level := ""
+		if i, ok := levelOf[c]; ok {
+			level = fmt.Sprintf(" (level %d)", i)
+		}
+		fmt.Fprintf(b, "\t%s [label=\"%s %d/%d%s\"];\n", id, buf.label, len(c), cap(c), level)
+		for _, child := range buf.children {
+			if cid, ok := ids[child]; ok {
+				fmt.Fprintf(b, "\t%s -> %s;\n", cid, id)
+				read[child] = true
+			}
+		}
+	}
+	for c, id := range ids {
+		switch {
+		case c == t.rootIn:
+			fmt.Fprintf(b, "\t%s -> output;\n", id)
+		case !read[c]:
+			fmt.Fprintf(b, "\t%s -> %s;\n", id, root)
+		}
+	}
+}
+
+func (t *tree[T]) dumpPooled(b *strings.Builder) {
+	// Node locks are never taken while holding the pool lock
+	t.pool.mu.Lock()
+	live := make([]*pnode[T], 0, len(t.pool.live))
+	for n := range t.pool.live {
+		live = append(live, n)
+	}
+	t.pool.mu.Unlock()
+
+	ids := make(map[*pnode[T]]string)
+	for _, n := range live {
+		ids[n] = fmt.Sprintf("n%d", len(ids))
+	}
+	labels := map[pkind]string{punordered: "unordered", pordered: "ordered", prounds: "rounds"}
+	for _, n := range live {
+		pending := n.pending()
+		n.mu.Lock()
+		parent := n.parent
+		n.mu.Unlock()
+
+		fmt.Fprintf(b, "\t%s [label=\"%s %d\"];\n", ids[n], labels[n.kind], pending)
+		if pid, ok := ids[parent]; ok {
+			fmt.Fprintf(b, "\t%s -> %s;\n", ids[n], pid)
+		} else if parent == nil {
+			fmt.Fprintf(b, "\t%s -> output;\n", ids[n])
+		}
+	}
+}
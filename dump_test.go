@@ -0,0 +1,48 @@
+package treeduction_test
+
+import (
+	"strings"
+	"testing"
+	"treeduction"
+)
+
+// TestDump tests the DOT export of the structure of a tree.
+func TestDump(t *testing.T) {
+	for _, tc := range []struct {
+		opts   []treeduction.Option
+		inputs int
+		edges  int
+	}{
+		{nil, 4, 7},
+		{[]treeduction.Option{treeduction.WithMaxWorkers(2)}, 0, 3},
+	} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, false, false, tc.opts...)
+
+		channels := make([]chan int, 4) // Never closed
+		for i := range channels {
+			channels[i] = make(chan int)
+			tree.Add(channels[i])
+		}
+
+		var b strings.Builder
+		if err := tree.Dump(&b); err != nil {
+			t.Fatalf("Unexpected error from Dump(): %v", err)
+		}
+		dot := b.String()
+		if !strings.HasPrefix(dot, "digraph treeduction {") {
+			t.Errorf("Expected a DOT digraph, got %q", dot)
+		}
+		if n := strings.Count(dot, `"input`); n != tc.inputs {
+			t.Errorf("Expected %d inputs, got %d in %q", tc.inputs, n, dot)
+		}
+		if n := strings.Count(dot, `"unordered`); n != 3 {
+			t.Errorf("Expected 3 nodes, got %d in %q", n, dot)
+		}
+		if n := strings.Count(dot, "->"); n != tc.edges {
+			t.Errorf("Expected %d edges, got %d in %q", tc.edges, n, dot)
+		}
+		tree.Finish()
+	}
+}
@@ -0,0 +1,41 @@
+package treeduction
+
+// DuplicatePolicy decides what Add does with a channel that is already being
+// read, or passed twice.
+type DuplicatePolicy int
+
+const (
+	// AllowDuplicates reads the channel once more, the readers then split
+	// its values between them (the default).
+	AllowDuplicates DuplicatePolicy = iota
+	// RejectDuplicates skips the channel, and reports it with an InputError
+	// wrapping ErrDuplicateInput, so that a configuration mistake surfaces.
+	RejectDuplicates
+	// DedupeInputs skips the channel silently.
+	DedupeInputs
+)
+
+// WithDuplicatePolicy sets what Add does with a channel that is already
+// being read, see DuplicatePolicy.
+func WithDuplicatePolicy(p DuplicatePolicy) Option {
+	return func(o *options) {
+		o.duplicates = p
+	}
+}
+
+// reading reports whether in is an input that is still being read.
+func (t *tree[T]) reading(in <-chan T) bool {
+	t.srcMu.Lock()
+	defer t.srcMu.Unlock()
+	_, ok := t.sources[in]
+	return ok
+}
+
+// reading reports whether in is an input of the Folder that is still being
+// read.
+func (f *Folder[T, A]) reading(in <-chan T) bool {
+	f.inMu.Lock()
+	defer f.inMu.Unlock()
+	_, ok := f.inputs[in]
+	return ok
+}
@@ -0,0 +1,61 @@
+package treeduction_test
+
+import (
+	"errors"
+	"testing"
+	"treeduction"
+)
+
+// TestRejectDuplicates tests that a channel that is already read is skipped
+// and reported.
+func TestRejectDuplicates(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithDuplicatePolicy(treeduction.RejectDuplicates))
+
+	ch := make(chan int)
+	other := make(chan int)
+	err := tree.Add(ch, other, ch)
+	var inputErr *treeduction.InputError
+	if !errors.As(err, &inputErr) || inputErr.Index != 2 || !errors.Is(err, treeduction.ErrDuplicateInput) {
+		t.Errorf("Expected input 2 to be a duplicate, got %v", err)
+	}
+	if err := tree.Add(other); !errors.Is(err, treeduction.ErrDuplicateInput) {
+		t.Errorf("Expected %v, got %v", treeduction.ErrDuplicateInput, err)
+	}
+
+	for i := range 10 {
+		ch <- i
+	}
+	close(ch)
+	close(other)
+	tree.Finish()
+	if v := <-tree.Output(); v != 45 {
+		t.Errorf("Expected 45, got %d", v)
+	}
+}
+
+// TestDedupeInputs tests that a channel that is already read is skipped
+// silently, by a Folder as well.
+func TestDedupeInputs(t *testing.T) {
+	tree := treeduction.Fold(0, func(n int, s string) int {
+		return n + len(s)
+	}, func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithDuplicatePolicy(treeduction.DedupeInputs))
+
+	ch := make(chan string)
+	if err := tree.Add(ch, ch); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := tree.Add(ch); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	ch <- "ab"
+	ch <- "c"
+	close(ch)
+	tree.Finish()
+	if v := <-tree.Output(); v != 3 {
+		t.Errorf("Expected 3, got %d", v)
+	}
+}
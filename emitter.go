@@ -0,0 +1,67 @@
+package treeduction
+
+import "time"
+
+func (o options) needsEmitter() bool {
+	return o.scan || o.windowSize > 0 || o.timeWindow > 0 || o.faults != nil && o.faults.ReorderRate > 0
+}
+
+// perValue reports whether the root needs every input value on its own, in
+// which case the nodes only fan in and don't combine.
+func (o options) perValue() bool {
+	return o.scan || o.windowSize > 0
+}
+
+// runEmitter owns the root stage when values have to be windowed or scanned
+// before they reach the output.
+func (t *tree[T]) runEmitter() {
+	defer close(t.emitDone)
+
+	var tick <-chan time.Time
+	if t.opts.timeWindow > 0 {
+		ticker := time.NewTicker(t.opts.timeWindow)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	w := window[T]{size: t.opts.windowSize, step: t.opts.windowStep}
+	windowed := w.size > 0 || t.opts.timeWindow > 0
+
+	send, flush := t.reorderer()
+	var total T
+	scanned := false
+	emit := func(v T) {
+		if t.opts.scan {
+			if scanned {
+				v = t.combiner(total, v)
+			}
+			total, scanned = v, true
+		}
+		send(v)
+	}
+
+	for {
+		select {
+		case v, ok := <-t.rootIn:
+			if !ok {
+				if r, ok := w.fold(t.combiner); ok {
+					emit(r)
+				}
+				flush()
+				return
+			}
+			if !windowed {
+				emit(v)
+				continue
+			}
+			if w.add(v) {
+				r, _ := w.fold(t.combiner)
+				emit(r)
+			}
+		case <-tick:
+			if r, ok := w.fold(t.combiner); ok {
+				emit(r)
+			}
+		}
+	}
+}
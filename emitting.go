@@ -0,0 +1,29 @@
+package treeduction
+
+import "slices"
+
+// NewEmitting is like New for a combiner that emits any number of values
+// instead of one: none when a and b cancel out, several when their merge
+// is split (e.g. into batches of a bounded size). The results are the lists
+// of values left by the combines, in order. Merging two lists combines the
+// last value of the first one with the first value of the second one, and
+// keeps what it emits in their place, so the values emitted by a combine
+// are only combined again with their neighbours, higher in the tree.
+func NewEmitting[T any](combiner func(a, b T, emit func(T)), bufferSize int, waitForAll bool, ordered bool, opts ...Option) *Folder[T, []T] {
+	merge := func(f []T, s []T) []T {
+		if len(f) == 0 {
+			return s
+		}
+		if len(s) == 0 {
+			return f
+		}
+		merged := slices.Clone(f[:len(f)-1])
+		combiner(f[len(f)-1], s[0], func(v T) {
+			merged = append(merged, v)
+		})
+		return append(merged, s[1:]...)
+	}
+	return Fold(nil, func(acc []T, v T) []T {
+		return merge(acc, []T{v})
+	}, merge, bufferSize, waitForAll, ordered, opts...)
+}
@@ -0,0 +1,47 @@
+package treeduction_test
+
+import (
+	"slices"
+	"testing"
+	"treeduction"
+)
+
+// TestEmitting tests a combiner that splits its merges into bounded batches.
+func TestEmitting(t *testing.T) {
+	tree := treeduction.NewEmitting(func(a, b []int, emit func([]int)) {
+		all := slices.Concat(a, b)
+		for len(all) > 3 {
+			emit(all[:3])
+			all = all[3:]
+		}
+		emit(all)
+	}, 10, true, true)
+
+	tree.AddValues([]int{1, 2}, []int{3, 4}, []int{5}, []int{6, 7})
+	tree.Finish()
+	var all []int
+	for _, batch := range <-tree.Output() {
+		if len(batch) > 3 {
+			t.Errorf("Expected batches of at most 3 values, got %v", batch)
+		}
+		all = append(all, batch...)
+	}
+	if !slices.Equal(all, []int{1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("Expected the values in order, got %v", all)
+	}
+}
+
+// TestEmittingNothing tests values that cancel out.
+func TestEmittingNothing(t *testing.T) {
+	tree := treeduction.NewEmitting(func(a, b int, emit func(int)) {
+		if a+b != 0 {
+			emit(a + b)
+		}
+	}, 10, true, true)
+
+	tree.AddValues(2, -2, 5)
+	tree.Finish()
+	if r := <-tree.Output(); !slices.Equal(r, []int{5}) {
+		t.Errorf("Expected [5], got %v", r)
+	}
+}
@@ -0,0 +1,83 @@
+package treeduction
+
+import "slices"
+
+// Range is the inputs from First to Last included, numbered from 0 in the
+// order they were added to the tree.
+type Range struct {
+	First, Last int
+}
+
+// Envelope is a result with its place in the output and the inputs whose
+// values were combined into it.
+type Envelope[T any] struct {
+	Value T
+	// Seq is 1 for the first result put on the output and grows by 1 with
+	// every other one, so a gap is a result that was dropped (see
+	// WithBackpressure).
+	Seq    uint64
+	Inputs []Range
+
+	// Whether Value holds a value yet, for the local folds
+	full bool
+}
+
+// NewEnveloped is like New, but every result comes in an Envelope, so that a
+// sink that must handle every result exactly once can detect the gaps and
+// the replays. The values of a call to AddValues or AddSlice count as one
+// input, like an iterator passed to AddSeq.
+func NewEnveloped[T any](combiner func(f T, s T) T, bufferSize int, waitForAll bool, ordered bool, opts ...Option) *Folder[T, Envelope[T]] {
+	f := Fold(Envelope[T]{}, func(e Envelope[T], v T) Envelope[T] {
+		if e.full {
+			e.Value = combiner(e.Value, v)
+		} else {
+			e.Value, e.full = v, true
+		}
+		return e
+	}, func(f Envelope[T], s Envelope[T]) Envelope[T] {
+		return Envelope[T]{Value: combiner(f.Value, s.Value), Inputs: mergeRanges(f.Inputs, s.Inputs), full: true}
+	}, bufferSize, waitForAll, ordered, opts...)
+
+	f.indexes = make(map[<-chan Envelope[T]]int)
+	f.tag = func(e Envelope[T], input int) Envelope[T] {
+		e.Inputs = []Range{{input, input}}
+		return e
+	}
+	f.stamp = func(e Envelope[T], seq uint64) Envelope[T] {
+		e.Seq = seq
+		return e
+	}
+	return f
+}
+
+// number returns the number of an input, which keeps its number when it is
+// added again by Rebalance.
+func (t *tree[T]) number(in <-chan T) int {
+	if t.indexes == nil {
+		return 0
+	}
+	i, ok := t.indexes[in]
+	if !ok {
+		i = len(t.indexes)
+		t.indexes[in] = i
+	}
+	return i
+}
+
+// mergeRanges returns the union of two sorted lists of ranges.
+func mergeRanges(a, b []Range) []Range {
+	all := slices.Concat(a, b)
+	slices.SortFunc(all, func(x, y Range) int {
+		return x.First - y.First
+	})
+
+	merged := make([]Range, 0, len(all))
+	for _, r := range all {
+		if n := len(merged); n > 0 && r.First <= merged[n-1].Last+1 {
+			merged[n-1].Last = max(merged[n-1].Last, r.Last)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,89 @@
+package treeduction_test
+
+import (
+	"slices"
+	"testing"
+	"treeduction"
+)
+
+// TestEnveloped tests that the results are numbered and carry the inputs
+// they cover.
+func TestEnveloped(t *testing.T) {
+	tree := treeduction.NewEnveloped(func(a, b int) int {
+		return a + b
+	}, 10, false, true)
+
+	// In one Add, the first round must not be emitted before the last input
+	inputs := make([]<-chan int, 3)
+	for i := range inputs {
+		c := make(chan int, 2)
+		c <- 1
+		c <- 2
+		close(c)
+		inputs[i] = c
+	}
+	tree.Add(inputs...)
+	for i, want := range []int{3, 6} {
+		e := <-tree.Output()
+		if e.Value != want || e.Seq != uint64(i+1) {
+			t.Errorf("Expected %d with sequence number %d, got %d with %d", want, i+1, e.Value, e.Seq)
+		}
+		if !slices.Equal(e.Inputs, []treeduction.Range{{0, 2}}) {
+			t.Errorf("Expected inputs 0 to 2, got %v", e.Inputs)
+		}
+	}
+	tree.Finish()
+}
+
+// TestEnvelopedGaps tests that the dropped results leave a gap in the
+// sequence numbers.
+func TestEnvelopedGaps(t *testing.T) {
+	tree := treeduction.NewEnveloped(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithOutputBuffer(1), treeduction.WithBackpressure(treeduction.DropOldest))
+
+	c := make(chan int)
+	tree.Add(c)
+	for i := range 5 {
+		c <- i
+	}
+	// The 4th result may still be on its way
+	var e treeduction.Envelope[int]
+	for e.Seq < 5 {
+		e = <-tree.Output()
+	}
+	if e.Value != 4 || !slices.Equal(e.Inputs, []treeduction.Range{{0, 0}}) {
+		t.Errorf("Expected the last value of input 0, got %v", e)
+	}
+	close(c)
+	tree.Finish()
+}
+
+// TestEnvelopedInputs tests that an input keeps its number across
+// Rebalance, and that the ranges of the inputs are merged.
+func TestEnvelopedInputs(t *testing.T) {
+	tree := treeduction.NewEnveloped(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	chans := make([]chan int, 5)
+	for i := range chans {
+		chans[i] = make(chan int, 1)
+		tree.Add(chans[i])
+	}
+	tree.Rebalance()
+	for _, i := range []int{0, 1, 3, 4} {
+		chans[i] <- i
+	}
+	for _, c := range chans {
+		close(c)
+	}
+	tree.Finish()
+	e := <-tree.Output()
+	if e.Value != 8 || e.Seq != 1 {
+		t.Errorf("Expected 8 with sequence number 1, got %d with %d", e.Value, e.Seq)
+	}
+	if want := []treeduction.Range{{0, 1}, {3, 4}}; !slices.Equal(e.Inputs, want) {
+		t.Errorf("Expected %v, got %v", want, e.Inputs)
+	}
+}
@@ -0,0 +1,120 @@
+package treeduction
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Seal ends an epoch of a waitForAll tree: the values of the inputs added so
+// far are reduced to one result, which is put on the output once they are
+// all closed, while the inputs added later are reduced into the next epoch.
+// A long-lived tree can then emit a result per batch instead of being
+// rebuilt for each one. The results come out in the order the epochs were
+// sealed, and Finish puts the result of the last epoch after them. It
+// returns ErrFinished after Finish, and errors.ErrUnsupported without
+// waitForAll or with WithMaxWorkers.
+func (t *tree[T]) Seal() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.finished.Load() {
+		t.fail(ErrFinished)
+		return ErrFinished
+	}
+	if !t.waitForAll || t.pool != nil {
+		return fmt.Errorf("treeduction: epochs need waitForAll and no workers: %w", errors.ErrUnsupported)
+	}
+
+	t.srcMu.Lock()
+	for _, srcs := range t.sources {
+		for _, src := range srcs {
+			src.sealed = true
+		}
+	}
+	t.srcMu.Unlock()
+
+	// The values that already reached the root belong to the epoch
+	var roots []<-chan T
+	runs := t.runs
+	if t.ordered {
+		t.runs = nil
+	} else {
+		close(t.stop)
+		t.stop = make(chan struct{})
+		t.wg.Wait()
+		t.sealOpen()
+		roots = t.levels.takeRoots()
+	}
+	t.epoch(t.nextRecord(false), func(fold func(T) bool) {
+		if t.ordered {
+			t.foldRounds(runs, nil, fold)
+		} else {
+			t.fanIn(roots, fold)
+		}
+	})
+	return nil
+}
+
+// epoch takes the result accumulated so far, and puts it on the output once
+// collect folded the other values of the epoch into it, after the result of
+// the previous epoch, then validates it against rec. t.mu must be held.
+func (t *tree[T]) epoch(rec *record[T], collect func(fold func(T) bool)) {
+	t.accMu.Lock()
+	acc, folded := t.acc, t.folded
+	var zero T
+	t.acc, t.folded = zero, false
+	t.accMu.Unlock()
+
+	prev, done := t.epochDone, make(chan struct{})
+	t.epochDone = done
+	t.epochs.Add(1)
+	t.spawn(func() {
+		defer t.epochs.Done()
+		defer close(done)
+
+		collect(func(v T) bool {
+			w := t.weight(v)
+			if folded {
+				acc = t.combiner(acc, v)
+			} else {
+				acc, folded = v, true
+			}
+			t.leave()
+			t.unhold(w)
+			return true
+		})
+
+		if prev != nil {
+			<-prev
+		}
+		if folded && !t.aborted.Load() {
+			t.send(acc)
+			t.validate(acc, rec)
+		}
+	})
+}
+
+// fanIn calls f with the values of the channels until they are all closed.
+func (t *tree[T]) fanIn(chans []<-chan T, f func(T) bool) {
+	c := make(chan T, t.bufSize)
+	t.track(c, "")
+	var wg sync.WaitGroup
+	wg.Add(len(chans))
+	for _, in := range chans {
+		t.spawn(func() {
+			defer wg.Done()
+			for v := range in {
+				t.forward(c, v)
+			}
+		})
+	}
+	t.spawn(func() {
+		wg.Wait()
+		t.untrack(c)
+		close(c)
+	})
+	for v := range c {
+		f(v)
+	}
+}
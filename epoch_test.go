@@ -0,0 +1,89 @@
+package treeduction_test
+
+import (
+	"errors"
+	"testing"
+	"treeduction"
+)
+
+// TestSeal tests that every epoch is reduced to its own result, in the
+// order they were sealed.
+func TestSeal(t *testing.T) {
+	options := [][]treeduction.Option{nil, {treeduction.WithStrategy(treeduction.KAry(3))}}
+	for _, ordered := range []bool{false, true} {
+		for _, opts := range options {
+			tree := treeduction.New(func(a, b int) int {
+				return a + b
+			}, 10, true, ordered, opts...)
+
+			// The first epoch is still running when the second one is sealed
+			slow := make(chan int)
+			tree.Add(slow)
+			tree.AddValues(1, 2, 3)
+			if err := tree.Seal(); err != nil {
+				t.Fatal(err)
+			}
+			tree.AddValues(10, 20)
+			tree.AddValues(30)
+			if err := tree.Seal(); err != nil {
+				t.Fatal(err)
+			}
+			tree.AddValues(100)
+
+			slow <- 4
+			close(slow)
+			if v := <-tree.Output(); v != 10 {
+				t.Errorf("Expected 10 for the first epoch, got %d", v)
+			}
+			if v := <-tree.Output(); v != 60 {
+				t.Errorf("Expected 60 for the second epoch, got %d", v)
+			}
+			if err := tree.Finish(); err != nil {
+				t.Fatal(err)
+			}
+			if v := <-tree.Output(); v != 100 {
+				t.Errorf("Expected 100 for the last epoch, got %d", v)
+			}
+			if _, ok := <-tree.Output(); ok {
+				t.Error("Expected the output to be closed")
+			}
+		}
+	}
+}
+
+// TestSealRebalance tests that the inputs of a sealed epoch stay in it.
+func TestSealRebalance(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	c := make(chan int)
+	tree.Add(c)
+	tree.Seal()
+	tree.AddValues(5)
+	tree.Rebalance()
+	c <- 1
+	close(c)
+	if v := <-tree.Output(); v != 1 {
+		t.Errorf("Expected 1 for the first epoch, got %d", v)
+	}
+	tree.Finish()
+	if v := <-tree.Output(); v != 5 {
+		t.Errorf("Expected 5 for the last epoch, got %d", v)
+	}
+}
+
+// TestSealUnsupported tests that the trees which stream their results can't
+// be sealed.
+func TestSealUnsupported(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false)
+	if err := tree.Seal(); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+	tree.Finish()
+	if err := tree.Seal(); !errors.Is(err, treeduction.ErrFinished) {
+		t.Errorf("Expected ErrFinished, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package treeduction
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+var (
+	// ErrOutputFull is reported when a result was discarded because of the
+	// FailWhenFull policy.
+	ErrOutputFull = errors.New("treeduction: output is full")
+	// ErrFinished is reported when the tree is used after Finish.
+	ErrFinished = errors.New("treeduction: tree is finished")
+	// ErrNotFinished is returned by Reset when the tree is still running.
+	ErrNotFinished = errors.New("treeduction: tree is not finished")
+	// ErrNoCheckpoint is returned by Checkpoint and Restore for the trees
+	// whose state can't be checkpointed.
+	ErrNoCheckpoint = errors.New("treeduction: tree can't be checkpointed")
+	// ErrNilInput is reported for a nil channel passed to Add, which would
+	// never send nor be closed.
+	ErrNilInput = errors.New("treeduction: input is nil")
+	// ErrDuplicateInput is reported for a channel passed to Add that is
+	// already being read, see RejectDuplicates.
+	ErrDuplicateInput = errors.New("treeduction: input is already being read")
+	// ErrOutputClosed is returned by Next once every result was read.
+	ErrOutputClosed = errors.New("treeduction: output is closed")
+	// ErrStarted is returned by Start when the tree was started already.
+	ErrStarted = errors.New("treeduction: tree is started")
+	// ErrCombineTimeout is reported for a combine that took longer than the
+	// timeout of WithCombineTimeout.
+	ErrCombineTimeout = errors.New("treeduction: combine timed out")
+)
+
+// PanicError is reported when the combiner panics.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("treeduction: combiner panicked: %v", e.Value)
+}
+
+// InputError is reported for a channel passed to Add that was skipped, with
+// its place in the arguments.
+type InputError struct {
+	Index int
+	Err   error
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("treeduction: input %d: %v", e.Index, e.Err)
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// skipInputs drops the nil channels of the inputs, and the ones that are
+// already being read (reading reports those) or passed twice according to
+// the DuplicatePolicy. It returns an InputError for each of them, except the
+// duplicates dropped silently. A closed channel is an input without values.
+func skipInputs[T any](out []<-chan T, p DuplicatePolicy, reading func(<-chan T) bool) ([]<-chan T, error) {
+	var errs []error
+	var seen map[<-chan T]bool
+	if p != AllowDuplicates {
+		seen = make(map[<-chan T]bool, len(out))
+	}
+	kept := make([]<-chan T, 0, len(out))
+	for i, o := range out {
+		switch {
+		case o == nil:
+			errs = append(errs, &InputError{Index: i, Err: ErrNilInput})
+		case seen != nil && (seen[o] || reading(o)):
+			if p == RejectDuplicates {
+				errs = append(errs, &InputError{Index: i, Err: ErrDuplicateInput})
+			}
+		default:
+			kept = append(kept, o)
+			if seen != nil {
+				seen[o] = true
+			}
+		}
+	}
+	if len(kept) == len(out) {
+		return out, nil
+	}
+	return kept, errors.Join(errs...)
+}
+
+// NewFallible is like New, but the combiner can fail. The first error (or
+// panic) stops reading the inputs, and is reported by Err and Finish. The
+// results emitted after it are incomplete.
+func NewFallible[T any](combiner func(f T, s T) (T, error), bufferSize int, waitForAll bool, ordered bool, opts ...Option) Tree[T] {
+	return Typed[T]{}.NewFallible(combiner, bufferSize, waitForAll, ordered, opts...)
+}
+
+// NewFallible is NewFallible with the typed options.
+func (w Typed[T]) NewFallible(combiner func(f T, s T) (T, error), bufferSize int, waitForAll bool, ordered bool, opts ...Option) Tree[T] {
+	t := newTree(bufferSize, waitForAll, ordered, opts, w.opts)
+	t.combiner = t.guard(combiner)
+	return t
+}
+
+// Err returns the first error of the tree, without waiting for it to finish:
+// a combiner error or panic, the cancellation of the parent context, or a
+// misuse of the tree.
+func (t *tree[T]) Err() error {
+	return t.error()
+}
+
+// guard turns combiner errors and panics into a failure of the tree, which
+// also cancels the context of the other combiners. The left value is kept in
+// place of the failed combination.
+func (t *tree[T]) guard(combiner func(f T, s T) (T, error)) func(f T, s T) T {
+	return func(f T, s T) (r T) {
+		if t.opts.adaptiveBatch && t.cost.sample() {
+			start := time.Now()
+			defer func() {
+				t.cost.observe(time.Since(start))
+			}()
+		}
+		if m := t.opts.metrics; m != nil {
+			start := time.Now()
+			defer func() {
+				m.Combined(time.Since(start))
+			}()
+		}
+		defer func() {
+			if p := recover(); p != nil {
+				t.fail(&PanicError{Value: p, Stack: debug.Stack()})
+				t.kill()
+				r = f
+			}
+		}()
+
+		var start time.Time
+		if t.hook != nil {
+			start = time.Now()
+		}
+		if t.cpus != nil {
+			t.cpus <- struct{}{}
+			defer func() { <-t.cpus }()
+		}
+		t.faults.delay()
+		defer t.watchCombine()()
+		r, err := combiner(f, s)
+		if err != nil {
+			t.fail(err)
+			t.kill()
+			return f
+		}
+		if t.hook != nil {
+			t.hook(f, s, r, time.Since(start))
+		}
+		return r
+	}
+}
+
+func (t *tree[T]) fail(err error) {
+	t.errMu.Lock()
+	defer t.errMu.Unlock()
+	if t.err == nil {
+		t.err = err
+		t.log(slog.LevelError, "failed", "error", err)
+	}
+}
+
+func (t *tree[T]) error() error {
+	t.errMu.Lock()
+	defer t.errMu.Unlock()
+	return t.joinErrors()
+}
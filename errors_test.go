@@ -0,0 +1,202 @@
+package treeduction_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestCombinerPanic tests that a panicking combiner fails the tree.
+func TestCombinerPanic(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		panic("boom")
+	}, 10, true, false)
+
+	ch1 := make(chan int, 1)
+	ch2 := make(chan int, 1)
+	ch1 <- 1
+	ch2 <- 2
+	close(ch1)
+	close(ch2)
+	tree.Add(ch1, ch2)
+
+	var perr *treeduction.PanicError
+	if err := tree.Finish(); !errors.As(err, &perr) || perr.Value != "boom" {
+		t.Errorf("Expected a PanicError from Finish(), got %v", err)
+	}
+}
+
+// TestFallibleCombiner tests that combiner errors are reported by Err and Finish.
+func TestFallibleCombiner(t *testing.T) {
+	errOdd := errors.New("odd sum")
+	tree := treeduction.NewFallible(func(a, b int) (int, error) {
+		if (a+b)%2 != 0 {
+			return 0, errOdd
+		}
+		return a + b, nil
+	}, 10, false, false)
+
+	ch1 := make(chan int, 1)
+	ch2 := make(chan int, 1)
+	ch1 <- 1
+	ch2 <- 2
+	tree.Add(ch1, ch2)
+
+	// The tree stops reading once the combiner failed
+	for range tree.Output() {
+		break
+	}
+	if err := tree.Err(); !errors.Is(err, errOdd) {
+		t.Errorf("Expected %v from Err(), got %v", errOdd, err)
+	}
+	if err := tree.Finish(); !errors.Is(err, errOdd) {
+		t.Errorf("Expected %v from Finish(), got %v", errOdd, err)
+	}
+}
+
+// TestParentCancel tests that cancelling the parent context is reported.
+func TestParentCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithContext(ctx))
+
+	ch := make(chan int) // Never closed
+	tree.Add(ch)
+	cancel()
+
+	if err := tree.Finish(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from Finish(), got %v", err)
+	}
+}
+
+// TestAddAfterFinish tests that adding to a finished tree is reported.
+func TestAddAfterFinish(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false)
+
+	if err := tree.Finish(); err != nil {
+		t.Errorf("Unexpected error from Finish(): %v", err)
+	}
+	if err := tree.Add(make(chan int)); !errors.Is(err, treeduction.ErrFinished) {
+		t.Errorf("Expected ErrFinished from Add(), got %v", err)
+	}
+	if err := tree.Err(); !errors.Is(err, treeduction.ErrFinished) {
+		t.Errorf("Expected ErrFinished from Err(), got %v", err)
+	}
+}
+
+// TestFinishTwice tests that a second Finish is reported instead of closing
+// the output again.
+func TestFinishTwice(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	if err := tree.Finish(); err != nil {
+		t.Errorf("Unexpected error from Finish(): %v", err)
+	}
+	if err := tree.Finish(); !errors.Is(err, treeduction.ErrFinished) {
+		t.Errorf("Expected ErrFinished from the second Finish(), got %v", err)
+	}
+	// A deferred Finish next to the one of the happy path doesn't fail the
+	// tree
+	if err := tree.Err(); err != nil {
+		t.Errorf("Expected no error from Err(), got %v", err)
+	}
+	if errs := tree.Errors(); len(errs) != 0 {
+		t.Errorf("Expected no error from Errors(), got %v", errs)
+	}
+}
+
+// TestFinishConcurrently tests that the calls made while the tree finishes
+// wait for it, and only one of them finishes it.
+func TestFinishConcurrently(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	// Abort may come first, and stop reading c
+	c := make(chan int, 1)
+	tree.Add(c)
+	errs := make(chan error, 3)
+	for _, finish := range []func() error{tree.Finish, tree.Finish, tree.Abort} {
+		go func() {
+			errs <- finish()
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	c <- 42
+	close(c)
+
+	var finished int
+	for range 3 {
+		switch err := <-errs; {
+		case err == nil:
+			finished++
+		case !errors.Is(err, treeduction.ErrFinished):
+			t.Errorf("Expected ErrFinished, got %v", err)
+		}
+	}
+	if finished != 1 {
+		t.Errorf("Expected the tree to be finished once, got %d", finished)
+	}
+}
+
+// TestDeferredFinish tests that a deferred Finish after the one of the happy
+// path doesn't disturb the result.
+func TestDeferredFinish(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	func() {
+		defer tree.Finish()
+		tree.AddValues(1, 2, 3)
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if v, ok := <-tree.Output(); !ok || v != 6 {
+		t.Errorf("Expected 6, got %d", v)
+	}
+	if _, ok := <-tree.Output(); ok {
+		t.Error("Expected the output to be closed")
+	}
+}
+
+// TestAddNil tests that nil channels are skipped with an error, and closed
+// ones reduced as empty inputs.
+func TestAddNil(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	closed := make(chan int)
+	close(closed)
+
+	err := tree.Add(values(1, 2), nil, closed, nil)
+	var inputErr *treeduction.InputError
+	if !errors.Is(err, treeduction.ErrNilInput) || !errors.As(err, &inputErr) || inputErr.Index != 1 {
+		t.Errorf("Expected ErrNilInput for input 1, got %v", err)
+	}
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-tree.Output(); r != 3 {
+		t.Errorf("Expected 3, got %d", r)
+	}
+
+	counted := treeduction.NewCounted(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	if err := counted.Add(nil, values(4)); !errors.Is(err, treeduction.ErrNilInput) {
+		t.Errorf("Expected ErrNilInput from a Folder, got %v", err)
+	}
+	counted.Finish()
+	if r := <-counted.Output(); r.Value != 4 || r.Count != 1 {
+		t.Errorf("Expected 4 from 1 value, got %+v", r)
+	}
+}
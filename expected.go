@@ -0,0 +1,129 @@
+package treeduction
+
+import "math/bits"
+
+// WithExpectedInputs builds a balanced binary tree for n inputs up front,
+// which the inputs fill in the order they are added, instead of the shape
+// that grows with each Add. Every input is then the same number of combines
+// away from the root, however the inputs are split between calls to Add, so
+// the shape of the tree is reproducible. The inputs past the n-th one are
+// added as usual, and the inputs added with a priority count as inputs of
+// the frame without taking a slot. As long as fewer than n inputs were
+// added, the values of an input whose sibling slot is still free are held
+// by their node, so without waitForAll they only come out once the n-th
+// input is added, or by Finish. The tree is built again after Rebalance and
+// Seal. It only applies to binary unordered trees without WithMaxWorkers,
+// ordered trees are balanced on every Add.
+func WithExpectedInputs(n int) Option {
+	if n <= 0 {
+		panic("treeduction: number of expected inputs must be positive")
+	}
+	return func(o *options) {
+		o.expected = n
+	}
+}
+
+// frame is a balanced tree built ahead of its inputs.
+type frame[T any] struct {
+	// The node of each input, in order
+	slots []*knode[T]
+	next  int
+	// The inputs added with a priority, which count against the slots
+	lanes int
+}
+
+// openFrame returns the frame of the tree, building it first, or nil
+// without WithExpectedInputs.
+func (t *tree[T]) openFrame() *frame[T] {
+	if t.opts.expected == 0 || t.opts.arity() != 2 {
+		return nil
+	}
+	if t.frame == nil {
+		t.frame = &frame[T]{}
+		root, depth := t.build(t.opts.expected, &t.frame.slots, nil)
+		t.addOne(root, depth)
+	}
+	return t.frame
+}
+
+// place adds an input to the next free slot of the frame. It reports false
+// if the input doesn't fit.
+func (t *tree[T]) place(c <-chan T) bool {
+	f := t.openFrame()
+	if f == nil || f.next == len(f.slots) {
+		return false
+	}
+	n := f.slots[f.next]
+	f.next++
+	t.adopt(n, c)
+	if n.n == n.size {
+		t.seal(n)
+	}
+	if f.next+f.lanes >= len(f.slots) {
+		t.sealSlots(f)
+	}
+	return true
+}
+
+// countLanes counts k inputs added with a priority against the frame.
+func (t *tree[T]) countLanes(k int) {
+	f := t.openFrame()
+	if f == nil || f.next == len(f.slots) {
+		return
+	}
+	f.lanes += k
+	if f.next+f.lanes >= len(f.slots) {
+		t.sealSlots(f)
+	}
+}
+
+// build returns the root of a balanced tree with n slots, and its depth.
+func (t *tree[T]) build(n int, slots *[]*knode[T], parent *knode[T]) (<-chan T, int) {
+	node := t.openNode(max(1, bits.Len(uint(n-1))))
+	node.parent = parent
+	halves := []int{n / 2, n - n/2}
+	if n == 1 {
+		halves = halves[1:]
+	}
+	node.size = len(halves)
+
+	depth := 0
+	for _, k := range halves {
+		if k == 1 {
+			*slots = append(*slots, node)
+			continue
+		}
+		child, d := t.build(k, slots, node)
+		t.adopt(node, child)
+		depth = max(depth, d)
+	}
+	if node.n == node.size {
+		t.seal(node)
+	}
+	return node.out, depth + 1
+}
+
+// sealFrame seals the nodes of the frame whose slots weren't all filled,
+// since no more inputs are coming.
+func (t *tree[T]) sealFrame() {
+	if f := t.frame; f != nil {
+		t.sealSlots(f)
+	}
+	t.frame = nil
+}
+
+// sealSlots seals the nodes of the free slots of f, which won't be filled.
+// The values of their last open child aren't held for a pair anymore, nor
+// the ones of their parents.
+func (t *tree[T]) sealSlots(f *frame[T]) {
+	for i := f.next; i < len(f.slots); i++ {
+		// A node with two free slots appears twice
+		if n := f.slots[i]; i == f.next || f.slots[i-1] != n {
+			t.seal(n)
+			for ; n != nil && n.lone == 0; n = n.parent {
+				n.release()
+			}
+		}
+	}
+	f.next = len(f.slots)
+}
@@ -0,0 +1,55 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestExpectedInputs tests that the inputs fill a balanced tree built up
+// front, whether or not they all come.
+func TestExpectedInputs(t *testing.T) {
+	for _, added := range []int{6, 3, 8} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false, treeduction.WithExpectedInputs(6), treeduction.WithLeakCheck(time.Second))
+
+		// One by one, the shape doesn't depend on it
+		for range added {
+			tree.AddValues(1)
+		}
+		// A node per pair of slots
+		if n := tree.Stats().Nodes; added <= 6 && n != 5 {
+			t.Errorf("Expected 5 nodes for 6 inputs, got %d", n)
+		}
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		if v := <-tree.Output(); v != added {
+			t.Errorf("Expected %d, got %d", added, v)
+		}
+	}
+}
+
+// TestExpectedInputsPriority tests that the inputs added with a priority
+// count against the frame, so that the values of a streaming tree aren't
+// held until Finish once n inputs were added.
+func TestExpectedInputsPriority(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithExpectedInputs(4))
+	defer tree.Abort()
+
+	c := make(chan int, 1)
+	tree.Add(c)
+	tree.AddWithPriority(1, make(chan int), make(chan int), make(chan int))
+	c <- 1
+	select {
+	case v := <-tree.Output():
+		if v != 1 {
+			t.Errorf("Expected 1, got %d", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the value before Finish")
+	}
+}
@@ -0,0 +1,116 @@
+package treeduction
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// FaultPolicy is the faults injected with WithFaultInjection. The rates are
+// probabilities, from 0 (never) to 1 (always).
+type FaultPolicy struct {
+	// A combine is delayed by up to MaxDelay with a probability of DelayRate
+	DelayRate float64
+	MaxDelay  time.Duration
+	// A result is held back and put on the output after the next one, which
+	// overtakes it
+	ReorderRate float64
+	// A value read from an input is dropped, as if it didn't fit with
+	// WithDropWhenFull
+	DropRate float64
+	// The seed of the faults, a random one if 0. The goroutines of the tree
+	// still run in any order, so a run can't be replayed.
+	Seed uint64
+}
+
+// WithFaultInjection makes the tree misbehave in the ways it is allowed to,
+// and then some, so that a test can check that the code reading the output
+// copes: combines that take long, results that come out of order and values
+// that are lost. It is meant for tests only. Reordering only applies without
+// waitForAll, and a result held back waits for the next one, or for Finish.
+func WithFaultInjection(p FaultPolicy) Option {
+	for _, r := range []float64{p.DelayRate, p.ReorderRate, p.DropRate} {
+		if r < 0 || r > 1 {
+			panic("treeduction: fault rates must be between 0 and 1")
+		}
+	}
+	return func(o *options) {
+		o.faults = &p
+	}
+}
+
+// faults draws the faults of a tree.
+type faults struct {
+	FaultPolicy
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newFaults(p *FaultPolicy) *faults {
+	if p == nil {
+		return nil
+	}
+	seed := p.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &faults{FaultPolicy: *p, rng: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// roll reports whether a fault of the given rate happens.
+func (f *faults) roll(rate float64) bool {
+	if rate == 0 {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rng.Float64() < rate
+}
+
+// drops reports whether a value read from an input is to be dropped.
+func (f *faults) drops() bool {
+	return f != nil && f.roll(f.DropRate)
+}
+
+// reorders reports whether a result is to be held back.
+func (f *faults) reorders() bool {
+	return f != nil && f.roll(f.ReorderRate)
+}
+
+// delay holds a combine back, if it is to be delayed.
+func (f *faults) delay() {
+	if f == nil || f.MaxDelay <= 0 || !f.roll(f.DelayRate) {
+		return
+	}
+	f.mu.Lock()
+	d := rand.N(f.MaxDelay + 1)
+	f.mu.Unlock()
+	time.Sleep(d)
+}
+
+// reorderer returns the function that puts the results of the emitter on
+// the output, holding some back to let the next one overtake them, and a
+// function to flush the one held back.
+func (t *tree[T]) reorderer() (send func(T), flush func()) {
+	var held T
+	holding := false
+	send = func(v T) {
+		switch {
+		case holding:
+			t.send(v)
+			t.send(held)
+			holding = false
+		case t.faults.reorders():
+			held, holding = v, true
+		default:
+			t.send(v)
+		}
+	}
+	flush = func() {
+		if holding {
+			t.send(held)
+			holding = false
+		}
+	}
+	return send, flush
+}
@@ -0,0 +1,99 @@
+package treeduction_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestFaultDrop tests that a fraction of the values is dropped.
+func TestFaultDrop(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithFaultInjection(treeduction.FaultPolicy{DropRate: 0.5, Seed: 1}))
+	c := make(chan int, 1000)
+	for range 1000 {
+		c <- 1
+	}
+	close(c)
+	tree.Add(c)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	v := <-tree.Output()
+	if v == 0 || v == 1000 {
+		t.Errorf("Expected some values to be dropped, got %d", v)
+	}
+}
+
+// TestFaultReorder tests that the results held back are overtaken by the
+// next ones.
+func TestFaultReorder(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false,
+		treeduction.WithTumblingWindow(1),
+		treeduction.WithFaultInjection(treeduction.FaultPolicy{ReorderRate: 1}))
+	c := make(chan int, 5)
+	for i := range 5 {
+		c <- i
+	}
+	close(c)
+	tree.Add(c)
+
+	var got []int
+	for range 4 {
+		got = append(got, <-tree.Output())
+	}
+	// The last result is only flushed by Finish
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	for v := range tree.Output() {
+		got = append(got, v)
+	}
+	if want := []int{1, 0, 3, 2, 4}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestFaultDelay tests that the combines are delayed.
+func TestFaultDelay(t *testing.T) {
+	var mu sync.Mutex
+	var longest time.Duration
+	tree := treeduction.With(treeduction.WithCombineHook(func(a, b, r int, d time.Duration) {
+		mu.Lock()
+		longest = max(longest, d)
+		mu.Unlock()
+	})).New(func(a, b int) int {
+		return a + b
+	}, 10, true, false,
+		treeduction.WithFaultInjection(treeduction.FaultPolicy{DelayRate: 1, MaxDelay: 20 * time.Millisecond}))
+	for range 20 {
+		c := make(chan int, 1)
+		c <- 1
+		close(c)
+		tree.Add(c)
+	}
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 20 {
+		t.Errorf("Expected 20, got %d", v)
+	}
+	if longest < time.Millisecond {
+		t.Errorf("Expected the combines to be delayed, the longest took %v", longest)
+	}
+}
+
+// TestFaultRates tests that the rates must be probabilities.
+func TestFaultRates(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+	treeduction.WithFaultInjection(treeduction.FaultPolicy{DropRate: 2})
+}
@@ -0,0 +1,35 @@
+package treeduction
+
+// WithFilter drops the values for which keep returns false as they are read
+// from the inputs, so that values known to change nothing (like empty
+// partial aggregates) don't cost a combine. In ordered mode a value that is
+// dropped doesn't take its place in its round, so the next values of its
+// input move up a round.
+func WithFilter[T any](keep func(T) bool) TypedOption[T] {
+	return func(_ *options, ty *typed[T]) {
+		ty.filter = keep
+	}
+}
+
+// admit prepares a value read from an input for the tree. It reports false
+// if the value doesn't enter it.
+func (t *tree[T]) admit(v T, src *source) (T, bool) {
+	if t.filter != nil && !t.filter(v) || t.duplicate(v) {
+		t.leave()
+		return v, false
+	}
+	if t.faults.drops() {
+		t.drop(src)
+		return v, false
+	}
+	t.logValue(v, src)
+	if t.tag != nil {
+		v = t.tag(v, src.index)
+	}
+	if !t.hold(v) {
+		t.leave()
+		return v, false
+	}
+	t.remember(v, src)
+	return v, true
+}
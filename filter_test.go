@@ -0,0 +1,36 @@
+package treeduction_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"treeduction"
+)
+
+// TestFilter tests that the values dropped by the filter never reach the
+// combiner.
+func TestFilter(t *testing.T) {
+	for _, opts := range [][]treeduction.Option{nil, {treeduction.WithMaxWorkers(2)}} {
+		var combines atomic.Int64
+		tree := treeduction.With(treeduction.WithFilter(func(v int) bool {
+			return v != 0
+		})).New(func(a, b int) int {
+			if a == 0 || b == 0 {
+				t.Error("Expected the zeros to be filtered out")
+			}
+			combines.Add(1)
+			return a + b
+		}, 10, true, false, opts...)
+
+		tree.AddValues(1, 0, 2, 0, 0, 3)
+		tree.AddValues(0, 0, 4)
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		if v := <-tree.Output(); v != 10 {
+			t.Errorf("Expected 10, got %d", v)
+		}
+		if n := combines.Load(); n != 3 {
+			t.Errorf("Expected 3 combines, got %d", n)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package treeduction
+
+import (
+	"iter"
+	"sync"
+)
+
+// Folder is a tree whose inputs are values of T, folded into accumulators of
+// type A, see Fold. The methods that take inputs take values of T, the other
+// ones are the methods of Tree[A].
+type Folder[T, A any] struct {
+	*tree[A]
+	init A
+	step func(A, T) A
+
+	// The inputs that are still being read
+	inMu   sync.Mutex
+	inputs map[<-chan T][]finput
+}
+
+// finput is a reading of an input of a Folder: detach is closed by Remove,
+// done by the reader once it stopped.
+type finput struct {
+	detach chan struct{}
+	done   chan struct{}
+}
+
+// Fold is like New, but the accumulator type A differs from the type T of
+// the input values (e.g. a sum and count pair for an average of float64
+// values). Every value is turned into an accumulator with step(init, v), and
+// the accumulators are merged by the tree, so init must be neutral for merge.
+func Fold[T, A any](init A, step func(A, T) A, merge func(A, A) A, bufferSize int, waitForAll bool, ordered bool, opts ...Option) *Folder[T, A] {
+	return FoldWith(Typed[A]{}, init, step, merge, bufferSize, waitForAll, ordered, opts...)
+}
+
+// FoldWith is Fold with the typed options of w, which take accumulators.
+func FoldWith[T, A any](w Typed[A], init A, step func(A, T) A, merge func(A, A) A, bufferSize int, waitForAll bool, ordered bool, opts ...Option) *Folder[T, A] {
+	t := newTree(bufferSize, waitForAll, ordered, opts, w.opts)
+	t.combiner = t.guard(func(f A, s A) (A, error) {
+		return merge(f, s), nil
+	})
+	return &Folder[T, A]{tree: t, init: init, step: step, inputs: make(map[<-chan T][]finput)}
+}
+
+// WithLocalFold makes a Folder fold the values of each input into an
+// accumulator in the goroutine reading it, with step, so that only a partial
+// per input enters the tree instead of every value (a map-side combine).
+// With waitForAll the partial is sent when the input is closed or removed,
+// or the tree paused, otherwise as soon as the input has no other value
+// ready. step must then fold a value into any accumulator:
+// step(acc, v) = merge(acc, step(init, v)).
+func WithLocalFold() Option {
+	return func(o *options) {
+		o.localFold = true
+	}
+}
+
+// Add starts reducing the values of the given channels, see Tree.Add.
+func (f *Folder[T, A]) Add(out ...<-chan T) error {
+	return f.add(0, out, f.accumulate, f.step)
+}
+
+// AddWithPriority is like Add for inputs with a priority, see
+// Tree.AddWithPriority.
+func (f *Folder[T, A]) AddWithPriority(p int, out ...<-chan T) error {
+	return f.add(p, out, f.accumulate, f.step)
+}
+
+func (f *Folder[T, A]) accumulate(v T) A {
+	return f.step(f.init, v)
+}
+
+// add reads the inputs into the tree with a priority, turning their values
+// into accumulators with conv. With WithLocalFold the next values of an input
+// are folded into its accumulator with step until it is sent.
+func (f *Folder[T, A]) add(priority int, out []<-chan T, conv func(T) A, step func(A, T) A) error {
+	out, skipped := skipInputs(out, f.opts.duplicates, f.reading)
+	accs := make([]<-chan A, len(out))
+	chans := make([]chan A, len(out))
+	for i := range out {
+		chans[i] = make(chan A, f.bufSize)
+		accs[i] = chans[i]
+	}
+	// The tree doesn't pause the channels fed here, the inputs are paused
+	// instead
+	f.srcMu.Lock()
+	for _, c := range accs {
+		f.internal[c] = struct{}{}
+	}
+	f.srcMu.Unlock()
+	if err := f.tree.AddWithPriority(priority, accs...); err != nil {
+		f.release(accs...)
+		return err
+	}
+
+	for i, o := range out {
+		c := chans[i]
+		in := finput{detach: make(chan struct{}), done: make(chan struct{})}
+		detach := in.detach
+		f.inMu.Lock()
+		f.inputs[o] = append(f.inputs[o], in)
+		f.inMu.Unlock()
+
+		f.readers.Add(1)
+		limit := newBucket(f.opts.sourceRate)
+		f.spawn(func() {
+			defer f.readers.Add(-1)
+			defer f.release(c)
+			defer close(c)
+			defer f.forgetInput(o, in)
+
+			// The accumulator of the values folded locally holds the slot
+			// of the first one
+			var acc A
+			held := false
+			send := func() bool {
+				if !held {
+					return true
+				}
+				select {
+				case c <- acc:
+					held = false
+					return true
+				case <-f.ctx.Done():
+					return false
+				}
+			}
+			for {
+				if resume := f.paused(); resume != nil {
+					if !send() {
+						return
+					}
+					f.parked.Add(1)
+					select {
+					case <-resume:
+					case <-detach:
+						f.parked.Add(-1)
+						f.flushInput(o, c, conv)
+						return
+					case <-f.ctx.Done():
+						f.parked.Add(-1)
+						return
+					}
+					f.parked.Add(-1)
+				}
+				if !f.throttle(limit, detach) || !held && !f.enter(detach) {
+					select {
+					case <-detach:
+						f.flushInput(o, c, conv)
+					default:
+					}
+					return
+				}
+				select {
+				case v, ok := <-o:
+					if !ok {
+						if !held {
+							f.vacate()
+						}
+						send()
+						return
+					}
+					if f.opts.localFold {
+						if held {
+							acc = step(acc, v)
+						} else {
+							acc, held = conv(v), true
+							f.alive.Add(1)
+						}
+						// Without waitForAll the results keep flowing
+						if f.waitForAll || len(o) > 0 {
+							continue
+						}
+						if !send() {
+							return
+						}
+						continue
+					}
+					f.alive.Add(1)
+					select {
+					case c <- conv(v):
+					case <-f.ctx.Done():
+						return
+					}
+				case <-f.pausing():
+					if !held {
+						f.vacate()
+					}
+				case <-detach:
+					if !held {
+						f.vacate()
+					}
+					if send() {
+						f.flushInput(o, c, conv)
+					}
+					return
+				case <-f.ctx.Done():
+					return
+				}
+			}
+		}, "role", "input")
+	}
+	return skipped
+}
+
+// release forgets internal channels that won't be read anymore.
+func (f *Folder[T, A]) release(chans ...<-chan A) {
+	f.srcMu.Lock()
+	defer f.srcMu.Unlock()
+	for _, c := range chans {
+		delete(f.internal, c)
+	}
+}
+
+// AddWithErr is like Add for a single input whose producer reports its
+// failures on errs, see Tree.AddWithErr.
+func (f *Folder[T, A]) AddWithErr(in <-chan T, errs <-chan error) error {
+	if err := f.Add(in); err != nil {
+		return err
+	}
+	f.watch(errs, func() {
+		f.Remove(in)
+	})
+	return nil
+}
+
+// Go runs a producer of values of T in a goroutine of the tree, see
+// Tree.Go.
+func (f *Folder[T, A]) Go(p func(emit func(T)) error) error {
+	c := make(chan T)
+	errs := make(chan error, 1)
+	if err := f.AddWithErr(c, errs); err != nil {
+		return err
+	}
+	f.spawn(func() {
+		produce(f.tree, c, errs, p)
+	}, "role", "producer")
+	return nil
+}
+
+// AddSeq starts reducing the values of the given iterators, see Tree.AddSeq.
+func (f *Folder[T, A]) AddSeq(seqs ...iter.Seq[T]) error {
+	accs := make([]iter.Seq[A], len(seqs))
+	for i, seq := range seqs {
+		accs[i] = func(yield func(A) bool) {
+			for v := range seq {
+				if !yield(f.accumulate(v)) {
+					return
+				}
+			}
+		}
+	}
+	return f.tree.AddSeq(accs...)
+}
+
+// AddSlice adds the values of s as a single input, see Tree.AddSlice.
+func (f *Folder[T, A]) AddSlice(s []T) error {
+	if f.opts.localFold && len(s) > 0 {
+		acc := f.accumulate(s[0])
+		for _, v := range s[1:] {
+			acc = f.step(acc, v)
+		}
+		return f.tree.AddValues(acc)
+	}
+	accs := make([]A, len(s))
+	for i, v := range s {
+		accs[i] = f.accumulate(v)
+	}
+	return f.tree.AddSlice(accs)
+}
+
+// AddValues adds the given values as a single input, see Tree.AddValues.
+func (f *Folder[T, A]) AddValues(vs ...T) error {
+	return f.AddSlice(vs)
+}
+
+// Remove stops reading from a channel that was passed to Add, see
+// Tree.Remove.
+func (f *Folder[T, A]) Remove(in <-chan T) bool {
+	f.unproduce(in)
+	f.inMu.Lock()
+	ins, ok := f.inputs[in]
+	dones := make([]chan struct{}, len(ins))
+	for i, r := range ins {
+		close(r.detach)
+		dones[i] = r.done
+	}
+	delete(f.inputs, in)
+	f.inMu.Unlock()
+
+	f.awaitReaders(dones)
+	return ok
+}
+
+// flushInput moves the values buffered in a removed input into the tree.
+func (f *Folder[T, A]) flushInput(in <-chan T, c chan<- A, conv func(T) A) {
+	for {
+		if !f.enter(nil) {
+			return
+		}
+		select {
+		case v, ok := <-in:
+			if !ok {
+				f.vacate()
+				return
+			}
+			f.alive.Add(1)
+			if !f.forward(c, conv(v)) {
+				return
+			}
+		default:
+			f.vacate()
+			return
+		}
+	}
+}
+
+func (f *Folder[T, A]) forgetInput(in <-chan T, r finput) {
+	defer close(r.done)
+	f.inMu.Lock()
+	defer f.inMu.Unlock()
+
+	ds := f.inputs[in]
+	for i, d := range ds {
+		if d == r {
+			ds = append(ds[:i], ds[i+1:]...)
+			break
+		}
+	}
+	if len(ds) == 0 {
+		delete(f.inputs, in)
+	} else {
+		f.inputs[in] = ds
+	}
+}
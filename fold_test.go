@@ -0,0 +1,155 @@
+package treeduction_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"treeduction"
+)
+
+type mean struct {
+	sum   float64
+	count int
+}
+
+func foldMean() *treeduction.Folder[float64, mean] {
+	return treeduction.Fold(mean{}, func(m mean, v float64) mean {
+		return mean{m.sum + v, m.count + 1}
+	}, func(a, b mean) mean {
+		return mean{a.sum + b.sum, a.count + b.count}
+	}, 10, true, false)
+}
+
+// TestFold tests a reduction into an accumulator of another type.
+func TestFold(t *testing.T) {
+	tree := foldMean()
+
+	ch := make(chan float64, 2)
+	ch <- 1
+	ch <- 2
+	close(ch)
+	tree.Add(ch)
+	tree.AddValues(3, 4)
+	tree.AddSlice([]float64{5})
+
+	tree.Finish()
+	m := <-tree.Output()
+	if m.count != 5 || m.sum/float64(m.count) != 3 {
+		t.Errorf("Expected a mean of 3 over 5 values, got %v", m)
+	}
+}
+
+// TestFoldRemove tests removing an input of a Folder.
+func TestFoldRemove(t *testing.T) {
+	tree := foldMean()
+
+	ch := make(chan float64, 2) // Never closed
+	tree.Add(ch)
+	ch <- 1
+	ch <- 2
+	if !tree.Remove(ch) {
+		t.Error("Expected Remove() to report the channel")
+	}
+
+	tree.Finish()
+	if m := <-tree.Output(); m.count != 2 {
+		t.Errorf("Expected 2 values, got %v", m)
+	}
+}
+
+// TestFoldRemoveThenSend tests that a value sent after Remove returned
+// stays in the channel.
+func TestFoldRemoveThenSend(t *testing.T) {
+	for range 50 {
+		tree := foldMean()
+		ch := make(chan float64, 1) // Never closed
+		tree.Add(ch)
+		tree.Remove(ch)
+		ch <- 50
+		tree.AddValues(1)
+
+		tree.Finish()
+		if m := <-tree.Output(); m.count != 1 {
+			t.Fatalf("Expected 1 value, got %v", m)
+		}
+		if len(ch) != 1 {
+			t.Fatal("Expected the value to stay in the channel")
+		}
+	}
+}
+
+// TestLocalFold tests that only a partial per input enters the tree with
+// waitForAll.
+func TestLocalFold(t *testing.T) {
+	var merges atomic.Int64
+	tree := treeduction.Fold(0, func(n int, s string) int {
+		return n + len(s)
+	}, func(a, b int) int {
+		merges.Add(1)
+		return a + b
+	}, 10, true, false, treeduction.WithLocalFold())
+
+	for range 4 {
+		c := make(chan string, 100)
+		for range 100 {
+			c <- "ab"
+		}
+		close(c)
+		tree.Add(c)
+	}
+	tree.AddValues("a", "b", "c")
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 803 {
+		t.Errorf("Expected 803, got %d", v)
+	}
+	if n := merges.Load(); n != 4 {
+		t.Errorf("Expected 4 merges of the partials, got %d", n)
+	}
+}
+
+// TestLocalFoldStreaming tests that the values are still reduced without
+// waitForAll.
+func TestLocalFoldStreaming(t *testing.T) {
+	tree := treeduction.NewCounted(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithLocalFold())
+
+	c := make(chan int)
+	tree.Add(c)
+	sum, count := 0, int64(0)
+	for i := 1; i <= 10; i++ {
+		c <- i
+		for count < int64(i) {
+			r := <-tree.Output()
+			sum += r.Value
+			count += r.Count
+		}
+	}
+	close(c)
+	tree.Finish()
+	if sum != 55 {
+		t.Errorf("Expected 55, got %d", sum)
+	}
+}
+
+// TestLocalFoldWeighted tests that the values of a weighted input are folded
+// with their weight.
+func TestLocalFoldWeighted(t *testing.T) {
+	tree := treeduction.NewWeighted(func(f, s treeduction.Weighted[float64]) float64 {
+		return (f.Value*f.Weight + s.Value*s.Weight) / (f.Weight + s.Weight)
+	}, 10, true, false, treeduction.WithLocalFold())
+
+	c := make(chan float64, 2)
+	c <- 1
+	c <- 1
+	close(c)
+	tree.AddWeighted(2, c)
+	tree.AddValues(4, 4, 4, 4)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-tree.Output(); r.Value != 2.5 || r.Weight != 8 {
+		t.Errorf("Expected 2.5 with a weight of 8, got %v with %v", r.Value, r.Weight)
+	}
+}
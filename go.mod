@@ -1,3 +1,5 @@
 module treeduction
 
 go 1.23.5
+
+require golang.org/x/sync v0.16.0
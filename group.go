@@ -0,0 +1,41 @@
+package treeduction
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// AttachGroup ties the tree to the lifecycle of an errgroup whose context is
+// ctx: a goroutine of g waits for the tree to finish and returns its error.
+// If the tree fails first (a combiner error, the cancellation of its parent
+// context), or ctx is done first because another goroutine of g failed, the
+// tree is aborted, so that g.Wait returns once every goroutine of the tree
+// exited and the failure of the tree cancels the rest of g. The goroutines
+// of the tree still run on their own, they aren't counted by the limit of g.
+func (t *tree[T]) AttachGroup(g *errgroup.Group, ctx context.Context) {
+	done, life := t.done, t.life
+	g.Go(func() error {
+		select {
+		case <-done:
+			return t.error()
+		case <-life.Done():
+			err := t.error()
+			t.abortIfRunning(done)
+			return err
+		case <-ctx.Done():
+			return t.abortIfRunning(done)
+		}
+	})
+}
+
+// abortIfRunning aborts the tree unless it was finished already, and waits
+// for done.
+func (t *tree[T]) abortIfRunning(done <-chan struct{}) error {
+	var err error
+	if !t.finished.Load() {
+		err = t.Abort()
+	}
+	<-done
+	return err
+}
@@ -0,0 +1,78 @@
+package treeduction_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"treeduction"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// TestAttachGroup tests that the error of the tree is returned by the group,
+// and that the tree is aborted when another goroutine of the group fails.
+func TestAttachGroup(t *testing.T) {
+	t.Run("finished", func(t *testing.T) {
+		g, ctx := errgroup.WithContext(context.Background())
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false)
+		tree.AttachGroup(g, ctx)
+		tree.AddValues(1, 2, 3)
+		g.Go(tree.Finish)
+		if err := g.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		if v := <-tree.Output(); v != 6 {
+			t.Errorf("Expected 6, got %d", v)
+		}
+	})
+
+	t.Run("tree fails", func(t *testing.T) {
+		errBad := errors.New("bad")
+		g, ctx := errgroup.WithContext(context.Background())
+		tree := treeduction.NewFallible(func(a, b int) (int, error) {
+			return 0, errBad
+		}, 10, true, false)
+		tree.AttachGroup(g, ctx)
+		// Never closed, only the failure of the tree stops the group
+		tree.Add(make(chan int))
+		tree.AddValues(1, 2)
+		g.Go(func() error {
+			<-ctx.Done()
+			return nil
+		})
+		if err := g.Wait(); !errors.Is(err, errBad) {
+			t.Errorf("Expected %v, got %v", errBad, err)
+		}
+		select {
+		case <-tree.Done():
+		default:
+			t.Error("Expected the tree to be done")
+		}
+	})
+
+	t.Run("group fails", func(t *testing.T) {
+		errBad := errors.New("bad")
+		g, ctx := errgroup.WithContext(context.Background())
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false)
+		tree.AttachGroup(g, ctx)
+		tree.Add(make(chan int))
+		g.Go(func() error {
+			time.Sleep(10 * time.Millisecond)
+			return errBad
+		})
+		if err := g.Wait(); !errors.Is(err, errBad) {
+			t.Errorf("Expected %v, got %v", errBad, err)
+		}
+		if g := tree.Stats().Goroutines; g != 0 {
+			t.Errorf("Expected no goroutine, got %d", g)
+		}
+		if _, ok := <-tree.Output(); ok {
+			t.Error("Expected the output to be closed")
+		}
+	})
+}
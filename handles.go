@@ -0,0 +1,75 @@
+package treeduction
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"iter"
+)
+
+// Producer is the half of a tree that feeds it, for the code that only adds
+// inputs and ends the reduction, see ProducerOf.
+type Producer[T any] interface {
+	Add(out ...<-chan T) error
+	AddSeq(seqs ...iter.Seq[T]) error
+	AddSlice(s []T) error
+	AddValues(vs ...T) error
+	AddWithErr(in <-chan T, errs <-chan error) error
+	AddWithPriority(p int, out ...<-chan T) error
+	AddTree(sub Tree[T]) error
+	AddReader(r io.Reader, decode func(*bufio.Reader) (T, error)) error
+	AddProducer(produce func(ctx context.Context) <-chan T) error
+	AddAny(ch <-chan any, convert func(any) (T, bool)) error
+	AddUntil(ch <-chan T, isLast func(T) bool, keepLast bool) error
+	AddSources(set *SourceSet[T]) error
+	Go(f func(emit func(T)) error) error
+	Seal() error
+	Finish() error
+	FinishContext(ctx context.Context) error
+	Abort() error
+}
+
+// Consumer is the half of a tree that reads its results, for the code that
+// only waits for them, see ConsumerOf.
+type Consumer[T any] interface {
+	Output() <-chan T
+	OutputBatches(n int) <-chan []T
+	OutputFor(class string) <-chan T
+	Classes() []string
+	Partitions() []<-chan T
+	Results() iter.Seq[T]
+	Results2() iter.Seq2[T, error]
+	Drain(ctx context.Context) ([]T, error)
+	TryNext() (T, bool)
+	Next(ctx context.Context) (T, error)
+	TakeN(ctx context.Context, n int) ([]T, error)
+	Done() <-chan struct{}
+	Err() error
+	Errors() []error
+	Abort() error
+}
+
+// producer hides the methods of a tree that aren't the ones of Producer,
+// from type assertions too.
+type producer[T any] struct {
+	Producer[T]
+}
+
+// consumer is producer for Consumer.
+type consumer[T any] struct {
+	Consumer[T]
+}
+
+// ProducerOf returns the half of t that feeds it, e.g. to hand it to
+// another package that mustn't read the results. It can't be converted back
+// to a Tree.
+func ProducerOf[T any](t Tree[T]) Producer[T] {
+	return producer[T]{t}
+}
+
+// ConsumerOf returns the half of t that reads its results, e.g. to hand it
+// to another package that mustn't add inputs. It can't be converted back to
+// a Tree.
+func ConsumerOf[T any](t Tree[T]) Consumer[T] {
+	return consumer[T]{t}
+}
@@ -0,0 +1,38 @@
+package treeduction_test
+
+import (
+	"testing"
+	"treeduction"
+)
+
+// TestHandles tests that the halves of a tree feed it and read its results,
+// and can't be converted back to the tree.
+func TestHandles(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	p, c := treeduction.ProducerOf(tree), treeduction.ConsumerOf(tree)
+	if _, ok := p.(treeduction.Tree[int]); ok {
+		t.Error("Expected the producer not to be a tree")
+	}
+	if _, ok := p.(treeduction.Consumer[int]); ok {
+		t.Error("Expected the producer not to be a consumer")
+	}
+	if _, ok := c.(treeduction.Producer[int]); ok {
+		t.Error("Expected the consumer not to be a producer")
+	}
+
+	if err := p.AddValues(1, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	<-c.Done()
+	if v, ok := c.TryNext(); !ok || v != 6 {
+		t.Errorf("Expected 6, got %d (%v)", v, ok)
+	}
+	if err := c.Err(); err != nil {
+		t.Error(err)
+	}
+}
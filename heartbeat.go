@@ -0,0 +1,44 @@
+package treeduction
+
+import "time"
+
+// WithHeartbeat puts beat() on the output at the end of every period d in
+// which no result was put on it, so that a watchdog downstream can tell a
+// tree whose inputs are quiet from a wedged one (e.g. beat returns a value
+// flagged as a heartbeat). A beat is dropped when the output is full, nobody
+// reads it then, and it isn't counted in Stats().Emitted nor scanned or
+// windowed. It has no effect with waitForAll, whose output holds a single
+// result.
+func WithHeartbeat[T any](d time.Duration, beat func() T) TypedOption[T] {
+	if d <= 0 {
+		panic("treeduction: heartbeat period must be positive")
+	}
+	return func(o *options, ty *typed[T]) {
+		o.heartbeat = d
+		ty.beat = beat
+	}
+}
+
+// heartbeat puts a beat on the output whenever it was quiet for a period,
+// until the tree stops reading its inputs.
+func (t *tree[T]) heartbeat() {
+	defer t.watchers.Done()
+	tick := time.NewTicker(t.opts.heartbeat)
+	defer tick.Stop()
+	last := t.emitted.Load()
+	for {
+		select {
+		case <-tick.C:
+		case <-t.ctx.Done():
+			return
+		}
+		if n := t.emitted.Load(); n != last {
+			last = n
+			continue
+		}
+		select {
+		case t.output <- t.beat():
+		default:
+		}
+	}
+}
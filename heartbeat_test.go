@@ -0,0 +1,56 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestHeartbeat tests that a beat comes out while the inputs are quiet, and
+// not while results do.
+func TestHeartbeat(t *testing.T) {
+	tree := treeduction.With(treeduction.WithHeartbeat(50*time.Millisecond, func() int {
+		return -1
+	})).New(func(a, b int) int {
+		return a + b
+	}, 10, false, false)
+	c := make(chan int)
+	tree.Add(c)
+
+	select {
+	case v := <-tree.Output():
+		if v != -1 {
+			t.Errorf("Expected a beat, got %d", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a beat while the input is quiet")
+	}
+	if n := tree.Stats().Emitted; n != 0 {
+		t.Errorf("Expected no result emitted, got %d", n)
+	}
+
+	// Results every 5ms leave no quiet period
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 20 {
+			c <- 1
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+	beats := 0
+	for sum := 0; sum < 20; {
+		if v := <-tree.Output(); v == -1 {
+			beats++
+		} else {
+			sum += v
+		}
+	}
+	<-done
+	close(c)
+	// A beat may have been due when the input started
+	if beats > 1 {
+		t.Errorf("Expected no beat while results come out, got %d", beats)
+	}
+	tree.Finish()
+}
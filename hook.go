@@ -0,0 +1,14 @@
+package treeduction
+
+import "time"
+
+// WithCombineHook calls hook after each combine of the tree with the two
+// values combined, the result and the time the combine took, to log
+// pathological merges, record latencies or inject faults in tests without
+// wrapping every combiner. A panic in hook fails the tree like a panic in
+// the combiner. With an in-place combiner a is the modified value.
+func WithCombineHook[T any](hook func(a, b, result T, d time.Duration)) TypedOption[T] {
+	return func(_ *options, ty *typed[T]) {
+		ty.hook = hook
+	}
+}
@@ -0,0 +1,56 @@
+package treeduction_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestCombineHook tests that the hook sees every combine with its values.
+func TestCombineHook(t *testing.T) {
+	var mu sync.Mutex
+	var combines int
+	tree := treeduction.With(treeduction.WithCombineHook(func(a, b, r int, d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		combines++
+		if a+b != r {
+			t.Errorf("Expected %d + %d = %d, got %d", a, b, a+b, r)
+		}
+		if d < time.Millisecond {
+			t.Errorf("Expected a combine to take at least 1ms, got %v", d)
+		}
+	})).New(func(a, b int) int {
+		time.Sleep(time.Millisecond)
+		return a + b
+	}, 10, true, false)
+
+	tree.AddValues(1, 2, 3, 4, 5, 6, 7, 8)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 36 {
+		t.Errorf("Expected 36, got %d", v)
+	}
+	// Every combine takes one value out of the tree
+	if combines != 7 {
+		t.Errorf("Expected 7 combines, got %d", combines)
+	}
+}
+
+// TestCombineHookPanic tests that a panic in the hook fails the tree.
+func TestCombineHookPanic(t *testing.T) {
+	tree := treeduction.With(treeduction.WithCombineHook(func(a, b, r int, d time.Duration) {
+		panic("fault")
+	})).New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	tree.AddValues(1, 2)
+	var perr *treeduction.PanicError
+	if err := tree.Finish(); !errors.As(err, &perr) || perr.Value != "fault" {
+		t.Errorf("Expected a PanicError from Finish(), got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package treeduction
+
+import "time"
+
+// WithIdleFlush gives "end of burst" semantics to inputs that are never
+// closed: once no value was read from any input for d (checked every d, so
+// up to 2d after the last one), the values inside the tree are reduced and
+// the result is put on the output. With finish, the tree then finishes
+// itself like FinishContext with a cancelled context, and the output is
+// closed. Otherwise it goes on with the next burst: with waitForAll, the
+// result of the burst is put on the output and the tree starts accumulating
+// again from scratch, without it a node passes on the value it holds for a
+// pair. Going on only applies to unordered trees without WithMaxWorkers.
+func WithIdleFlush(d time.Duration, finish bool) Option {
+	if d <= 0 {
+		panic("treeduction: idle duration must be positive")
+	}
+	return func(o *options) {
+		o.idle = d
+		o.idleFinish = finish
+	}
+}
+
+// watchIdle flushes the tree every time it went idle after reading values.
+func (t *tree[T]) watchIdle() {
+	d := t.opts.idle
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	// From 0, the values may be read before this goroutine starts
+	var seen, flushed int64
+	for {
+		select {
+		case <-timer.C:
+		case <-t.ctx.Done():
+			return
+		}
+		n := t.consumed.Load()
+		if n == seen && n != flushed {
+			if t.opts.idleFinish {
+				t.finishItself()
+				return
+			}
+			if t.drain(d) {
+				flushed = n
+			}
+		}
+		seen = n
+		timer.Reset(d)
+	}
+}
+
+// finishItself finishes the tree without waiting for its inputs to close,
+// like FinishContext with a cancelled context, unless it was finished
+// already.
+func (t *tree[T]) finishItself() {
+	if t.markFinished() {
+		t.cancel()
+		t.finish()
+		t.settle()
+	}
+}
+
+// drain pauses the tree until the values inside it reached the root, for at
+// most d, and with waitForAll puts the result accumulated so far on the
+// output. It reports false if the values didn't all reach the root in time.
+func (t *tree[T]) drain(d time.Duration) bool {
+	if t.ordered || t.opts.pooled() {
+		return false
+	}
+	// Not to resume a tree paused by the caller
+	if t.paused() == nil {
+		t.Pause()
+		defer t.Resume()
+	}
+
+	deadline := time.Now().Add(d)
+	for t.alive.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		select {
+		case <-time.After(time.Millisecond):
+		case <-t.ctx.Done():
+			return false
+		}
+	}
+	if !t.waitForAll {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished.Load() {
+		return false
+	}
+	t.epoch(t.nextRecord(true), func(func(T) bool) {})
+	return true
+}
@@ -0,0 +1,89 @@
+package treeduction_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestIdleFlush tests that every burst of values is reduced to a result of
+// its own, though the inputs are never closed.
+func TestIdleFlush(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithIdleFlush(20*time.Millisecond, false))
+
+	c1, c2 := make(chan int), make(chan int)
+	tree.Add(c1, c2)
+	for _, burst := range [][]int{{1, 2, 3}, {4, 5}} {
+		sum := 0
+		for i, v := range burst {
+			[]chan int{c1, c2}[i%2] <- v
+			sum += v
+		}
+		select {
+		case v := <-tree.Output():
+			if v != sum {
+				t.Errorf("Expected %d, got %d", sum, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the result of %v", burst)
+		}
+	}
+	close(c1)
+	close(c2)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := <-tree.Output(); ok {
+		t.Errorf("Expected nothing left, got %d", v)
+	}
+}
+
+// TestIdleFinish tests that an idle tree finishes itself.
+func TestIdleFinish(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithIdleFlush(20*time.Millisecond, true))
+
+	c := make(chan int)
+	tree.Add(c)
+	c <- 1
+	c <- 2
+	c <- 3
+	if v := <-tree.Output(); v != 6 {
+		t.Errorf("Expected 6, got %d", v)
+	}
+	if _, ok := <-tree.Output(); ok {
+		t.Error("Expected the output to be closed")
+	}
+	if err := tree.Finish(); !errors.Is(err, treeduction.ErrFinished) {
+		t.Errorf("Expected ErrFinished, got %v", err)
+	}
+}
+
+// TestIdleFlushStreaming tests that the values held by the nodes for a
+// pair are passed on once the tree is idle.
+func TestIdleFlushStreaming(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithIdleFlush(20*time.Millisecond, false))
+
+	c := make(chan int)
+	tree.Add(c)
+	c <- 1
+	c <- 2
+	c <- 3
+	sum := 0
+	for sum < 6 {
+		select {
+		case v := <-tree.Output():
+			sum += v
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the values to reach the output, got %d", sum)
+		}
+	}
+	close(c)
+	tree.Finish()
+}
@@ -0,0 +1,120 @@
+package treeduction
+
+// WithMaxInFlight bounds the number of values inside the tree to n, no
+// matter how many inputs it has, so that the memory it uses is predictable:
+// an input is only read when a value left the tree, by being combined or
+// reaching the output. A node doesn't hold a value waiting for its pair
+// while an input waits for room. It only applies to unordered trees without
+// WithMaxWorkers, the other nodes can't pass a value on alone.
+func WithMaxInFlight(n int) Option {
+	if n <= 0 {
+		panic("treeduction: number of values in flight must be positive")
+	}
+	return func(o *options) {
+		o.maxInFlight = n
+	}
+}
+
+// combine merges two values inside the tree, which then holds one less.
+func (t *tree[T]) combine(f T, s T) T {
+	sides := t.weight(f) + t.weight(s)
+	v := t.combiner(f, s)
+	t.leave()
+	t.reweigh(sides, v)
+	return v
+}
+
+// enter takes a slot for a value about to be read from an input. It returns
+// false if done fired or the tree was cancelled first.
+func (t *tree[T]) enter(done <-chan struct{}) bool {
+	if t.slots == nil {
+		return true
+	}
+	select {
+	case t.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	// Out of slots, the nodes pass their values on alone to free some
+	defer t.hunger()()
+	select {
+	case t.slots <- struct{}{}:
+		return true
+	case <-done:
+		return false
+	case <-t.ctx.Done():
+		return false
+	}
+}
+
+// hunger makes the nodes pass their values on alone until the returned
+// function is called, while a reader waits for room in the tree.
+func (t *tree[T]) hunger() func() {
+	t.starveMu.Lock()
+	if t.starved == 0 {
+		close(*t.starve.Load())
+	}
+	t.starved++
+	t.starveMu.Unlock()
+	return func() {
+		t.starveMu.Lock()
+		if t.starved--; t.starved == 0 {
+			starve := make(chan struct{})
+			t.starve.Store(&starve)
+		}
+		t.starveMu.Unlock()
+	}
+}
+
+// vacate frees a slot that wasn't used.
+func (t *tree[T]) vacate() {
+	if t.slots == nil {
+		return
+	}
+	select {
+	case <-t.slots:
+	default:
+	}
+}
+
+// leave is called when a value left the tree, by being combined or reaching
+// the root.
+func (t *tree[T]) leave() {
+	t.alive.Add(-1)
+	t.vacate()
+}
+
+// forward sends v on a channel of the tree. Once the tree is cancelled
+// (WithContext, a failure) nobody may read the channel anymore, v is then
+// lost so that the goroutine doesn't stay blocked. It reports whether v was
+// sent.
+func (t *tree[T]) forward(c chan<- T, v T) bool {
+	select {
+	case c <- v:
+		return true
+	default:
+	}
+	select {
+	case c <- v:
+		return true
+	case <-t.life.Done():
+		t.lose(v)
+		return false
+	}
+}
+
+// lose forgets a value of a cancelled tree.
+func (t *tree[T]) lose(v T) {
+	t.leave()
+	t.unhold(t.weight(v))
+}
+
+// starving returns a channel that is closed while a reader waits for a
+// slot or for its weight to fit, or a finishing Loop for the values held.
+func (t *tree[T]) starving() <-chan struct{} {
+	if t.slots == nil && t.weights == nil && t.loop.Load() == nil {
+		return nil
+	}
+	return *t.starve.Load()
+}
@@ -0,0 +1,94 @@
+package treeduction_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"treeduction"
+)
+
+// TestMaxInFlight tests that the tree never holds more values than allowed,
+// whatever the number of inputs.
+func TestMaxInFlight(t *testing.T) {
+	const limit = 4
+	var inFlight, peak atomic.Int64
+	tree := treeduction.New(func(a, b int) int {
+		inFlight.Add(-1)
+		return a + b
+	}, 10, true, false, treeduction.WithMaxInFlight(limit))
+
+	inputs := make([]<-chan int, 32)
+	for i := range inputs {
+		c := make(chan int)
+		go func() {
+			for range 100 {
+				c <- 1
+				n := inFlight.Add(1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+			}
+			close(c)
+		}()
+		inputs[i] = c
+	}
+	tree.Add(inputs...)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 3200 {
+		t.Errorf("Expected 3200, got %d", v)
+	}
+	// The result accumulated so far is counted too
+	if p := peak.Load(); p > limit+1 {
+		t.Errorf("Expected at most %d values in flight, got %d", limit+1, p)
+	}
+}
+
+// TestMaxInFlightStreaming tests that values are passed on alone instead of
+// waiting for a pair when the tree is out of room.
+func TestMaxInFlightStreaming(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithMaxInFlight(1))
+
+	inputs := make([]<-chan int, 8)
+	for i := range inputs {
+		c := make(chan int, 10)
+		for range 10 {
+			c <- 1
+		}
+		close(c)
+		inputs[i] = c
+	}
+	tree.Add(inputs...)
+	sum := 0
+	for sum < 80 {
+		sum += <-tree.Output()
+	}
+	tree.Finish()
+	if sum != 80 {
+		t.Errorf("Expected 80, got %d", sum)
+	}
+}
+
+// TestMaxInFlightFolder tests that the inputs of a Folder give their slot
+// back when they are closed.
+func TestMaxInFlightFolder(t *testing.T) {
+	tree := treeduction.Fold(0, func(n int, s string) int {
+		return n + len(s)
+	}, func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithMaxInFlight(1))
+
+	for range 3 {
+		c := make(chan string)
+		close(c)
+		tree.Add(c)
+	}
+	tree.AddValues("a", "bb", "ccc")
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 6 {
+		t.Errorf("Expected 6, got %d", v)
+	}
+}
@@ -0,0 +1,177 @@
+package treeduction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// WithInline combines the values on the goroutine that calls Run instead of
+// in goroutines of the tree, for a fan-in of a few channels where the
+// goroutines cost more than they save. Add only registers the inputs, Run
+// reads them until they are closed, and Finish then emits like it does
+// otherwise: with waitForAll a single result, without it a result per batch
+// of values that were ready together, like WithSequentialFallback. With
+// waitForAll, Finish reads the inputs that no Run read on the caller first,
+// without it they are dropped like the values a tree didn't read. Up to 4
+// inputs are read without allocating. It only applies to unordered trees
+// without WithMaxWorkers, and the priorities of the inputs, WithRateLimit
+// and Pause are ignored.
+func WithInline() Option {
+	return func(o *options) {
+		o.inline = true
+	}
+}
+
+// inlined is an input registered for Run.
+type inlined[T any] struct {
+	c   <-chan T
+	src *source
+}
+
+func (t *tree[T]) inlining() bool {
+	return t.opts.inline && !t.ordered && !t.opts.pooled()
+}
+
+// addInline registers inputs for Run.
+func (t *tree[T]) addInline(out []<-chan T) {
+	for _, o := range out {
+		t.inlined = append(t.inlined, inlined[T]{c: o, src: t.attach(o)})
+	}
+}
+
+// Run reads the inputs of a tree created with WithInline until they are all
+// closed, combining their values on the calling goroutine. It returns the
+// cause of ctx if it is done first, a later Run (or Finish, with
+// waitForAll) then reads the inputs that are left. Inputs added while Run
+// runs are read by the next one, and Finish must wait for Run to return.
+func (t *tree[T]) Run(ctx context.Context) error {
+	if !t.inlining() {
+		return fmt.Errorf("treeduction: only unordered inline trees without workers run on the caller: %w", errors.ErrUnsupported)
+	}
+	t.mu.Lock()
+	ins := t.inlined
+	t.inlined = nil
+	t.mu.Unlock()
+
+	var err error
+	for len(ins) > 0 {
+		var i int
+		var v T
+		var ok bool
+		if i, v, ok, err = t.receive(ctx, ins, true); err != nil {
+			break
+		}
+		if !ok {
+			ins = t.closeInline(ins, i)
+			continue
+		}
+		v, ok = t.readInline(v, ins[i].src)
+		// With waitForAll the values are folded one by one at the root
+		for !t.waitForAll && !t.opts.perValue() {
+			i, w, more, _ := t.receive(ctx, ins, false)
+			if i < 0 {
+				break
+			}
+			if !more {
+				ins = t.closeInline(ins, i)
+				continue
+			}
+			w, more = t.readInline(w, ins[i].src)
+			switch {
+			case !more:
+			case ok:
+				v = t.combine(v, w)
+			default:
+				v, ok = w, true
+			}
+		}
+		if ok {
+			t.deliver(v, nil)
+		}
+	}
+
+	if len(ins) > 0 {
+		t.mu.Lock()
+		t.inlined = append(ins, t.inlined...)
+		t.mu.Unlock()
+	}
+	return err
+}
+
+// readInline accounts for a value read from an input, it reports false if
+// the value doesn't enter the tree.
+func (t *tree[T]) readInline(v T, src *source) (T, bool) {
+	t.consume(src)
+	return t.admit(v, src)
+}
+
+// closeInline forgets the i-th input, which was closed.
+func (t *tree[T]) closeInline(ins []inlined[T], i int) []inlined[T] {
+	t.forget(ins[i].c, ins[i].src)
+	return append(ins[:i], ins[i+1:]...)
+}
+
+// receive reads a value from one of the inputs, it returns -1 if none is
+// ready and block is false, or if ctx is done.
+func (t *tree[T]) receive(ctx context.Context, ins []inlined[T], block bool) (int, T, bool, error) {
+	var zero T
+	if len(ins) > 4 {
+		cases := make([]reflect.SelectCase, 0, len(ins)+1)
+		for _, in := range ins {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(in.c)})
+		}
+		if block {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+		} else {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+		}
+		i, v, ok := reflect.Select(cases)
+		if i == len(ins) {
+			if block {
+				return -1, zero, false, context.Cause(ctx)
+			}
+			return -1, zero, false, nil
+		}
+		if !ok {
+			return i, zero, false, nil
+		}
+		return i, v.Interface().(T), true, nil
+	}
+
+	var c [4]<-chan T
+	for i, in := range ins {
+		c[i] = in.c
+	}
+	var v T
+	var ok bool
+	i := -1
+	if block {
+		select {
+		case v, ok = <-c[0]:
+			i = 0
+		case v, ok = <-c[1]:
+			i = 1
+		case v, ok = <-c[2]:
+			i = 2
+		case v, ok = <-c[3]:
+			i = 3
+		case <-ctx.Done():
+			return -1, zero, false, context.Cause(ctx)
+		}
+	} else {
+		select {
+		case v, ok = <-c[0]:
+			i = 0
+		case v, ok = <-c[1]:
+			i = 1
+		case v, ok = <-c[2]:
+			i = 2
+		case v, ok = <-c[3]:
+			i = 3
+		default:
+		}
+	}
+	return i, v, ok, nil
+}
@@ -0,0 +1,98 @@
+package treeduction_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestInline tests that an inline tree reduces its inputs on the goroutine
+// calling Run, with few inputs and with many.
+func TestInline(t *testing.T) {
+	for _, n := range []int{1, 3, 10} {
+		for _, waitForAll := range []bool{true, false} {
+			tree := treeduction.New(func(a, b int) int {
+				return a + b
+			}, 10, waitForAll, false, treeduction.WithInline())
+			for range n {
+				tree.AddValues(1, 2, 3)
+			}
+			if g := tree.Stats().Goroutines; g != 0 {
+				t.Errorf("Expected no goroutine, got %d", g)
+			}
+			if err := tree.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if err := tree.Finish(); err != nil {
+				t.Fatal(err)
+			}
+			sum, results := 0, 0
+			for v := range tree.Output() {
+				sum += v
+				results++
+			}
+			if sum != 6*n {
+				t.Errorf("Expected %d, got %d", 6*n, sum)
+			}
+			if waitForAll && results != 1 {
+				t.Errorf("Expected a single result, got %d", results)
+			}
+		}
+	}
+}
+
+// TestInlineFinish tests that Finish reads the inputs that no Run read, with
+// waitForAll.
+func TestInlineFinish(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithInline())
+	tree.AddValues(1, 2, 3)
+	tree.AddValues(4)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 10 {
+		t.Errorf("Expected 10, got %d", v)
+	}
+}
+
+// TestInlineContext tests that Run stops when ctx is done, and that the
+// next one reads the inputs that are left.
+func TestInlineContext(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithInline())
+	c := make(chan int, 1)
+	c <- 1
+	tree.Add(c)
+	tree.AddValues(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := tree.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v from Run(), got %v", context.DeadlineExceeded, err)
+	}
+	c <- 3
+	close(c)
+	if err := tree.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	tree.Finish()
+	if v := <-tree.Output(); v != 6 {
+		t.Errorf("Expected 6, got %d", v)
+	}
+}
+
+// TestInlineUnsupported tests that Run fails without WithInline.
+func TestInlineUnsupported(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, true, treeduction.WithInline())
+	if err := tree.Run(context.Background()); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected errors.ErrUnsupported from Run(), got %v", err)
+	}
+	tree.Finish()
+}
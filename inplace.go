@@ -0,0 +1,21 @@
+package treeduction
+
+// NewInPlace is like New, but the combiner merges src into dst instead of
+// returning a new value, so that large mergeable values (HyperLogLog
+// sketches, bitmaps) are reused instead of allocated on every combine. The
+// tree owns the values it reads: a value sent to an input must not be used
+// by its producer anymore, and a result or snapshot may still be merged
+// into while the tree runs, unless it is the final result of waitForAll.
+func NewInPlace[T any](merge func(dst *T, src T), bufferSize int, waitForAll bool, ordered bool, opts ...Option) Tree[T] {
+	return Typed[T]{}.NewInPlace(merge, bufferSize, waitForAll, ordered, opts...)
+}
+
+// NewInPlace is NewInPlace with the typed options.
+func (w Typed[T]) NewInPlace(merge func(dst *T, src T), bufferSize int, waitForAll bool, ordered bool, opts ...Option) Tree[T] {
+	t := newTree(bufferSize, waitForAll, ordered, opts, w.opts)
+	t.combiner = t.guard(func(f T, s T) (T, error) {
+		merge(&f, s)
+		return f, nil
+	})
+	return t
+}
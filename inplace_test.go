@@ -0,0 +1,31 @@
+package treeduction_test
+
+import (
+	"testing"
+	"treeduction"
+)
+
+type bitmap struct {
+	bits []uint64
+}
+
+// TestInPlace tests merging values in place.
+func TestInPlace(t *testing.T) {
+	tree := treeduction.NewInPlace(func(dst *bitmap, src bitmap) {
+		for i, b := range src.bits {
+			dst.bits[i] |= b
+		}
+	}, 10, true, false)
+
+	for i := range 128 {
+		b := bitmap{bits: make([]uint64, 2)}
+		b.bits[i/64] |= 1 << (i % 64)
+		tree.AddValues(b)
+	}
+	tree.Finish()
+
+	r := <-tree.Output()
+	if r.bits[0] != ^uint64(0) || r.bits[1] != ^uint64(0) {
+		t.Errorf("Expected every bit to be set, got %x", r.bits)
+	}
+}
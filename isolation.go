@@ -0,0 +1,64 @@
+package treeduction
+
+// WithBatchIsolation reduces the inputs of every Add call (a batch) in a
+// subtree of their own, whose values are never combined with the ones of
+// another batch, and puts the results of a batch on the output only once
+// the results of the earlier batches are all out, so that the results of
+// the batches don't interleave. The values of a batch wait in its subtree
+// meanwhile, holding back its inputs. It has no effect with waitForAll (see
+// Seal), in ordered mode, with WithSequentialFallback and with WithMaxWorkers.
+func WithBatchIsolation() Option {
+	return func(o *options) {
+		o.batchIsolation = true
+	}
+}
+
+// isolating reports whether every Add starts a new batch.
+func (t *tree[T]) isolating() bool {
+	return t.opts.batchIsolation && !t.waitForAll && !t.ordered && !t.opts.sequential && t.pool == nil
+}
+
+// isolate ends the current batch: its roots are handed to a goroutine that
+// delivers their values once the previous batch is done, and the next
+// inputs start a new subtree. t.mu must be held.
+func (t *tree[T]) isolate() {
+	close(t.stop)
+	t.stop = make(chan struct{})
+	t.wg.Wait()
+	t.sealOpen()
+
+	roots := t.levels.takeRoots()
+	if len(roots) == 0 {
+		return
+	}
+
+	prev, done := t.batchDone, make(chan struct{})
+	t.batchDone = done
+	t.epochs.Add(1)
+	t.spawn(func() {
+		defer t.epochs.Done()
+		defer close(done)
+		if prev != nil {
+			<-prev
+		}
+		t.fanIn(roots, func(v T) bool {
+			return t.deliver(v, nil)
+		})
+	}, "role", "collector")
+}
+
+// waitBatch waits for the previous batch to be delivered before a collector
+// of the current one delivers anything. It reports false if stop fired
+// first.
+func (t *tree[T]) waitBatch(stop <-chan struct{}) bool {
+	prev := t.batchDone
+	if prev == nil {
+		return true
+	}
+	select {
+	case <-prev:
+		return true
+	case <-stop:
+		return false
+	}
+}
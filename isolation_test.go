@@ -0,0 +1,85 @@
+package treeduction_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestBatchIsolation tests that the values of two Add calls are never
+// combined together and that the results of the second one only come out
+// after the ones of the first.
+func TestBatchIsolation(t *testing.T) {
+	concat := func(a, b string) string {
+		return a + b
+	}
+	tree := treeduction.New(concat, 10, false, false, treeduction.WithBatchIsolation())
+
+	slow := make(chan string)
+	tree.Add(slow)
+	slow <- "a"
+
+	fast := make(chan string, 3)
+	fast <- "b"
+	fast <- "b"
+	fast <- "b"
+	close(fast)
+	tree.Add(fast)
+
+	// The second batch is done, but has to wait for the first one
+	time.Sleep(50 * time.Millisecond)
+	slow <- "a"
+	close(slow)
+
+	var got []string
+	for n := 0; n < 5; {
+		v := <-tree.Output()
+		got = append(got, v)
+		n += len(v)
+	}
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	seenB := false
+	for _, v := range got {
+		if strings.Contains(v, "a") && strings.Contains(v, "b") {
+			t.Fatalf("Batches were combined together: %v", got)
+		}
+		if strings.Contains(v, "b") {
+			seenB = true
+		} else if seenB {
+			t.Fatalf("A result of the first batch came after the second one: %v", got)
+		}
+	}
+}
+
+// TestBatchIsolationAbort tests that Abort doesn't wait for a batch held back
+// by an earlier one whose inputs never close.
+func TestBatchIsolationAbort(t *testing.T) {
+	tree := treeduction.New(sum, 10, false, false, treeduction.WithBatchIsolation())
+	tree.Add(make(chan int))
+	for i := range 3 {
+		c := make(chan int, 2)
+		c <- i
+		c <- i
+		close(c)
+		tree.Add(c)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- tree.Abort()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Abort is stuck")
+	}
+	for range tree.Output() {
+	}
+}
@@ -0,0 +1,107 @@
+package treeduction
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// KeyedInputs are the inputs added with AddMap, known by their keys.
+type KeyedInputs[K comparable, T any] struct {
+	t    Tree[T]
+	keys map[<-chan T]K
+}
+
+// KeyError is an InputError of an input added with AddMap, with its key in
+// place of its index.
+type KeyError[K comparable] struct {
+	Key K
+	Err error
+}
+
+func (e *KeyError[K]) Error() string {
+	return fmt.Sprintf("treeduction: input %v: %v", e.Key, e.Err)
+}
+
+func (e *KeyError[K]) Unwrap() error {
+	return e.Err
+}
+
+// AddMap adds the channels of m to t like Add, e.g. one per shard, and keeps
+// their keys, so that their stats and the slowest of them are reported by
+// key rather than by channel. The InputErrors of Add are reported as
+// KeyErrors.
+func AddMap[K comparable, T any](t Tree[T], m map[K]<-chan T) (*KeyedInputs[K, T], error) {
+	order := make([]K, 0, len(m))
+	chans := make([]<-chan T, 0, len(m))
+	keys := make(map[<-chan T]K, len(m))
+	for k, c := range m {
+		order = append(order, k)
+		chans = append(chans, c)
+		if c != nil {
+			keys[c] = k
+		}
+	}
+	err := t.Add(chans...)
+	return &KeyedInputs[K, T]{t: t, keys: keys}, keyErrors(err, order)
+}
+
+// keyErrors replaces the InputErrors in err with the KeyErrors of their
+// indexes in order.
+func keyErrors[K comparable](err error, order []K) error {
+	switch e := err.(type) {
+	case *InputError:
+		return &KeyError[K]{Key: order[e.Index], Err: e.Err}
+	case interface{ Unwrap() []error }:
+		errs := e.Unwrap()
+		keyed := make([]error, len(errs))
+		for i, err := range errs {
+			keyed[i] = keyErrors(err, order)
+		}
+		return errors.Join(keyed...)
+	}
+	return err
+}
+
+// Key returns the key of an input added with AddMap.
+func (k *KeyedInputs[K, T]) Key(in <-chan T) (K, bool) {
+	key, ok := k.keys[in]
+	return key, ok
+}
+
+// Name returns the key of an input added with AddMap as a string, so that
+// DebugHandler names the inputs by key.
+func (k *KeyedInputs[K, T]) Name(in <-chan T) (string, bool) {
+	key, ok := k.keys[in]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprint(key), true
+}
+
+// Stats returns the reading of the inputs that are still being read, like
+// Stats.Sources.
+func (k *KeyedInputs[K, T]) Stats() map[K]SourceStats {
+	stats := make(map[K]SourceStats)
+	for in, s := range k.t.Stats().Sources {
+		if key, ok := k.keys[in]; ok {
+			stats[key] = s
+		}
+	}
+	return stats
+}
+
+// Slowest returns up to n of the keys of the inputs that are still being
+// read, the ones the tree waited the longest for first, like SlowestSources.
+func (k *KeyedInputs[K, T]) Slowest(n int) []K {
+	var slowest []K
+	for _, in := range k.t.SlowestSources(math.MaxInt) {
+		if len(slowest) == n {
+			break
+		}
+		if key, ok := k.keys[in]; ok {
+			slowest = append(slowest, key)
+		}
+	}
+	return slowest
+}
@@ -0,0 +1,48 @@
+package treeduction_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"treeduction"
+)
+
+// TestAddMap tests that the inputs added with AddMap are reported by key.
+func TestAddMap(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	east, west := make(chan int), make(chan int)
+	inputs, err := treeduction.AddMap(tree, map[string]<-chan int{
+		"east":  east,
+		"west":  west,
+		"north": nil,
+	})
+	var ke *treeduction.KeyError[string]
+	if !errors.As(err, &ke) || ke.Key != "north" || !errors.Is(err, treeduction.ErrNilInput) {
+		t.Errorf("Expected the nil input to be reported by key, got %v", err)
+	}
+
+	east <- 1
+	east <- 2
+	west <- 3
+	if key, ok := inputs.Key(west); !ok || key != "west" {
+		t.Errorf("Expected west, got %q (%v)", key, ok)
+	}
+	stats := inputs.Stats()
+	if _, ok := stats["east"]; !ok || len(stats) != 2 {
+		t.Errorf("Expected the stats of east and west, got %v", stats)
+	}
+	if slowest := inputs.Slowest(1); len(slowest) != 1 || !slices.Contains([]string{"east", "west"}, slowest[0]) {
+		t.Errorf("Expected one of the inputs, got %v", slowest)
+	}
+
+	close(east)
+	close(west)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 6 {
+		t.Errorf("Expected 6, got %d", v)
+	}
+}
@@ -0,0 +1,114 @@
+package treeduction
+
+import (
+	"sync"
+	"time"
+)
+
+// Measured is a result with the number of input values folded into it and
+// the time the oldest of them was read from its input, see NewMeasured.
+type Measured[T any] struct {
+	Value  T
+	Count  int64
+	Oldest time.Time
+}
+
+// NewMeasured is like NewCounted, but every value is timestamped when it is
+// read from its input, and the time the oldest value of each result took to
+// reach the output is recorded in Stats.Latency, e.g. for the p99 of the
+// time a value takes to be aggregated. The latency of the other values of
+// a result is shorter, so the histogram is an upper bound.
+func NewMeasured[T any](combiner func(f T, s T) T, bufferSize int, waitForAll bool, ordered bool, opts ...Option) *Folder[T, Measured[T]] {
+	f := Fold(Measured[T]{}, func(m Measured[T], v T) Measured[T] {
+		if m.Count == 0 {
+			return Measured[T]{Value: v, Count: 1, Oldest: time.Now()}
+		}
+		return Measured[T]{Value: combiner(m.Value, v), Count: m.Count + 1, Oldest: m.Oldest}
+	}, func(f Measured[T], s Measured[T]) Measured[T] {
+		oldest := f.Oldest
+		if s.Oldest.Before(oldest) {
+			oldest = s.Oldest
+		}
+		return Measured[T]{Value: combiner(f.Value, s.Value), Count: f.Count + s.Count, Oldest: oldest}
+	}, bufferSize, waitForAll, ordered, opts...)
+
+	f.latencies = &histogram{}
+	f.observe = func(m Measured[T]) {
+		if m.Count > 0 {
+			f.latencies.record(time.Since(m.Oldest))
+		}
+	}
+	return f
+}
+
+// Histogram counts durations in buckets, see Stats.Latency.
+type Histogram struct {
+	// Counts[i] is the number of durations up to Bounds[i], and the last
+	// count the number of longer ones.
+	Bounds []time.Duration
+	Counts []int64
+}
+
+// Total returns the number of durations.
+func (h Histogram) Total() int64 {
+	var n int64
+	for _, c := range h.Counts {
+		n += c
+	}
+	return n
+}
+
+// Quantile returns the bound of the bucket of the q quantile, 0 < q <= 1,
+// e.g. 0.99 for the p99. It is 0 without durations, and the largest bound
+// for the longer ones.
+func (h Histogram) Quantile(q float64) time.Duration {
+	total := h.Total()
+	if total == 0 {
+		return 0
+	}
+	rank := int64(q*float64(total) + 0.5)
+	var n int64
+	for i, c := range h.Counts {
+		n += c
+		if n >= max(rank, 1) && i < len(h.Bounds) {
+			return h.Bounds[i]
+		}
+	}
+	return h.Bounds[len(h.Bounds)-1]
+}
+
+// The buckets of a histogram go from 1µs, doubling up to about 18 hours.
+const buckets = 37
+
+// histogram records durations for a Histogram.
+type histogram struct {
+	mu     sync.Mutex
+	counts [buckets + 1]int64
+}
+
+func (h *histogram) record(d time.Duration) {
+	i := 0
+	for bound := time.Microsecond; i < buckets && d > bound; bound *= 2 {
+		i++
+	}
+	h.mu.Lock()
+	h.counts[i]++
+	h.mu.Unlock()
+}
+
+func (h *histogram) snapshot() Histogram {
+	s := Histogram{Bounds: make([]time.Duration, buckets), Counts: make([]int64, buckets+1)}
+	for i, bound := 0, time.Microsecond; i < buckets; i, bound = i+1, bound*2 {
+		s.Bounds[i] = bound
+	}
+	h.mu.Lock()
+	copy(s.Counts, h.counts[:])
+	h.mu.Unlock()
+	return s
+}
+
+func (h *histogram) reset() {
+	h.mu.Lock()
+	h.counts = [buckets + 1]int64{}
+	h.mu.Unlock()
+}
@@ -0,0 +1,51 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestMeasured tests that the latency of the results is recorded.
+func TestMeasured(t *testing.T) {
+	tree := treeduction.NewMeasured(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	c := make(chan int, 3)
+	c <- 1
+	c <- 2
+	c <- 3
+	tree.Add(c)
+	time.Sleep(20 * time.Millisecond)
+	close(c)
+	tree.Finish()
+	m := <-tree.Output()
+	if m.Value != 6 || m.Count != 3 {
+		t.Errorf("Expected 6 from 3 values, got %+v", m)
+	}
+
+	h := tree.Stats().Latency
+	if n := h.Total(); n != 1 {
+		t.Errorf("Expected the latency of 1 result, got %d", n)
+	}
+	if p := h.Quantile(0.99); p < 16*time.Millisecond || p > time.Second {
+		t.Errorf("Expected a p99 of about 20ms, got %v", p)
+	}
+}
+
+// TestHistogramQuantile tests the quantiles of a histogram.
+func TestHistogramQuantile(t *testing.T) {
+	h := treeduction.Histogram{
+		Bounds: []time.Duration{time.Millisecond, 2 * time.Millisecond},
+		Counts: []int64{90, 9, 1},
+	}
+	for q, want := range map[float64]time.Duration{0.5: time.Millisecond, 0.95: 2 * time.Millisecond, 1: 2 * time.Millisecond} {
+		if got := h.Quantile(q); got != want {
+			t.Errorf("Expected the %v quantile to be %v, got %v", q, want, got)
+		}
+	}
+	if got := (treeduction.Histogram{}).Quantile(0.5); got != 0 {
+		t.Errorf("Expected 0 without durations, got %v", got)
+	}
+}
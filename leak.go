@@ -0,0 +1,90 @@
+package treeduction
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WithLeakCheck makes Finish and Abort verify that every goroutine started
+// by the tree exits within grace, and report the ones that don't with a
+// *LeakError. It records where each goroutine was started, so it is meant
+// for tests and debugging.
+func WithLeakCheck(grace time.Duration) Option {
+	if grace <= 0 {
+		panic("treeduction: leak check grace period must be positive")
+	}
+	return func(o *options) {
+		o.leakGrace = grace
+	}
+}
+
+// LeakError is returned by Finish and Abort with WithLeakCheck when
+// goroutines of the tree are still running after the grace period. Stages
+// counts them by the place they were started from.
+type LeakError struct {
+	Stages map[string]int
+}
+
+func (e *LeakError) Error() string {
+	stages := make([]string, 0, len(e.Stages))
+	n := 0
+	for stage, count := range e.Stages {
+		stages = append(stages, fmt.Sprintf("%s x%d", stage, count))
+		n += count
+	}
+	slices.Sort(stages)
+	return fmt.Sprintf("treeduction: %d goroutines leaked: %s", n, strings.Join(stages, ", "))
+}
+
+// started records a goroutine started from the caller of spawn, and returns
+// the function to call when it exits.
+func (t *tree[T]) started() func() {
+	pc, file, line, _ := runtime.Caller(2)
+	name := "?"
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		name = fn.Name()
+		for i := strings.LastIndex(name, ".func"); i > 0; i = strings.LastIndex(name, ".func") {
+			name = name[:i]
+		}
+		name = name[strings.LastIndex(name, ".")+1:]
+	}
+	stage := fmt.Sprintf("%s (%s:%d)", name, filepath.Base(file), line)
+
+	t.leakMu.Lock()
+	defer t.leakMu.Unlock()
+	if t.stages == nil {
+		t.stages = make(map[string]int)
+	}
+	t.stages[stage]++
+	return func() {
+		t.leakMu.Lock()
+		defer t.leakMu.Unlock()
+		if t.stages[stage]--; t.stages[stage] == 0 {
+			delete(t.stages, stage)
+		}
+	}
+}
+
+// checkLeaks waits for the goroutines of the tree to exit, and adds a
+// *LeakError to err if some are still running after the grace period.
+func (t *tree[T]) checkLeaks(err error) error {
+	if t.opts.leakGrace <= 0 {
+		return err
+	}
+	for deadline := time.Now().Add(t.opts.leakGrace); t.goroutines.Load() > 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	t.leakMu.Lock()
+	defer t.leakMu.Unlock()
+	if len(t.stages) == 0 {
+		return err
+	}
+	return errors.Join(err, &LeakError{Stages: maps.Clone(t.stages)})
+}
@@ -0,0 +1,66 @@
+package treeduction_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestLeakCheck tests that Finish and Abort report no leak when every
+// goroutine of the tree exits.
+func TestLeakCheck(t *testing.T) {
+	for _, waitForAll := range []bool{false, true} {
+		for _, ordered := range []bool{false, true} {
+			tree := treeduction.New(func(a, b int) int {
+				return a + b
+			}, 10, waitForAll, ordered, treeduction.WithLeakCheck(time.Second))
+			tree.AddValues(1, 2, 3)
+			tree.Add(make(chan int)) // Never closed
+			tree.Go(func(emit func(int)) error {
+				emit(4)
+				return nil
+			})
+
+			var err error
+			if waitForAll {
+				err = tree.Abort()
+			} else {
+				err = tree.Finish()
+			}
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		}
+	}
+}
+
+// TestLeakCheckReport tests that a goroutine still running after Finish is
+// reported with the place it was started from.
+func TestLeakCheckReport(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithLeakCheck(10*time.Millisecond))
+
+	block := make(chan struct{})
+	defer close(block)
+	tree.Go(func(emit func(int)) error {
+		<-block
+		return nil
+	})
+
+	err := tree.Finish()
+	var leak *treeduction.LeakError
+	if !errors.As(err, &leak) {
+		t.Fatalf("Expected a LeakError, got %v", err)
+	}
+	if len(leak.Stages) != 1 {
+		t.Fatalf("Expected 1 leaking stage, got %v", leak.Stages)
+	}
+	for stage, n := range leak.Stages {
+		if !strings.Contains(stage, "producer.go") || n != 1 {
+			t.Errorf("Expected the producer to leak once, got %s x%d", stage, n)
+		}
+	}
+}
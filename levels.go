@@ -0,0 +1,69 @@
+package treeduction
+
+// level is a level of an unordered tree: the root waiting there for a
+// sibling, the output of a subtree of that height, and for the trees that
+// aren't binary the node that still takes children, whose output is the
+// root.
+type level[T any] struct {
+	root <-chan T
+	open *knode[T]
+}
+
+// levels are the levels of an unordered tree from the leaves up, added as
+// the tree grows, a level being empty once its root was combined. The roots
+// that don't stand for a level, like the lanes of the priorities once they
+// are sealed, are kept apart.
+type levels[T any] struct {
+	at    []level[T]
+	loose []<-chan T
+}
+
+// get returns the i-th level, adding the levels below it that are missing.
+func (l *levels[T]) get(i int) *level[T] {
+	for len(l.at) <= i {
+		l.at = append(l.at, level[T]{})
+	}
+	return &l.at[i]
+}
+
+// roots returns the roots of the levels from the lowest, and the loose ones.
+func (l *levels[T]) roots() []<-chan T {
+	var roots []<-chan T
+	for _, lv := range l.at {
+		if lv.root != nil {
+			roots = append(roots, lv.root)
+		}
+	}
+	return append(roots, l.loose...)
+}
+
+// takeRoots is roots, with the levels emptied of them.
+func (l *levels[T]) takeRoots() []<-chan T {
+	roots := l.roots()
+	for i := range l.at {
+		l.at[i].root = nil
+	}
+	l.loose = nil
+	return roots
+}
+
+// occupied returns the levels holding a root, from the lowest.
+func (l *levels[T]) occupied() []int {
+	var occupied []int
+	for i, lv := range l.at {
+		if lv.root != nil {
+			occupied = append(occupied, i)
+		}
+	}
+	return occupied
+}
+
+// height is the number of levels up to the highest one holding a root.
+func (l *levels[T]) height() int {
+	for i := len(l.at) - 1; i >= 0; i-- {
+		if l.at[i].root != nil {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,175 @@
+package treeduction
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// WithLocalGroups reduces the inputs in two levels: they are split in
+// groups of size in the order they are added, and each group is folded by
+// one of up to workers goroutines (group g by worker g%workers), which sends
+// its partial to the tree whenever none of its inputs has a value ready. The
+// tree then only combines the partials, a leaf per worker, so the values of
+// an input stay on the goroutine that reads it until they are folded, which
+// keeps most of the combines local when there are many inputs with cheap
+// values. It only applies to unordered trees without WithMaxWorkers,
+// WithInline or WithBatchIsolation, and the priorities of the inputs,
+// WithMaxInFlight, WithRateLimit and Pause are ignored.
+func WithLocalGroups(size, workers int) Option {
+	if size < 1 || workers < 1 {
+		panic("treeduction: local groups need a size and workers")
+	}
+	return func(o *options) {
+		o.localSize = size
+		o.localWorkers = workers
+	}
+}
+
+// lworker folds the inputs of its groups, see WithLocalGroups.
+type lworker[T any] struct {
+	wake chan struct{}
+
+	mu      sync.Mutex
+	pending []inlined[T]
+	running bool
+}
+
+func (t *tree[T]) localizing() bool {
+	return t.opts.localSize > 0 && !t.ordered && !t.isolating()
+}
+
+// addLocal hands the inputs to the workers of their groups, and returns the
+// leaves of the workers it started.
+func (t *tree[T]) addLocal(out []<-chan T) []<-chan T {
+	var leaves []<-chan T
+	for _, o := range out {
+		k := t.grouped / t.opts.localSize % t.opts.localWorkers
+		t.grouped++
+		for len(t.local) <= k {
+			t.local = append(t.local, &lworker[T]{wake: make(chan struct{}, 1)})
+		}
+		w := t.local[k]
+		w.mu.Lock()
+		w.pending = append(w.pending, inlined[T]{c: o, src: t.attach(o)})
+		// A worker exits once its inputs are closed, the next group of it
+		// starts a new one
+		if !w.running {
+			w.running = true
+			c := make(chan T, t.bufAt(0))
+			t.track(c, "local")
+			t.spawn(func() { t.runLocal(w, c) }, "role", "local", "worker", strconv.Itoa(k))
+			leaves = append(leaves, c)
+		}
+		w.mu.Unlock()
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+	return leaves
+}
+
+// runLocal folds the values of the inputs of a worker, and puts the partial
+// on its leaf once no other value is ready.
+func (t *tree[T]) runLocal(w *lworker[T], leaf chan T) {
+	defer func() {
+		t.untrack(leaf)
+		close(leaf)
+	}()
+
+	var acc T
+	var held bool
+	fold := func(v T, src *source) {
+		t.consume(src)
+		v, ok := t.admit(v, src)
+		switch {
+		case !ok:
+		case held:
+			acc = t.combine(acc, v)
+		default:
+			acc, held = v, true
+		}
+	}
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(t.ctx.Done())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(w.wake)},
+	}
+	var ins []inlined[T]
+	end := func(k int) {
+		t.forget(ins[k].c, ins[k].src)
+		ins = slices.Delete(ins, k, k+1)
+		cases = slices.Delete(cases, 2+2*k, 4+2*k)
+	}
+
+	for {
+		w.mu.Lock()
+		for _, in := range w.pending {
+			ins = append(ins, in)
+			cases = append(cases,
+				reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(in.c)},
+				reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(in.src.detach)},
+			)
+		}
+		w.pending = nil
+		if len(ins) == 0 && !held {
+			w.running = false
+			w.mu.Unlock()
+			return
+		}
+		w.mu.Unlock()
+
+		n := len(cases)
+		if held {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+		}
+		i, v, ok := reflect.Select(cases)
+		cases = cases[:n]
+		switch {
+		case i == n:
+			t.put(leaf, acc)
+			var zero T
+			acc, held = zero, false
+		case i == 0:
+			if held {
+				t.put(leaf, acc)
+			}
+			w.mu.Lock()
+			ins = append(ins, w.pending...)
+			w.pending = nil
+			w.running = false
+			w.mu.Unlock()
+			for _, in := range ins {
+				t.forget(in.c, in.src)
+			}
+			return
+		case i == 1:
+		case (i-2)%2 == 1:
+			// Removed, the values buffered in it are folded first
+			k := (i - 2) / 2
+			for drained := false; !drained; {
+				select {
+				case x, ok := <-ins[k].c:
+					if ok {
+						fold(x, ins[k].src)
+					} else {
+						drained = true
+					}
+				default:
+					drained = true
+				}
+			}
+			end(k)
+		case !ok:
+			end((i - 2) / 2)
+		default:
+			var x T
+			if iv := v.Interface(); iv != nil {
+				x = iv.(T)
+			}
+			fold(x, ins[(i-2)/2].src)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestLocalGroups tests that the inputs are reduced by a bounded number of
+// workers, streaming or not, and that a worker whose inputs are closed is
+// started again by the next group.
+func TestLocalGroups(t *testing.T) {
+	for _, waitForAll := range []bool{true, false} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, waitForAll, false, treeduction.WithLocalGroups(4, 2))
+
+		inputs := make([]chan int, 20)
+		chans := make([]<-chan int, len(inputs))
+		for i := range inputs {
+			inputs[i] = make(chan int)
+			chans[i] = inputs[i]
+		}
+		tree.Add(chans...)
+		// 2 workers, and the node combining their leaves
+		if g := tree.Stats().Goroutines; g > 4 {
+			t.Errorf("Expected at most 4 goroutines, got %d", g)
+		}
+		for i, c := range inputs {
+			go func() {
+				for j := range 10 {
+					c <- i*10 + j
+				}
+				close(c)
+			}()
+		}
+		time.Sleep(10 * time.Millisecond)
+		tree.AddValues(1000)
+
+		want := 200*199/2 + 1000
+		if !waitForAll {
+			// Finish stops reading the inputs of a streaming tree
+			sum := 0
+			for sum < want {
+				select {
+				case v := <-tree.Output():
+					sum += v
+				case <-time.After(time.Second):
+					t.Fatalf("Expected %d, got %d", want, sum)
+				}
+			}
+		}
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		if waitForAll {
+			if sum := <-tree.Output(); sum != want {
+				t.Errorf("Expected %d, got %d", want, sum)
+			}
+		}
+	}
+}
+
+// TestLocalGroupsRemove tests that the values buffered in a removed input
+// are still folded.
+func TestLocalGroupsRemove(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithLocalGroups(2, 1))
+	c := make(chan int, 3)
+	c <- 1
+	c <- 2
+	c <- 3
+	tree.Add(c)
+	tree.Remove(c)
+	tree.AddValues(4)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 10 {
+		t.Errorf("Expected 10, got %d", v)
+	}
+}
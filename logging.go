@@ -0,0 +1,40 @@
+package treeduction
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithName names the tree in its logs, to tell it apart from the other trees
+// of the process, see WithLogger.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithLogger logs the lifecycle of the tree to l: the inputs added and the
+// levels the tree grows at the debug level, Finish and Abort at the info
+// level, the results dropped by the backpressure policy and the errors of
+// the inputs that don't fail the tree as warnings, and the first error of
+// the tree as an error.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// loggerOf returns the logger of the options, with the name of the tree.
+func loggerOf(o options) *slog.Logger {
+	if o.logger == nil || o.name == "" {
+		return o.logger
+	}
+	return o.logger.With("tree", o.name)
+}
+
+func (t *tree[T]) log(level slog.Level, msg string, args ...any) {
+	if t.logger == nil {
+		return
+	}
+	t.logger.Log(context.Background(), level, msg, args...)
+}
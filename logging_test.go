@@ -0,0 +1,41 @@
+package treeduction_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"treeduction"
+)
+
+// TestLogger tests that the lifecycle of a tree is logged with its name.
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	tree := treeduction.New(func(a, b int) int {
+		if a+b > 5 {
+			panic("too much")
+		}
+		return a + b
+	}, 10, true, false, treeduction.WithName("sums"), treeduction.WithLogger(logger))
+
+	tree.AddValues(1, 2)
+	tree.AddValues(3, 4)
+	tree.Finish()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for _, want := range []string{"inputs added", "level promoted", "failed", "finished"} {
+		found := false
+		for _, line := range lines {
+			if strings.Contains(line, "msg=\""+want+"\"") || strings.Contains(line, "msg="+want) {
+				found = true
+				if !strings.Contains(line, "tree=sums") {
+					t.Errorf("Expected the name of the tree in %q", line)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected %q to be logged, got:\n%s", want, buf.String())
+		}
+	}
+}
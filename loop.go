@@ -0,0 +1,129 @@
+package treeduction
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Loop feeds the results of the tree back into it, for iterative
+// reductions: a result that reaches the root is only put on the output once
+// converged returns true for it. Otherwise it waits for the next result
+// that didn't converge, the two are combined and the result enters the tree
+// again through a leaf of its own, where it may meet new values on its way
+// to the root. The results that loop are still inside the tree, so they
+// count for WithMaxInFlight and WithWeighter, and Finish waits for them:
+// once the inputs are done and nothing else is left in the tree, the last
+// result is put on the output even if it didn't converge. It only applies
+// to unordered streaming trees without WithMaxWorkers, and can only be
+// called once.
+func (t *tree[T]) Loop(converged func(T) bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.finished.Load() {
+		t.fail(ErrFinished)
+		return ErrFinished
+	}
+	if t.ordered || t.waitForAll || t.opts.pooled() {
+		return fmt.Errorf("treeduction: only unordered streaming trees without workers can loop: %w", errors.ErrUnsupported)
+	}
+	l := &loop[T]{
+		converged: converged,
+		wake:      make(chan struct{}, 1),
+		back:      make(chan T, t.bufAt(0)),
+	}
+	if !t.loop.CompareAndSwap(nil, l) {
+		return errors.New("treeduction: the tree already loops")
+	}
+	t.track(l.back, "loop")
+	t.spawn(func() { t.runLoop(l) }, "role", "loop")
+	t.plant([]<-chan T{l.back}, 0)
+	return nil
+}
+
+// loop holds the results that didn't converge until they are fed back.
+type loop[T any] struct {
+	converged func(T) bool
+	wake      chan struct{}
+	back      chan T
+
+	mu      sync.Mutex
+	pending []T
+	done    bool
+}
+
+// feed takes a result that reached the root, it reports false if the result
+// goes on to the output instead.
+func (l *loop[T]) feed(v T) bool {
+	if l.converged(v) {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done {
+		return false
+	}
+	l.pending = append(l.pending, v)
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// runLoop combines the pending results two by two and feeds them back, until
+// the inputs are done and the pending results are all that is left in the
+// tree.
+func (t *tree[T]) runLoop(l *loop[T]) {
+	defer func() {
+		t.untrack(l.back)
+		close(l.back)
+	}()
+	var fed func()
+	defer func() {
+		if fed != nil {
+			fed()
+		}
+	}()
+	for {
+		l.mu.Lock()
+		n := len(l.pending)
+		if n >= 2 {
+			f, s := l.pending[0], l.pending[1]
+			l.pending = l.pending[2:]
+			l.mu.Unlock()
+			t.forward(l.back, t.combine(f, s))
+			continue
+		}
+		// The values in flight would come back to the loop, or be combined
+		// with a result fed back
+		if t.ctx.Err() != nil && t.alive.Load() == int64(n) {
+			l.done = true
+			last := l.pending
+			l.pending = nil
+			l.mu.Unlock()
+			for _, v := range last {
+				t.deliver(v, nil)
+			}
+			return
+		}
+		l.mu.Unlock()
+
+		// Once the inputs are done, the nodes pass on the values they hold
+		// for a pair, and nothing tells when the tree is empty
+		done, poll := t.ctx.Done(), (<-chan time.Time)(nil)
+		if t.ctx.Err() != nil {
+			done, poll = nil, time.After(time.Millisecond)
+			if fed == nil {
+				fed = t.hunger()
+			}
+		}
+		select {
+		case <-l.wake:
+		case <-done:
+		case <-poll:
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package treeduction_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestLoop tests that the results are fed back into the tree until they
+// converge, and that Finish puts the last one on the output.
+func TestLoop(t *testing.T) {
+	for _, opts := range [][]treeduction.Option{
+		nil,
+		{treeduction.WithStrategy(treeduction.KAry(3))},
+		{treeduction.WithMaxInFlight(4)},
+	} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 2, false, false, opts...)
+		if err := tree.Loop(func(v int) bool {
+			return v >= 8
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		var inputs []chan int
+		for range 4 {
+			c := make(chan int, 10)
+			for range 10 {
+				c <- 1
+			}
+			close(c)
+			inputs = append(inputs, c)
+			tree.Add(c)
+		}
+		results := make(chan []int)
+		go func() {
+			var rs []int
+			for v := range tree.Output() {
+				rs = append(rs, v)
+			}
+			results <- rs
+		}()
+
+		// Finish stops reading the inputs
+		for _, c := range inputs {
+			for len(c) > 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		sum := 0
+		rs := <-results
+		for i, v := range rs {
+			if v < 8 && i < len(rs)-1 {
+				t.Errorf("Expected converged results before the last one, got %v", rs)
+			}
+			sum += v
+		}
+		if sum != 40 {
+			t.Errorf("Expected the results to sum to 40, got %v", rs)
+		}
+	}
+}
+
+// TestLoopAbort tests that Abort drops the results that loop.
+func TestLoopAbort(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 2, false, false)
+	tree.Loop(func(int) bool {
+		return false
+	})
+	c := make(chan int, 3) // Never closed
+	c <- 1
+	c <- 2
+	c <- 3
+	tree.Add(c)
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		done <- tree.Abort()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Abort() to return")
+	}
+}
+
+// TestLoopUnsupported tests that only unordered streaming trees loop, once.
+func TestLoopUnsupported(t *testing.T) {
+	sum := func(a, b int) int {
+		return a + b
+	}
+	never := func(int) bool {
+		return false
+	}
+	for _, tree := range []treeduction.Tree[int]{
+		treeduction.New(sum, 1, true, false),
+		treeduction.New(sum, 1, false, true),
+		treeduction.New(sum, 1, false, false, treeduction.WithMaxWorkers(2)),
+	} {
+		if err := tree.Loop(never); !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("Expected errors.ErrUnsupported from Loop(), got %v", err)
+		}
+		tree.Finish()
+	}
+
+	tree := treeduction.New(sum, 1, false, false)
+	if err := tree.Loop(never); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Loop(never); err == nil {
+		t.Error("Expected an error from a second Loop()")
+	}
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+}
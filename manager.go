@@ -0,0 +1,177 @@
+package treeduction
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Manager keeps a tree per key (e.g. per tenant), created on the first
+// input of the key, and evicts the trees that went idle. The results are
+// read from the trees made by newTree, which can start reading their output
+// before returning them.
+type Manager[K comparable, T any] struct {
+	newTree func(key K) Tree[T]
+	idle    time.Duration
+
+	mu     sync.Mutex
+	trees  map[K]*managed[T]
+	closed bool
+	// The trees being finished after being evicted, and their errors
+	evicting sync.WaitGroup
+	errs     []error
+	stop     chan struct{}
+}
+
+type managed[T any] struct {
+	tree Tree[T]
+	// When the last input was submitted
+	last time.Time
+}
+
+// NewManager returns a manager of trees made by newTree. With idle > 0, a
+// tree that got no input and read no value for idle (checked every idle, so
+// up to 2*idle after the last one) is finished and forgotten, and the next
+// input of its key makes a new tree. An evicted tree that doesn't finish
+// within another idle, like a waitForAll tree with an input left open, is
+// aborted with context.DeadlineExceeded.
+func NewManager[K comparable, T any](newTree func(key K) Tree[T], idle time.Duration) *Manager[K, T] {
+	m := &Manager[K, T]{
+		newTree: newTree,
+		idle:    idle,
+		trees:   make(map[K]*managed[T]),
+		stop:    make(chan struct{}),
+	}
+	if idle > 0 {
+		go m.evictIdle()
+	}
+	return m
+}
+
+// Submit adds the channels to the tree of key, made first if there is none.
+// It returns ErrFinished after FinishAll or AbortAll.
+func (m *Manager[K, T]) Submit(key K, in ...<-chan T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrFinished
+	}
+
+	t, ok := m.trees[key]
+	if !ok {
+		t = &managed[T]{tree: m.newTree(key)}
+		m.trees[key] = t
+	}
+	t.last = time.Now()
+	return t.tree.Add(in...)
+}
+
+// Tree returns the tree of key, if there is one.
+func (m *Manager[K, T]) Tree(key K) (Tree[T], bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.trees[key]
+	if !ok {
+		return nil, false
+	}
+	return t.tree, true
+}
+
+// Len returns the number of trees.
+func (m *Manager[K, T]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.trees)
+}
+
+// FinishAll finishes every tree in parallel, and returns the errors they
+// and the evicted trees finished with. No tree is made afterwards.
+func (m *Manager[K, T]) FinishAll() error {
+	return m.closeAll(Tree[T].Finish)
+}
+
+// AbortAll aborts every tree, see FinishAll.
+func (m *Manager[K, T]) AbortAll() error {
+	return m.closeAll(Tree[T].Abort)
+}
+
+func (m *Manager[K, T]) closeAll(end func(Tree[T]) error) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrFinished
+	}
+	m.closed = true
+	trees := m.trees
+	m.trees = make(map[K]*managed[T])
+	m.mu.Unlock()
+	close(m.stop)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 0, len(trees))
+	var errMu sync.Mutex
+	for _, t := range trees {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := end(t.tree); err != nil {
+				errMu.Lock()
+				errs = append(errs, err)
+				errMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	m.evicting.Wait()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return errors.Join(append(errs, m.errs...)...)
+}
+
+// evictIdle finishes the idle trees every idle, until the manager is
+// closed.
+func (m *Manager[K, T]) evictIdle() {
+	ticker := time.NewTicker(m.idle)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-m.stop:
+			return
+		}
+
+		m.mu.Lock()
+		for key, t := range m.trees {
+			if time.Since(t.lastActive()) < m.idle {
+				continue
+			}
+			delete(m.trees, key)
+			m.evicting.Add(1)
+			go func() {
+				defer m.evicting.Done()
+				ctx, cancel := context.WithTimeout(context.Background(), m.idle)
+				defer cancel()
+				if err := t.tree.FinishContext(ctx); err != nil {
+					m.mu.Lock()
+					m.errs = append(m.errs, err)
+					m.mu.Unlock()
+				}
+			}()
+		}
+		m.mu.Unlock()
+	}
+}
+
+// lastActive is when an input was last submitted to the tree, or a value
+// last read from its inputs.
+func (t *managed[T]) lastActive() time.Time {
+	last := t.last
+	for _, s := range t.tree.Stats().Sources {
+		if s.Last.After(last) {
+			last = s.Last
+		}
+	}
+	return last
+}
@@ -0,0 +1,123 @@
+package treeduction_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+func values(vs ...int) <-chan int {
+	c := make(chan int, len(vs))
+	for _, v := range vs {
+		c <- v
+	}
+	close(c)
+	return c
+}
+
+// TestManager tests that the inputs of every key are reduced by a tree of
+// their own.
+func TestManager(t *testing.T) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sums := make(map[string][]int)
+	m := treeduction.NewManager(func(key string) treeduction.Tree[int] {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range tree.Output() {
+				mu.Lock()
+				sums[key] = append(sums[key], v)
+				mu.Unlock()
+			}
+		}()
+		return tree
+	}, 0)
+
+	m.Submit("a", values(1, 2))
+	m.Submit("b", values(10))
+	m.Submit("a", values(3))
+	if n := m.Len(); n != 2 {
+		t.Errorf("Expected 2 trees, got %d", n)
+	}
+	if _, ok := m.Tree("c"); ok {
+		t.Error("Expected no tree for a key without inputs")
+	}
+	if err := m.FinishAll(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if sums["a"][0] != 6 || sums["b"][0] != 10 {
+		t.Errorf("Expected 6 and 10, got %v", sums)
+	}
+	if err := m.Submit("a", values(1)); !errors.Is(err, treeduction.ErrFinished) {
+		t.Errorf("Expected ErrFinished after FinishAll, got %v", err)
+	}
+}
+
+// TestManagerEvict tests that an idle tree is finished, and made again by
+// the next input of its key.
+func TestManagerEvict(t *testing.T) {
+	results := make(chan int, 2)
+	m := treeduction.NewManager(func(key string) treeduction.Tree[int] {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false)
+		go func() {
+			for v := range tree.Output() {
+				results <- v
+			}
+		}()
+		return tree
+	}, 10*time.Millisecond)
+
+	m.Submit("a", values(1, 2))
+	if r := <-results; r != 3 {
+		t.Errorf("Expected 3 from the evicted tree, got %d", r)
+	}
+	if n := m.Len(); n != 0 {
+		t.Errorf("Expected the tree to be evicted, got %d trees", n)
+	}
+
+	m.Submit("a", values(4))
+	m.AbortAll()
+	select {
+	case r := <-results:
+		t.Errorf("Expected the aborted tree to put nothing on the output, got %d", r)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+// TestManagerEvictOpen tests that an evicted tree with an input left open
+// is aborted instead of holding FinishAll back.
+func TestManagerEvictOpen(t *testing.T) {
+	m := treeduction.NewManager(func(key string) treeduction.Tree[int] {
+		return treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false)
+	}, 10*time.Millisecond)
+
+	m.Submit("a", make(chan int))
+	for m.Len() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- m.FinishAll()
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the evicted tree to be aborted, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected FinishAll to return")
+	}
+}
@@ -0,0 +1,580 @@
+package treeduction
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"iter"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// MapTree returns a view of t as a tree of U, for code that expects a tree
+// of another type: to converts the results (and snapshots) of t, and from
+// converts the values added to the view before they enter t. The lifecycle
+// is the one of t, so finishing or aborting the view finishes or aborts t.
+//
+// Each channel added to the view is converted by a goroutine, which stops
+// once the channel is closed or removed, or the view is finished. Output and
+// Partitions are converted by a goroutine as well, started by their first
+// call, which holds one result in hand: don't mix them with Next, TryNext
+// or Results.
+func MapTree[T, U any](t Tree[T], to func(T) U, from func(U) T) Tree[U] {
+	return &mapped[T, U]{
+		t:       t,
+		to:      to,
+		from:    from,
+		done:    make(chan struct{}),
+		aborted: make(chan struct{}),
+		inputs:  make(map[<-chan U]minput[T]),
+		origin:  make(map[<-chan T]<-chan U),
+	}
+}
+
+type mapped[T, U any] struct {
+	t    Tree[T]
+	to   func(T) U
+	from func(U) T
+
+	// Guards the converted inputs, done is closed once t doesn't read
+	// anymore and aborted once the view is aborted, both replaced by Reset
+	mu      sync.Mutex
+	done    chan struct{}
+	aborted chan struct{}
+	inputs  map[<-chan U]minput[T]
+	origin  map[<-chan T]<-chan U
+
+	outputOnce     sync.Once
+	output         <-chan U
+	partitionsOnce sync.Once
+	partitions     []<-chan U
+	classes        map[string]<-chan U
+}
+
+// minput is a channel added to a mapped tree, with the channel its values
+// are converted to.
+type minput[T any] struct {
+	c    <-chan T
+	stop chan struct{}
+}
+
+// convert starts converting the values of in, until in is closed or
+// removed, stop is closed, or the view is finished.
+func (m *mapped[T, U]) convert(in <-chan U, stop <-chan struct{}) <-chan T {
+	if in == nil {
+		return nil
+	}
+	c := make(chan T)
+	removed := make(chan struct{})
+	m.mu.Lock()
+	m.inputs[in] = minput[T]{c: c, stop: removed}
+	m.origin[c] = in
+	done := m.done
+	m.mu.Unlock()
+
+	go func() {
+		defer close(c)
+		for {
+			var v U
+			select {
+			case u, ok := <-in:
+				if !ok {
+					return
+				}
+				v = u
+			case <-removed:
+				return
+			case <-stop:
+				return
+			case <-done:
+				return
+			}
+			select {
+			case c <- m.from(v):
+			case <-removed:
+				return
+			case <-stop:
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+	return c
+}
+
+func (m *mapped[T, U]) convertAll(out []<-chan U) []<-chan T {
+	cs := make([]<-chan T, len(out))
+	for i, in := range out {
+		cs[i] = m.convert(in, nil)
+	}
+	return cs
+}
+
+// stop ends the goroutines converting the inputs, once t stopped reading.
+func (m *mapped[T, U]) stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case <-m.done:
+	default:
+		close(m.done)
+	}
+}
+
+// mapChan converts the values of c in a goroutine, until c is closed or the
+// view is aborted. It is called with m.mu held.
+func (m *mapped[T, U]) mapChan(c <-chan T) <-chan U {
+	out := make(chan U)
+	aborted := m.aborted
+	go func() {
+		defer close(out)
+		for v := range c {
+			select {
+			case out <- m.to(v):
+			case <-aborted:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (m *mapped[T, U]) mapSlice(vs []T) []U {
+	if vs == nil {
+		return nil
+	}
+	us := make([]U, len(vs))
+	for i, v := range vs {
+		us[i] = m.to(v)
+	}
+	return us
+}
+
+func (m *mapped[T, U]) unmapSlice(us []U) []T {
+	vs := make([]T, len(us))
+	for i, u := range us {
+		vs[i] = m.from(u)
+	}
+	return vs
+}
+
+func (m *mapped[T, U]) Add(out ...<-chan U) error {
+	return m.t.Add(m.convertAll(out)...)
+}
+
+func (m *mapped[T, U]) AddSeq(seqs ...iter.Seq[U]) error {
+	converted := make([]iter.Seq[T], len(seqs))
+	for i, seq := range seqs {
+		converted[i] = func(yield func(T) bool) {
+			for u := range seq {
+				if !yield(m.from(u)) {
+					return
+				}
+			}
+		}
+	}
+	return m.t.AddSeq(converted...)
+}
+
+func (m *mapped[T, U]) AddSlice(s []U) error {
+	return m.t.AddSlice(m.unmapSlice(s))
+}
+
+func (m *mapped[T, U]) AddValues(vs ...U) error {
+	return m.t.AddValues(m.unmapSlice(vs)...)
+}
+
+func (m *mapped[T, U]) AddWithErr(in <-chan U, errs <-chan error) error {
+	return m.t.AddWithErr(m.convert(in, nil), errs)
+}
+
+func (m *mapped[T, U]) AddWithPriority(p int, out ...<-chan U) error {
+	return m.t.AddWithPriority(p, m.convertAll(out)...)
+}
+
+// AddTree adds sub to t through a view of sub as a tree of T.
+func (m *mapped[T, U]) AddTree(sub Tree[U]) error {
+	return m.t.AddTree(MapTree(sub, m.from, m.to))
+}
+
+func (m *mapped[T, U]) AddReader(r io.Reader, decode func(*bufio.Reader) (U, error)) error {
+	return m.t.AddReader(r, func(br *bufio.Reader) (T, error) {
+		u, err := decode(br)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		return m.from(u), nil
+	})
+}
+
+func (m *mapped[T, U]) AddProducer(produce func(ctx context.Context) <-chan U) error {
+	return m.t.AddProducer(func(ctx context.Context) <-chan T {
+		return m.convert(produce(ctx), ctx.Done())
+	})
+}
+
+func (m *mapped[T, U]) AddUntil(ch <-chan U, isLast func(U) bool, keepLast bool) error {
+	m.mu.Lock()
+	done := m.done
+	m.mu.Unlock()
+	return m.t.Go(untilLast(ch, isLast, keepLast, done, m.from))
+}
+
+func (m *mapped[T, U]) AddAny(ch <-chan any, convert func(any) (U, bool)) error {
+	return m.t.AddAny(ch, func(a any) (T, bool) {
+		u, ok := convert(a)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		return m.from(u), true
+	})
+}
+
+// AddSources adds the inputs of set to t at once, converted like the
+// channels added to the view.
+func (m *mapped[T, U]) AddSources(set *SourceSet[U]) error {
+	converted := &SourceSet[T]{}
+	for _, in := range set.inputs {
+		if in.produce == nil {
+			converted.inputs = append(converted.inputs, setInput[T]{in: m.convert(in.in, nil), errs: in.errs})
+			continue
+		}
+		converted.inputs = append(converted.inputs, setInput[T]{produce: func(ctx context.Context, emit func(T)) error {
+			return in.produce(ctx, func(u U) {
+				emit(m.from(u))
+			})
+		}})
+	}
+	return m.t.AddSources(converted)
+}
+
+func (m *mapped[T, U]) Go(f func(emit func(U)) error) error {
+	return m.t.Go(func(emit func(T)) error {
+		return f(func(u U) {
+			emit(m.from(u))
+		})
+	})
+}
+
+func (m *mapped[T, U]) Remove(in <-chan U) bool {
+	m.mu.Lock()
+	i, ok := m.inputs[in]
+	if ok {
+		delete(m.inputs, in)
+		delete(m.origin, i.c)
+	}
+	m.mu.Unlock()
+	if !ok {
+		return false
+	}
+	removed := m.t.Remove(i.c)
+	close(i.stop)
+	return removed
+}
+
+func (m *mapped[T, U]) Rebalance() {
+	m.t.Rebalance()
+}
+
+func (m *mapped[T, U]) Results() iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for v := range m.t.Results() {
+			if !yield(m.to(v)) {
+				m.stop()
+				return
+			}
+		}
+		m.stop()
+	}
+}
+
+func (m *mapped[T, U]) Results2() iter.Seq2[U, error] {
+	return func(yield func(U, error) bool) {
+		defer m.stop()
+		for v, err := range m.t.Results2() {
+			if err != nil {
+				var zero U
+				yield(zero, err)
+				return
+			}
+			if !yield(m.to(v), nil) {
+				return
+			}
+		}
+	}
+}
+
+func (m *mapped[T, U]) Drain(ctx context.Context) ([]U, error) {
+	defer m.stop()
+	vs, err := m.t.Drain(ctx)
+	return m.mapSlice(vs), err
+}
+
+func (m *mapped[T, U]) Output() <-chan U {
+	m.outputOnce.Do(func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.output = m.mapChan(m.t.Output())
+	})
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.output
+}
+
+func (m *mapped[T, U]) OutputBatches(n int) <-chan []U {
+	out := make(chan []U)
+	m.mu.Lock()
+	aborted := m.aborted
+	m.mu.Unlock()
+	go func() {
+		defer close(out)
+		for b := range m.t.OutputBatches(n) {
+			select {
+			case out <- m.mapSlice(b):
+			case <-aborted:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (m *mapped[T, U]) Done() <-chan struct{} {
+	return m.t.Done()
+}
+
+func (m *mapped[T, U]) TryNext() (U, bool) {
+	v, ok := m.t.TryNext()
+	if !ok {
+		var zero U
+		return zero, false
+	}
+	return m.to(v), true
+}
+
+func (m *mapped[T, U]) Next(ctx context.Context) (U, error) {
+	v, err := m.t.Next(ctx)
+	if err != nil {
+		var zero U
+		return zero, err
+	}
+	return m.to(v), nil
+}
+
+func (m *mapped[T, U]) TakeN(ctx context.Context, n int) ([]U, error) {
+	vs, err := m.t.TakeN(ctx, n)
+	us := make([]U, len(vs))
+	for i, v := range vs {
+		us[i] = m.to(v)
+	}
+	return us, err
+}
+
+func (m *mapped[T, U]) Partitions() []<-chan U {
+	m.partitionsOnce.Do(func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for _, p := range m.t.Partitions() {
+			m.partitions = append(m.partitions, m.mapChan(p))
+		}
+	})
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.partitions
+}
+
+func (m *mapped[T, U]) OutputFor(class string) <-chan U {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.classes[class]; ok {
+		return c
+	}
+	out := m.t.OutputFor(class)
+	if out == nil {
+		return nil
+	}
+	if m.classes == nil {
+		m.classes = make(map[string]<-chan U)
+	}
+	c := m.mapChan(out)
+	m.classes[class] = c
+	return c
+}
+
+func (m *mapped[T, U]) Start(ctx context.Context) error {
+	return m.t.Start(ctx)
+}
+
+func (m *mapped[T, U]) AttachGroup(g *errgroup.Group, ctx context.Context) {
+	m.t.AttachGroup(g, ctx)
+}
+
+func (m *mapped[T, U]) Classes() []string {
+	return m.t.Classes()
+}
+
+func (m *mapped[T, U]) Finish() error {
+	defer m.stop()
+	return m.t.Finish()
+}
+
+func (m *mapped[T, U]) FinishContext(ctx context.Context) error {
+	defer m.stop()
+	return m.t.FinishContext(ctx)
+}
+
+// Abort aborts t, and discards the results converted for the outputs that
+// nobody reads anymore.
+func (m *mapped[T, U]) Abort() error {
+	defer m.stop()
+	m.mu.Lock()
+	select {
+	case <-m.aborted:
+	default:
+		close(m.aborted)
+	}
+	m.mu.Unlock()
+	return m.t.Abort()
+}
+
+func (m *mapped[T, U]) Seal() error {
+	return m.t.Seal()
+}
+
+func (m *mapped[T, U]) Err() error {
+	return m.t.Err()
+}
+
+func (m *mapped[T, U]) Errors() []error {
+	return m.t.Errors()
+}
+
+func (m *mapped[T, U]) Snapshot() (U, bool) {
+	v, ok := m.t.Snapshot()
+	if !ok {
+		var zero U
+		return zero, false
+	}
+	return m.to(v), true
+}
+
+// Stats returns the stats of t. Only the inputs added through the view are
+// listed in Consumed and Sources.
+func (m *mapped[T, U]) Stats() Stats[U] {
+	s := m.t.Stats()
+	u := Stats[U]{
+		Nodes:      s.Nodes,
+		Goroutines: s.Goroutines,
+		Consumed:   make(map[<-chan U]int64),
+		Sources:    make(map[<-chan U]SourceStats),
+		Emitted:    s.Emitted,
+		Depth:      s.Depth,
+		Pending:    s.Pending,
+		Expired:    s.Expired,
+		Rejected:   s.Rejected,
+		Duplicates: s.Duplicates,
+		Latency:    s.Latency,
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for c, n := range s.Consumed {
+		if in, ok := m.origin[c]; ok {
+			u.Consumed[in] = n
+		}
+	}
+	for c, ss := range s.Sources {
+		if in, ok := m.origin[c]; ok {
+			u.Sources[in] = ss
+		}
+	}
+	return u
+}
+
+// SlowestSources returns the slowest of the inputs added through the view.
+func (m *mapped[T, U]) SlowestSources(k int) []<-chan U {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var slowest []<-chan U
+	for _, c := range m.t.SlowestSources(len(m.origin) + k) {
+		if in, ok := m.origin[c]; ok && len(slowest) < k {
+			slowest = append(slowest, in)
+		}
+	}
+	return slowest
+}
+
+func (m *mapped[T, U]) Dump(w io.Writer) error {
+	return m.t.Dump(w)
+}
+
+func (m *mapped[T, U]) Reset() error {
+	if err := m.t.Reset(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.done = make(chan struct{})
+	m.aborted = make(chan struct{})
+	clear(m.inputs)
+	clear(m.origin)
+	m.outputOnce, m.output = sync.Once{}, nil
+	m.partitionsOnce, m.partitions = sync.Once{}, nil
+	m.classes = nil
+	return nil
+}
+
+func (m *mapped[T, U]) SetCombiner(combiner func(f U, s U) U) error {
+	return m.t.SetCombiner(func(f T, s T) T {
+		return m.from(combiner(m.to(f), m.to(s)))
+	})
+}
+
+func (m *mapped[T, U]) Loop(converged func(U) bool) error {
+	return m.t.Loop(func(v T) bool {
+		return converged(m.to(v))
+	})
+}
+
+func (m *mapped[T, U]) Run(ctx context.Context) error {
+	return m.t.Run(ctx)
+}
+
+func (m *mapped[T, U]) Pause() {
+	m.t.Pause()
+}
+
+func (m *mapped[T, U]) Resume() {
+	m.t.Resume()
+}
+
+func (m *mapped[T, U]) Checkpoint(w io.Writer, codec Codec[U]) error {
+	return m.t.Checkpoint(w, mappedCodec[T, U]{codec, m.to, m.from})
+}
+
+func (m *mapped[T, U]) Restore(r io.Reader, codec Codec[U]) error {
+	return m.t.Restore(r, mappedCodec[T, U]{codec, m.to, m.from})
+}
+
+// mappedCodec encodes values of T with a Codec of U.
+type mappedCodec[T, U any] struct {
+	codec Codec[U]
+	to    func(T) U
+	from  func(U) T
+}
+
+func (c mappedCodec[T, U]) Encode(w io.Writer, v T) error {
+	return c.codec.Encode(w, c.to(v))
+}
+
+func (c mappedCodec[T, U]) Decode(r io.Reader) (T, error) {
+	u, err := c.codec.Decode(r)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return c.from(u), nil
+}
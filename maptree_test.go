@@ -0,0 +1,133 @@
+package treeduction_test
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+func itoa(v int) string {
+	return strconv.Itoa(v)
+}
+
+func atoi(s string) int {
+	v, _ := strconv.Atoi(s)
+	return v
+}
+
+// TestMapTree tests that a view of a tree converts what goes in and out.
+func TestMapTree(t *testing.T) {
+	sum := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	tree := treeduction.MapTree(sum, itoa, atoi)
+
+	ch := make(chan string, 2)
+	ch <- "1"
+	ch <- "2"
+	close(ch)
+	tree.Add(ch)
+	tree.AddValues("3", "4")
+
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != "10" {
+		t.Errorf("Expected 10, got %s", v)
+	}
+}
+
+// TestMapTreeStats tests that the stats of a view list its inputs.
+func TestMapTreeStats(t *testing.T) {
+	tree := treeduction.MapTree(treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false), itoa, atoi)
+	defer tree.Finish()
+
+	ch := make(chan string)
+	tree.Add(ch)
+	ch <- "5"
+	if v := <-tree.Output(); v != "5" {
+		t.Errorf("Expected 5, got %s", v)
+	}
+	if n := tree.Stats().Consumed[ch]; n != 1 {
+		t.Errorf("Expected 1 value consumed from the input, got %d", n)
+	}
+
+	if !tree.Remove(ch) {
+		t.Error("Expected the input to be removed")
+	}
+	if tree.Remove(ch) {
+		t.Error("Expected the input to be removed only once")
+	}
+}
+
+// TestMapTreeSubtree tests that a tree of another type can be added to a
+// view.
+func TestMapTreeSubtree(t *testing.T) {
+	tree := treeduction.MapTree(treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false), itoa, atoi)
+	sub := treeduction.New(func(a, b string) string {
+		return itoa(atoi(a) * atoi(b))
+	}, 10, true, false)
+	sub.AddValues("2", "3")
+	tree.AddTree(sub)
+	tree.AddValues("4")
+
+	var results []string
+	for v := range tree.Results() {
+		results = append(results, v)
+	}
+	if len(results) != 1 || results[0] != "10" {
+		t.Errorf("Expected [10], got %v", results)
+	}
+}
+
+// TestMapTreeAbort tests that aborting a view closes its converted output.
+func TestMapTreeAbort(t *testing.T) {
+	tree := treeduction.MapTree(treeduction.New(func(a, b int) int {
+		return a + b
+	}, 0, false, false), itoa, atoi)
+	out := tree.Output()
+
+	ch := make(chan string)
+	tree.Add(ch)
+	ch <- "1"
+	tree.Abort()
+
+	done := make(chan struct{})
+	go func() {
+		for range out {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("The output wasn't closed")
+	}
+}
+
+// TestMapTreeAbortLeaks tests that aborting a view stops its goroutines,
+// with an input left open and batches that nobody reads.
+func TestMapTreeAbortLeaks(t *testing.T) {
+	before := runtime.NumGoroutine()
+	tree := treeduction.MapTree(treeduction.New(func(a, b int) int {
+		return a + b
+	}, 0, false, false), itoa, atoi)
+	tree.OutputBatches(1)
+	tree.Add(make(chan string))
+	tree.AddValues("1")
+	tree.Abort()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the goroutines of the view to stop, got %d more", runtime.NumGoroutine()-before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,100 @@
+package treeduction
+
+import "context"
+
+// MergeSorted merges inputs that are each sorted by cmp into one sorted
+// output, with a balanced tree of goroutines that each merge two of the
+// streams, the way a tree reduces them. With combine, the values that
+// compare equal are combined into one (e.g. the counts of the same key),
+// otherwise they are all emitted, the ones of the earlier inputs first. The
+// output is closed once the inputs are all closed, or when ctx is done.
+func MergeSorted[T any](ctx context.Context, cmp func(a, b T) int, combine func(a, b T) T, bufferSize int, inputs ...<-chan T) <-chan T {
+	m := merger[T]{ctx: ctx, cmp: cmp, combine: combine, bufSize: bufferSize}
+	if len(inputs) == 0 {
+		c := make(chan T)
+		close(c)
+		return c
+	}
+	return m.build(inputs)
+}
+
+type merger[T any] struct {
+	ctx     context.Context
+	cmp     func(a, b T) int
+	combine func(a, b T) T
+	bufSize int
+}
+
+// build returns the output of a balanced tree merging the inputs.
+func (m merger[T]) build(inputs []<-chan T) <-chan T {
+	if len(inputs) == 1 {
+		// Still through a node, which stops with ctx and combines the
+		// equal values of the input
+		none := make(chan T)
+		close(none)
+		return m.node(inputs[0], none)
+	}
+	mid := len(inputs) / 2
+	return m.node(m.build(inputs[:mid]), m.build(inputs[mid:]))
+}
+
+// node merges two sorted streams.
+func (m merger[T]) node(f, s <-chan T) <-chan T {
+	out := make(chan T, m.bufSize)
+	go func() {
+		defer close(out)
+		var pending T
+		held := false
+		emit := func(v T) bool {
+			if held && m.combine != nil && m.cmp(pending, v) == 0 {
+				pending = m.combine(pending, v)
+				return true
+			}
+			if held {
+				select {
+				case out <- pending:
+				case <-m.ctx.Done():
+					return false
+				}
+			}
+			pending, held = v, true
+			return true
+		}
+
+		a, aok := m.recv(f)
+		b, bok := m.recv(s)
+		for aok || bok {
+			var ok bool
+			// The left stream goes first on ties, so the merge is stable
+			if aok && (!bok || m.cmp(a, b) <= 0) {
+				ok = emit(a)
+				a, aok = m.recv(f)
+			} else {
+				ok = emit(b)
+				b, bok = m.recv(s)
+			}
+			if !ok {
+				return
+			}
+		}
+		if held && m.ctx.Err() == nil {
+			select {
+			case out <- pending:
+			case <-m.ctx.Done():
+			}
+		}
+	}()
+	return out
+}
+
+// recv reads the next value of c, it reports false once c is closed or ctx
+// is done.
+func (m merger[T]) recv(c <-chan T) (T, bool) {
+	select {
+	case v, ok := <-c:
+		return v, ok
+	case <-m.ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
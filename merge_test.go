@@ -0,0 +1,73 @@
+package treeduction_test
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"testing"
+	"treeduction"
+)
+
+func sorted(vs ...int) <-chan int {
+	c := make(chan int, len(vs))
+	for _, v := range vs {
+		c <- v
+	}
+	close(c)
+	return c
+}
+
+// TestMergeSorted tests that sorted inputs are merged in order.
+func TestMergeSorted(t *testing.T) {
+	out := treeduction.MergeSorted(context.Background(), cmp.Compare[int], nil, 10,
+		sorted(1, 4, 7), sorted(2, 5, 8), sorted(3, 6, 9), sorted(1, 10))
+
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+	if want := []int{1, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+type count struct {
+	key string
+	n   int
+}
+
+// TestMergeSortedCombine tests that the values of the same key are
+// combined, within and across inputs.
+func TestMergeSortedCombine(t *testing.T) {
+	in := func(keys ...string) <-chan count {
+		c := make(chan count, len(keys))
+		for _, k := range keys {
+			c <- count{k, 1}
+		}
+		close(c)
+		return c
+	}
+	out := treeduction.MergeSorted(context.Background(), func(a, b count) int {
+		return cmp.Compare(a.key, b.key)
+	}, func(a, b count) count {
+		return count{a.key, a.n + b.n}
+	}, 10, in("a", "a", "c"), in("b", "c"), in("a", "d"))
+
+	var got []count
+	for v := range out {
+		got = append(got, v)
+	}
+	want := []count{{"a", 3}, {"b", 1}, {"c", 2}, {"d", 1}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestMergeSortedCancel tests that the output is closed once ctx is done.
+func TestMergeSortedCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := treeduction.MergeSorted(ctx, cmp.Compare[int], nil, 0, make(chan int), sorted(1))
+	cancel()
+	for range out {
+	}
+}
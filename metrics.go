@@ -0,0 +1,106 @@
+package treeduction
+
+import (
+	"expvar"
+	"time"
+)
+
+// Metrics receives events from a tree, see WithMetrics. The methods are
+// called from the goroutines of the tree, so they must be safe for concurrent
+// use and return quickly.
+type Metrics interface {
+	// Combined is called after every call to the combiner, with how long it
+	// took.
+	Combined(d time.Duration)
+	// NodeCreated is called when a combining node is added to the tree.
+	NodeCreated()
+	// QueueDepth is called after a value is buffered in a channel of the tree
+	// (the output included), with the number of values buffered in it.
+	QueueDepth(n int)
+}
+
+// WithMetrics reports the events of the tree to m.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+type expvarMetrics struct {
+	combined     *expvar.Int
+	combineNanos *expvar.Int
+	nodes        *expvar.Int
+	queueDepth   *expvar.Int
+}
+
+// NewExpvarMetrics publishes the metrics of a tree as an expvar.Map with the
+// given name, which must be unique like any expvar name. The map holds the
+// number of combines, their total duration in nanoseconds, the number of
+// nodes created and the last queue depth reported.
+func NewExpvarMetrics(name string) Metrics {
+	m := expvar.NewMap(name)
+	e := &expvarMetrics{
+		combined:     new(expvar.Int),
+		combineNanos: new(expvar.Int),
+		nodes:        new(expvar.Int),
+		queueDepth:   new(expvar.Int),
+	}
+	m.Set("combined", e.combined)
+	m.Set("combine_nanos", e.combineNanos)
+	m.Set("nodes", e.nodes)
+	m.Set("queue_depth", e.queueDepth)
+	return e
+}
+
+func (e *expvarMetrics) Combined(d time.Duration) {
+	e.combined.Add(1)
+	e.combineNanos.Add(int64(d))
+}
+
+func (e *expvarMetrics) NodeCreated() {
+	e.nodes.Add(1)
+}
+
+func (e *expvarMetrics) QueueDepth(n int) {
+	e.queueDepth.Set(int64(n))
+}
+
+// nodeCreated accounts for a new combining node. The returned function is
+// called once the node closes.
+func (t *tree[T]) nodeCreated() func() {
+	t.nodes.Add(1)
+	if m := t.opts.metrics; m != nil {
+		m.NodeCreated()
+	}
+
+	parent := t.spanCtx
+	if parent == nil {
+		parent = t.life
+	}
+	span := t.startSpan(parent, "treeduction.node")
+	return func() {
+		t.nodes.Add(-1)
+		span.end()
+	}
+}
+
+// put buffers a value in a channel of the tree.
+func (t *tree[T]) put(c chan<- T, v T) {
+	if !t.forward(c, v) {
+		return
+	}
+	if m := t.opts.metrics; m != nil {
+		m.QueueDepth(len(c))
+	}
+}
+
+// sent is called after a result was put on the output.
+func (t *tree[T]) sent(v T) {
+	t.emitted.Add(1)
+	if t.observe != nil {
+		t.observe(v)
+	}
+	if m := t.opts.metrics; m != nil {
+		m.QueueDepth(len(t.output))
+	}
+}
@@ -0,0 +1,69 @@
+package treeduction_test
+
+import (
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+type countingMetrics struct {
+	combined atomic.Int64
+	nodes    atomic.Int64
+	depths   atomic.Int64
+}
+
+func (m *countingMetrics) Combined(time.Duration) { m.combined.Add(1) }
+func (m *countingMetrics) NodeCreated()           { m.nodes.Add(1) }
+func (m *countingMetrics) QueueDepth(int)         { m.depths.Add(1) }
+
+// TestMetrics tests that the events of the tree are reported.
+func TestMetrics(t *testing.T) {
+	m := &countingMetrics{}
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithMetrics(m))
+
+	tree.AddValues(1)
+	tree.AddValues(2)
+	tree.AddValues(3)
+	tree.AddValues(4)
+	tree.Finish()
+	<-tree.Output()
+
+	if n := m.combined.Load(); n != 3 {
+		t.Errorf("Expected 3 combines, got %d", n)
+	}
+	if n := m.nodes.Load(); n != 3 {
+		t.Errorf("Expected 3 nodes, got %d", n)
+	}
+	if m.depths.Load() == 0 {
+		t.Error("Expected queue depths to be reported")
+	}
+}
+
+var expvarRuns atomic.Int64
+
+// TestExpvarMetrics tests publishing the metrics with expvar.
+func TestExpvarMetrics(t *testing.T) {
+	// Expvar names can't be published twice
+	name := fmt.Sprintf("treeduction_test_%d", expvarRuns.Add(1))
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithMetrics(treeduction.NewExpvarMetrics(name)))
+
+	tree.AddValues(1)
+	tree.AddValues(2)
+	tree.Finish()
+	<-tree.Output()
+
+	m := expvar.Get(name).(*expvar.Map)
+	if v := m.Get("combined").String(); v != "1" {
+		t.Errorf("Expected 1 combine, got %s", v)
+	}
+	if v := m.Get("nodes").String(); v != "1" {
+		t.Errorf("Expected 1 node, got %s", v)
+	}
+}
@@ -0,0 +1,92 @@
+package treeduction
+
+import "context"
+
+// TryNext returns the next result if one is waiting on the output, without
+// blocking. It reports false when there is none yet, and once the output is
+// closed.
+func (t *tree[T]) TryNext() (T, bool) {
+	select {
+	case v, ok := <-t.output:
+		return v, ok
+	default:
+		var zero T
+		return zero, false
+	}
+}
+
+// Next waits for the next result. It returns ErrOutputClosed once the output
+// is closed and every result was read, and the cause of ctx if it is done
+// first.
+func (t *tree[T]) Next(ctx context.Context) (T, error) {
+	var zero T
+	select {
+	case v, ok := <-t.output:
+		if !ok {
+			return zero, ErrOutputClosed
+		}
+		return v, nil
+	case <-ctx.Done():
+		return zero, context.Cause(ctx)
+	}
+}
+
+// TakeN waits for the next n results, e.g. early samples of a streaming tree
+// before the rest is aborted. It returns the results read so far with
+// ErrOutputClosed if the output is closed first, and with the cause of ctx
+// if it is done first. The tree goes on running either way.
+func (t *tree[T]) TakeN(ctx context.Context, n int) ([]T, error) {
+	vs := make([]T, 0, n)
+	for len(vs) < n {
+		v, err := t.Next(ctx)
+		if err != nil {
+			return vs, err
+		}
+		vs = append(vs, v)
+	}
+	return vs, nil
+}
+
+// OutputBatches returns a channel of the results in slices of up to n, e.g.
+// for a consumer that inserts them in bulk: a slice is sent once it holds n
+// results, and the last one, shorter, once the output is closed. It reads
+// the output in a goroutine, so it shouldn't be mixed with the other ways to
+// read it, and it is called again after Reset. The slices that aren't read
+// once the tree is aborted are dropped.
+func (t *tree[T]) OutputBatches(n int) <-chan []T {
+	if n < 1 {
+		panic("treeduction: batches need at least a result")
+	}
+	out := make(chan []T)
+	in, done := t.output, t.done
+	send := func(b []T) bool {
+		select {
+		case out <- b:
+			return true
+		case <-done:
+			if t.aborted.Load() {
+				return false
+			}
+			out <- b
+			return true
+		}
+	}
+	go func() {
+		defer close(out)
+		b := make([]T, 0, n)
+		for v := range in {
+			b = append(b, v)
+			if len(b) < n {
+				continue
+			}
+			if !send(b) {
+				return
+			}
+			b = make([]T, 0, n)
+		}
+		if len(b) > 0 {
+			send(b)
+		}
+	}()
+	return out
+}
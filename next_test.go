@@ -0,0 +1,150 @@
+package treeduction_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestTryNext tests that TryNext doesn't block.
+func TestTryNext(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	if _, ok := tree.TryNext(); ok {
+		t.Error("Expected no result before Finish")
+	}
+	tree.AddValues(1, 2, 3)
+	tree.Finish()
+	if v, ok := tree.TryNext(); !ok || v != 6 {
+		t.Errorf("Expected 6, got %d (%t)", v, ok)
+	}
+	if _, ok := tree.TryNext(); ok {
+		t.Error("Expected no result after the last one")
+	}
+}
+
+// TestNext tests that Next waits for a result, the context, or the end of
+// the output.
+func TestNext(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := tree.Next(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	ch := make(chan int, 1)
+	ch <- 5
+	tree.Add(ch)
+	if v, err := tree.Next(context.Background()); err != nil || v != 5 {
+		t.Errorf("Expected 5, got %d (%v)", v, err)
+	}
+	close(ch)
+	tree.Finish()
+	if _, err := tree.Next(context.Background()); !errors.Is(err, treeduction.ErrOutputClosed) {
+		t.Errorf("Expected %v, got %v", treeduction.ErrOutputClosed, err)
+	}
+}
+
+// TestTakeN tests taking the first results of a streaming tree before
+// aborting it.
+func TestTakeN(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithScan())
+	ch := make(chan int) // Never closed
+	tree.Add(ch)
+	go func() {
+		for i := 1; i <= 4; i++ {
+			ch <- i
+		}
+	}()
+
+	vs, err := tree.TakeN(context.Background(), 3)
+	if err != nil || !slices.Equal(vs, []int{1, 3, 6}) {
+		t.Errorf("Expected [1 3 6], got %v (%v)", vs, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	vs, err = tree.TakeN(ctx, 2)
+	if !errors.Is(err, context.DeadlineExceeded) || !slices.Equal(vs, []int{10}) {
+		t.Errorf("Expected [10] and %v, got %v (%v)", context.DeadlineExceeded, vs, err)
+	}
+
+	tree.Abort()
+	if vs, err := tree.TakeN(context.Background(), 1); !errors.Is(err, treeduction.ErrOutputClosed) || len(vs) != 0 {
+		t.Errorf("Expected no result and %v, got %v (%v)", treeduction.ErrOutputClosed, vs, err)
+	}
+}
+
+// TestOutputBatches tests that the results are sent in slices of n, the last
+// one once the output is closed.
+func TestOutputBatches(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithScan())
+	ch := make(chan int)
+	tree.Add(ch)
+	batches := tree.OutputBatches(2)
+	sent := make(chan struct{})
+	go func() {
+		for i := 1; i <= 5; i++ {
+			ch <- i
+		}
+		close(ch)
+		close(sent)
+	}()
+
+	var got [][]int
+	for len(got) < 2 {
+		got = append(got, <-batches)
+	}
+	// Finish stops reading the inputs of a streaming tree
+	<-sent
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	for b := range batches {
+		got = append(got, b)
+	}
+	want := [][]int{{1, 3}, {6, 10}, {15}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestOutputBatchesAbort tests that the batches nobody reads don't keep
+// their goroutine once the tree is aborted.
+func TestOutputBatchesAbort(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithScan())
+	batches := tree.OutputBatches(1)
+	tree.AddValues(1, 2, 3)
+	time.Sleep(10 * time.Millisecond)
+	tree.Abort()
+	select {
+	case <-tree.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected the tree to be done")
+	}
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-batches:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("Expected the batches to be closed")
+		}
+	}
+}
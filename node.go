@@ -0,0 +1,64 @@
+package treeduction
+
+import "context"
+
+// Node is the logic of a node of the tree, see WithNodeFactory.
+type Node[T any] interface {
+	// Run reads the values of the children of the node until they are all
+	// closed, and sends the values it passes on to the parent. It returns
+	// once its inputs are closed or ctx is done (the tree failed or was
+	// aborted), the tree then closes the output.
+	Run(ctx context.Context, io NodeIO[T])
+}
+
+// NodeIO is what a Node reads from and writes to.
+type NodeIO[T any] struct {
+	// Inputs are the children of the node.
+	Inputs []<-chan T
+	// Output is read by the parent of the node.
+	Output chan<- T
+	// Combine combines two values read from Inputs with the combiner of the
+	// tree, which accounts for them like its own nodes do.
+	Combine func(a, b T) T
+	// Drop tells the tree that a value read from Inputs won't be sent on,
+	// e.g. a duplicate.
+	Drop func(v T)
+}
+
+// WithNodeFactory runs the nodes of the tree with the Node returned by f for
+// their level (1 for the nodes combining the inputs), instead of the ones
+// combining their values in pairs, e.g. for nodes that drop duplicates,
+// compress or sample the values, with the shape, buffers and lifecycle of
+// the tree unchanged. Every value read from Inputs must be sent on Output,
+// combined or dropped, and a node mustn't hold a value once its inputs are
+// closed. It only applies to binary unordered trees without WithMaxWorkers,
+// and WithBatchSize and WithCombinerConcurrency have no effect on the nodes.
+func WithNodeFactory[T any](f func(level int) Node[T]) TypedOption[T] {
+	return func(o *options, ty *typed[T]) {
+		o.nodeFactory = true
+		ty.newNode = f
+	}
+}
+
+// customNode runs a Node made by the factory over two children.
+func (t *tree[T]) customNode(f <-chan T, s <-chan T, level int) <-chan T {
+	c := make(chan T, t.bufAt(level))
+	t.track(c, "custom", f, s)
+	closed := t.nodeCreated()
+	n := t.newNode(level)
+	t.spawn(func() {
+		n.Run(t.life, NodeIO[T]{
+			Inputs:  []<-chan T{f, s},
+			Output:  c,
+			Combine: t.combine,
+			Drop: func(v T) {
+				t.leave()
+				t.unhold(t.weight(v))
+			},
+		})
+		closed()
+		t.untrack(c)
+		close(c)
+	}, levelLabels(level)...)
+	return c
+}
@@ -0,0 +1,66 @@
+package treeduction_test
+
+import (
+	"context"
+	"testing"
+	"treeduction"
+)
+
+// dedup passes on the first of equal values and drops the others.
+type dedup struct{}
+
+func (dedup) Run(ctx context.Context, io treeduction.NodeIO[int]) {
+	seen := make(map[int]bool)
+	f, s := io.Inputs[0], io.Inputs[1]
+	for f != nil || s != nil {
+		var v int
+		var ok bool
+		select {
+		case v, ok = <-f:
+			if !ok {
+				f = nil
+				continue
+			}
+		case v, ok = <-s:
+			if !ok {
+				s = nil
+				continue
+			}
+		case <-ctx.Done():
+			return
+		}
+		if seen[v] {
+			io.Drop(v)
+			continue
+		}
+		seen[v] = true
+		select {
+		case io.Output <- v:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// TestNodeFactory tests that the nodes of the tree run the logic of the
+// factory.
+func TestNodeFactory(t *testing.T) {
+	var levels []int
+	tree := treeduction.With(treeduction.WithNodeFactory(func(level int) treeduction.Node[int] {
+		levels = append(levels, level)
+		return dedup{}
+	})).New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	tree.AddValues(1, 2, 3)
+	tree.AddValues(2, 3, 4)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 10 {
+		t.Errorf("Expected 10, got %d", v)
+	}
+	if len(levels) != 1 || levels[0] != 1 {
+		t.Errorf("Expected a node at level 1, got %v", levels)
+	}
+}
@@ -0,0 +1,60 @@
+package treeduction
+
+import "math"
+
+// NewSumInt64 is a tree that sums int64 values. It is a Fold with
+// WithLocalFold: every input is summed up in the goroutine reading it, and
+// only a partial per input (or per burst of values without waitForAll) goes
+// through the nodes of the tree, which still run on channels. Sums and
+// minimums and maximums don't depend on the order, so the tree is
+// unordered.
+func NewSumInt64(bufferSize int, waitForAll bool, opts ...Option) Tree[int64] {
+	return newNumeric(0, func(a, b int64) int64 { return a + b }, bufferSize, waitForAll, opts)
+}
+
+// NewSumUint64 is like NewSumInt64 for uint64 values.
+func NewSumUint64(bufferSize int, waitForAll bool, opts ...Option) Tree[uint64] {
+	return newNumeric(0, func(a, b uint64) uint64 { return a + b }, bufferSize, waitForAll, opts)
+}
+
+// NewSumFloat64 is like NewSumInt64 for float64 values. The rounding of the
+// sum depends on the way the values are split into partials.
+func NewSumFloat64(bufferSize int, waitForAll bool, opts ...Option) Tree[float64] {
+	return newNumeric(0, func(a, b float64) float64 { return a + b }, bufferSize, waitForAll, opts)
+}
+
+// NewMinInt64 is like NewSumInt64 for the minimum of int64 values.
+func NewMinInt64(bufferSize int, waitForAll bool, opts ...Option) Tree[int64] {
+	return newNumeric(math.MaxInt64, func(a, b int64) int64 { return min(a, b) }, bufferSize, waitForAll, opts)
+}
+
+// NewMinUint64 is like NewSumInt64 for the minimum of uint64 values.
+func NewMinUint64(bufferSize int, waitForAll bool, opts ...Option) Tree[uint64] {
+	return newNumeric(math.MaxUint64, func(a, b uint64) uint64 { return min(a, b) }, bufferSize, waitForAll, opts)
+}
+
+// NewMinFloat64 is like NewSumInt64 for the minimum of float64 values.
+func NewMinFloat64(bufferSize int, waitForAll bool, opts ...Option) Tree[float64] {
+	return newNumeric(math.Inf(1), func(a, b float64) float64 { return min(a, b) }, bufferSize, waitForAll, opts)
+}
+
+// NewMaxInt64 is like NewSumInt64 for the maximum of int64 values.
+func NewMaxInt64(bufferSize int, waitForAll bool, opts ...Option) Tree[int64] {
+	return newNumeric(math.MinInt64, func(a, b int64) int64 { return max(a, b) }, bufferSize, waitForAll, opts)
+}
+
+// NewMaxUint64 is like NewSumInt64 for the maximum of uint64 values.
+func NewMaxUint64(bufferSize int, waitForAll bool, opts ...Option) Tree[uint64] {
+	return newNumeric(0, func(a, b uint64) uint64 { return max(a, b) }, bufferSize, waitForAll, opts)
+}
+
+// NewMaxFloat64 is like NewSumInt64 for the maximum of float64 values.
+func NewMaxFloat64(bufferSize int, waitForAll bool, opts ...Option) Tree[float64] {
+	return newNumeric(math.Inf(-1), func(a, b float64) float64 { return max(a, b) }, bufferSize, waitForAll, opts)
+}
+
+// newNumeric folds the values of each input locally with combiner, whose
+// identity is init.
+func newNumeric[T any](init T, combiner func(T, T) T, bufferSize int, waitForAll bool, opts []Option) Tree[T] {
+	return Fold(init, combiner, combiner, bufferSize, waitForAll, false, append(opts, WithLocalFold())...)
+}
@@ -0,0 +1,84 @@
+package treeduction_test
+
+import (
+	"math"
+	"testing"
+	"treeduction"
+)
+
+func reduceAll[T any](tree treeduction.Tree[T], inputs ...[]T) T {
+	for _, in := range inputs {
+		tree.AddSlice(in)
+	}
+	tree.Finish()
+	return <-tree.Output()
+}
+
+// TestNumeric tests the trees of numbers.
+func TestNumeric(t *testing.T) {
+	if r := reduceAll(treeduction.NewSumInt64(10, true), []int64{1, 2}, []int64{3}, []int64{-4}); r != 2 {
+		t.Errorf("Expected the sum to be 2, got %d", r)
+	}
+	if r := reduceAll(treeduction.NewSumFloat64(10, true), []float64{0.5, 1}, []float64{2}); r != 3.5 {
+		t.Errorf("Expected the sum to be 3.5, got %v", r)
+	}
+	if r := reduceAll(treeduction.NewMinInt64(10, true), []int64{5, -3}, []int64{math.MaxInt64}); r != -3 {
+		t.Errorf("Expected the min to be -3, got %d", r)
+	}
+	if r := reduceAll(treeduction.NewMinUint64(10, true), []uint64{7, 9}, []uint64{8}); r != 7 {
+		t.Errorf("Expected the min to be 7, got %d", r)
+	}
+	if r := reduceAll(treeduction.NewMaxFloat64(10, true), []float64{-2, -1.5}, []float64{-3}); r != -1.5 {
+		t.Errorf("Expected the max to be -1.5, got %v", r)
+	}
+	if r := reduceAll(treeduction.NewMaxInt64(10, true), []int64{math.MinInt64}, []int64{-8, -9}); r != -8 {
+		t.Errorf("Expected the max to be -8, got %d", r)
+	}
+}
+
+// TestNumericChannels tests that the values of channels are all counted.
+func TestNumericChannels(t *testing.T) {
+	tree := treeduction.NewSumUint64(0, true)
+	for range 8 {
+		c := make(chan uint64)
+		tree.Add(c)
+		go func() {
+			defer close(c)
+			for range 1000 {
+				c <- 1
+			}
+		}()
+	}
+	tree.Finish()
+	if r := <-tree.Output(); r != 8000 {
+		t.Errorf("Expected the sum to be 8000, got %d", r)
+	}
+}
+
+func BenchmarkNumeric(b *testing.B) {
+	trees := map[string]func() treeduction.Tree[int64]{
+		"New": func() treeduction.Tree[int64] {
+			return treeduction.New(func(a, b int64) int64 { return a + b }, 16, true, false)
+		},
+		"NewSumInt64": func() treeduction.Tree[int64] {
+			return treeduction.NewSumInt64(16, true)
+		},
+	}
+	for name, newTree := range trees {
+		b.Run(name, func(b *testing.B) {
+			for range b.N {
+				tree := newTree()
+				for range 64 {
+					c := make(chan int64, 1000)
+					for i := range int64(1000) {
+						c <- i
+					}
+					close(c)
+					tree.Add(c)
+				}
+				tree.Finish()
+				<-tree.Output()
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package treeduction
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Option configures optional behaviour of a tree, see the With* functions.
+type Option func(*options)
+
+type options struct {
+	ctx context.Context
+
+	scan bool
+
+	windowSize int
+	windowStep int
+	timeWindow time.Duration
+
+	maxWorkers int
+	shared     *Pool
+
+	pairingTimeout time.Duration
+	combineTimeout time.Duration
+	timeoutPolicy  TimeoutPolicy
+	batchSize      int
+	batchIsolation bool
+	strategy       Strategy
+	adaptiveBatch  bool
+	maxInFlight    int
+	maxWeight      int64
+	faults         *FaultPolicy
+	dedupWindow    int
+	validation     float64
+	nodeFactory    bool
+	partitions     int
+	walDir         string
+	retry          Retry
+	localFold      bool
+	concurrency    int
+	parallelism    int
+	expected       int
+	expectedValues int64
+	sequential     bool
+	inline         bool
+	deterministic  bool
+	deferredStart  bool
+	localSize      int
+	localWorkers   int
+	rate           float64
+	sourceRate     float64
+
+	nodeBuffer   int
+	bufferPolicy func(level int) int
+	autoBuffer   bool
+	outputBuffer int
+	backpressure Backpressure
+	dropWhenFull bool
+	sourcePolicy SourcePolicy
+	duplicates   DuplicatePolicy
+
+	metrics Metrics
+	tracer  Tracer
+	name    string
+	logger  *slog.Logger
+
+	progress      func(consumed, produced int64)
+	progressEvery int64
+
+	leakGrace      time.Duration
+	strictGrace    time.Duration
+	strictFail     bool
+	profilerLabels bool
+
+	idle       time.Duration
+	idleFinish bool
+
+	heartbeat time.Duration
+}
+
+func newOptions(waitForAll bool, opts []Option) options {
+	o := options{nodeBuffer: -1, outputBuffer: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// With waitForAll only the final value is emitted, so there is nothing
+	// to scan or window
+	if waitForAll {
+		o.scan = false
+		o.windowSize, o.windowStep, o.timeWindow = 0, 0, 0
+		if o.faults != nil {
+			faults := *o.faults
+			faults.ReorderRate = 0
+			o.faults = &faults
+		}
+	}
+	return o
+}
+
+func (o options) parent() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
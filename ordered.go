@@ -0,0 +1,191 @@
+package treeduction
+
+import "time"
+
+// run is a balanced ordered subtree over size consecutive inputs. Runs are
+// kept from left (earliest) to right, and merged like a binary counter so
+// that there are only O(log n) of them no matter how the inputs were added.
+type run[T any] struct {
+	c     <-chan T
+	size  int
+	depth int
+}
+
+// roundState is what a stopped run collector hands back: the values of an
+// incomplete round (so that they aren't lost or reordered) and the runs that
+// were drained.
+type roundState[T any] struct {
+	held    []*T
+	drained []bool
+}
+
+// addOrdered builds a balanced ordered subtree out of one Add batch and
+// appends it to the right of everything that was added before.
+func (t *tree[T]) addOrdered(leaves []<-chan T) {
+	if len(leaves) == 0 {
+		return
+	}
+	t.detachRuns()
+
+	c, depth := t.buildOrdered(leaves)
+	r := run[T]{c: c, size: len(leaves), depth: depth}
+	for len(t.runs) > 0 && t.runs[len(t.runs)-1].size <= r.size {
+		prev := t.runs[len(t.runs)-1]
+		t.runs = t.runs[:len(t.runs)-1]
+		depth := max(prev.depth, r.depth) + 1
+		r = run[T]{
+			c:     t.orderedNode(prev.c, r.c, depth),
+			size:  prev.size + r.size,
+			depth: depth,
+		}
+	}
+	t.runs = append(t.runs, r)
+}
+
+// buildOrdered returns the root of a balanced subtree and its depth.
+func (t *tree[T]) buildOrdered(leaves []<-chan T) (<-chan T, int) {
+	if len(leaves) == 1 {
+		return leaves[0], 1
+	}
+	mid := len(leaves) / 2
+	f, fd := t.buildOrdered(leaves[:mid])
+	s, sd := t.buildOrdered(leaves[mid:])
+	d := max(fd, sd) + 1
+	return t.orderedNode(f, s, d), d
+}
+
+// foldRounds reads one value from every run that isn't drained, folds them
+// from left to right and passes the result to deliver, until all the runs
+// are drained or stop fires.
+func (t *tree[T]) foldRounds(runs []run[T], stop <-chan struct{}, deliver func(T) bool) roundState[T] {
+	st := roundState[T]{held: make([]*T, len(runs)), drained: make([]bool, len(runs))}
+	for {
+		live := false
+		// With a pairing timeout, the runs that are late skip the round
+		var expire <-chan time.Time
+		expired := false
+		for i, r := range runs {
+			if st.drained[i] {
+				continue
+			}
+			if expired {
+				// Only take what's already there
+				select {
+				case v, ok := <-r.c:
+					if !ok {
+						st.drained[i] = true
+						continue
+					}
+					st.held[i] = &v
+				default:
+				}
+				continue
+			}
+			select {
+			case v, ok := <-r.c:
+				if !ok {
+					st.drained[i] = true
+					continue
+				}
+				st.held[i] = &v
+				live = true
+				if expire == nil && t.opts.pairingTimeout > 0 {
+					expire = time.After(t.opts.pairingTimeout)
+				}
+			case <-expire:
+				expired = true
+			case <-stop:
+				return st
+			}
+		}
+		if !live {
+			return st
+		}
+
+		if t.opts.perValue() {
+			for i, v := range st.held {
+				if v == nil {
+					continue
+				}
+				if !deliver(*v) {
+					return st
+				}
+				st.held[i] = nil
+			}
+			continue
+		}
+
+		acc, _ := t.foldPadded(st.held)
+		if !deliver(acc) {
+			return st
+		}
+		clear(st.held)
+	}
+}
+
+// collectRuns forwards the rounds to the output until the next Add.
+func (t *tree[T]) collectRuns() {
+	done := make(chan roundState[T], 1)
+	t.runsDone = done
+
+	t.wg.Add(1)
+	runs, stop := t.runs, t.stop
+	t.spawn(func() {
+		defer t.wg.Done()
+		done <- t.foldRounds(runs, stop, func(v T) bool {
+			return t.deliver(v, stop)
+		})
+	})
+}
+
+// detachRuns waits for the run collector to stop, so that the runs can be
+// handed to new nodes without two readers racing on them.
+func (t *tree[T]) detachRuns() {
+	if t.runsDone == nil {
+		return
+	}
+	st := <-t.runsDone
+	t.runsDone = nil
+
+	runs := t.runs[:0]
+	for i, r := range t.runs {
+		switch {
+		case st.held[i] != nil:
+			r.c = t.prepend(*st.held[i], r.c)
+		case st.drained[i]:
+			// Nothing is left to pair with
+			continue
+		}
+		runs = append(runs, r)
+	}
+	t.runs = runs
+}
+
+func (t *tree[T]) prepend(v T, c <-chan T) <-chan T {
+	out := make(chan T, t.bufSize)
+	t.track(out, "prepend", c)
+	t.spawn(func() {
+		t.forward(out, v)
+		for v := range c {
+			t.forward(out, v)
+		}
+		t.untrack(out)
+		close(out)
+	})
+	return out
+}
+
+// finishOrdered folds the rounds in order, from left to right.
+func (t *tree[T]) finishOrdered() error {
+	t.foldRounds(t.runs, nil, func(v T) bool {
+		t.fold(v)
+		t.leave()
+		return true
+	})
+	t.epochs.Wait()
+	t.cancel()
+
+	t.emitFinal()
+	t.closeOutput()
+	return t.error()
+}
@@ -0,0 +1,56 @@
+package treeduction
+
+// WithPadding makes the ordered nodes combine a value whose sibling is
+// missing (its input closed earlier, or it is late, see WithPairingTimeout)
+// with identity in its place, instead of passing it through, so that the
+// combiner sees the same shape of combines whatever the lengths of the
+// inputs. It has no effect in unordered mode.
+func WithPadding[T any](identity T) TypedOption[T] {
+	return func(_ *options, ty *typed[T]) {
+		ty.padding = &identity
+	}
+}
+
+// padLeft returns a value of the right side of a node whose left sibling is
+// missing, combined with the identity if there is one.
+func (t *tree[T]) padLeft(v T) T {
+	if t.padding == nil || t.opts.perValue() {
+		return v
+	}
+	return t.combiner(*t.padding, v)
+}
+
+// padRight is padLeft for a value of the left side.
+func (t *tree[T]) padRight(v T) T {
+	if t.padding == nil || t.opts.perValue() {
+		return v
+	}
+	return t.combiner(v, *t.padding)
+}
+
+// foldPadded folds the values of a round in order, with the identity in the
+// place of the missing ones (nil) if there is one. Only the combines of two
+// values of the tree count as such.
+func (t *tree[T]) foldPadded(values []*T) (T, bool) {
+	var acc T
+	have, real := false, false
+	for _, v := range values {
+		counted := v != nil
+		if !counted {
+			if t.padding == nil || t.opts.perValue() {
+				continue
+			}
+			v = t.padding
+		}
+		switch {
+		case !have:
+			acc, have = *v, true
+		case real && counted:
+			acc = t.combine(acc, *v)
+		default:
+			acc = t.combiner(acc, *v)
+		}
+		real = real || counted
+	}
+	return acc, real
+}
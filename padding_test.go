@@ -0,0 +1,60 @@
+package treeduction_test
+
+import (
+	"slices"
+	"testing"
+	"treeduction"
+)
+
+// TestUnevenInputs tests that in ordered mode the values of the longer input
+// are passed through, or combined with the identity with WithPadding.
+func TestUnevenInputs(t *testing.T) {
+	concat := func(a, b string) string {
+		return a + b
+	}
+	feed := func(tree treeduction.Tree[string]) {
+		c1 := make(chan string, 3)
+		c2 := make(chan string, 3)
+		c1 <- "a"
+		c1 <- "b"
+		c1 <- "c"
+		c2 <- "x"
+		close(c1)
+		close(c2)
+		tree.Add(c1, c2)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		opts  []treeduction.Option
+		typed []treeduction.TypedOption[string]
+		want  string
+	}{
+		{"pass through", nil, nil, "axbc"},
+		{"padding", nil, []treeduction.TypedOption[string]{treeduction.WithPadding("_")}, "axb_c_"},
+		{"pool", []treeduction.Option{treeduction.WithMaxWorkers(1)}, nil, "axbc"},
+		{"pool with padding", []treeduction.Option{treeduction.WithMaxWorkers(1)}, []treeduction.TypedOption[string]{treeduction.WithPadding("_")}, "axb_c_"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := treeduction.With(tt.typed...).New(concat, 10, true, true, tt.opts...)
+			feed(tree)
+			tree.Finish()
+			if v := <-tree.Output(); v != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, v)
+			}
+		})
+	}
+
+	t.Run("streaming", func(t *testing.T) {
+		tree := treeduction.With(treeduction.WithPadding("_")).New(concat, 10, false, true)
+		defer tree.Finish()
+		feed(tree)
+		var got []string
+		for range 3 {
+			got = append(got, <-tree.Output())
+		}
+		if want := []string{"ax", "b_", "c_"}; !slices.Equal(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package treeduction
+
+import "time"
+
+// WithPairingTimeout stops an ordered node from waiting forever for a
+// starved side: a value that waited longer than d for its sibling is passed
+// on alone, and a round of the root waits at most d for the runs that are
+// late. A slow subtree then delays its values instead of wedging the branch,
+// but they aren't combined with the values of the same round anymore. It
+// doesn't apply to WithMaxWorkers, where nodes never wait.
+func WithPairingTimeout(d time.Duration) Option {
+	if d <= 0 {
+		panic("treeduction: pairing timeout must be positive")
+	}
+	return func(o *options) {
+		o.pairingTimeout = d
+	}
+}
+
+// pairOrTimeout runs an ordered node with a pairing timeout until both sides
+// are closed.
+func (t *tree[T]) pairOrTimeout(f, s <-chan T, c chan<- T, d time.Duration) {
+	timer := time.NewTimer(d)
+	timer.Stop()
+	for f != nil || s != nil {
+		var v1, v2 T
+		var have1, have2 bool
+		select {
+		case v, ok := <-f:
+			if !ok {
+				f = nil
+				continue
+			}
+			v1, have1 = v, true
+		case v, ok := <-s:
+			if !ok {
+				s = nil
+				continue
+			}
+			v2, have2 = v, true
+		}
+
+		// Wait for the sibling, if it may still send
+		timer.Reset(d)
+		switch {
+		case have1 && s != nil:
+			select {
+			case v, ok := <-s:
+				if ok {
+					v2, have2 = v, true
+				} else {
+					s = nil
+				}
+			case <-timer.C:
+			}
+		case have2 && f != nil:
+			select {
+			case v, ok := <-f:
+				if ok {
+					v1, have1 = v, true
+				} else {
+					f = nil
+				}
+			case <-timer.C:
+			}
+		}
+		timer.Stop()
+
+		switch {
+		case have1 && have2 && !t.opts.perValue():
+			t.put(c, t.combine(v1, v2))
+		case have1 && have2:
+			t.put(c, v1)
+			t.put(c, v2)
+		case have1:
+			t.put(c, t.padRight(v1))
+		default:
+			t.put(c, t.padLeft(v2))
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestPairingTimeout tests that a value isn't held back forever by a sibling
+// that never sends.
+func TestPairingTimeout(t *testing.T) {
+	tree := treeduction.New(func(a, b string) string {
+		return a + b
+	}, 10, false, true, treeduction.WithPairingTimeout(20*time.Millisecond))
+
+	c1 := make(chan string)
+	c2 := make(chan string)
+	tree.Add(c1, c2)
+	c1 <- "a"
+
+	select {
+	case v := <-tree.Output():
+		if v != "a" {
+			t.Errorf("Expected a, got %s", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The value was held back by its sibling")
+	}
+
+	// Values that arrive in time are still paired
+	c1 <- "b"
+	c2 <- "c"
+	if v := <-tree.Output(); v != "bc" {
+		t.Errorf("Expected bc, got %s", v)
+	}
+	close(c1)
+	close(c2)
+	tree.Finish()
+}
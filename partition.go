@@ -0,0 +1,59 @@
+package treeduction
+
+// WithOutputPartitioner puts every result on one of n output channels,
+// returned by Partitions, instead of the output: partition(v) modulo n picks
+// the channel of v, e.g. for consumers sharded by key. Each channel has the
+// buffer size of the output, and the tree waits for a consumer of a full one
+// like for a consumer of the output. The output is read by the tree and
+// closed when it finishes, it must not be read. It can't be used with
+// WithSink.
+func WithOutputPartitioner[T any](partition func(T) int, n int) TypedOption[T] {
+	if n <= 0 {
+		panic("treeduction: the number of partitions must be positive")
+	}
+	return func(o *options, ty *typed[T]) {
+		ty.partition = partition
+		o.partitions = n
+	}
+}
+
+// Partitions returns the output channels of WithOutputPartitioner, nil
+// without it. They are closed when the output would be.
+func (t *tree[T]) Partitions() []<-chan T {
+	if t.partitions == nil {
+		return nil
+	}
+	parts := make([]<-chan T, len(t.partitions))
+	for i, c := range t.partitions {
+		parts[i] = c
+	}
+	return parts
+}
+
+// startPartitions makes the partitions and routes the results of the
+// output to them.
+func (t *tree[T]) startPartitions(buffer int) {
+	parts := make([]chan T, t.opts.partitions)
+	for i := range parts {
+		parts[i] = make(chan T, buffer)
+	}
+	t.partitions = parts
+	t.partitioned = make(chan struct{})
+	out, done, life := t.output, t.partitioned, t.life
+	t.spawn(func() {
+		defer close(done)
+		for v := range out {
+			i := t.partition(v) % len(parts)
+			if i < 0 {
+				i += len(parts)
+			}
+			select {
+			case parts[i] <- v:
+			case <-life.Done():
+			}
+		}
+		for _, c := range parts {
+			close(c)
+		}
+	}, "role", "partitioner")
+}
@@ -0,0 +1,73 @@
+package treeduction_test
+
+import (
+	"sync"
+	"testing"
+	"treeduction"
+)
+
+// TestOutputPartitioner tests that every result goes to its partition.
+func TestOutputPartitioner(t *testing.T) {
+	tree := treeduction.With(treeduction.WithOutputPartitioner(func(v int) int {
+		return v
+	}, 3)).New(func(a, b int) int {
+		return a + b
+	}, 10, false, false)
+
+	parts := tree.Partitions()
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 partitions, got %d", len(parts))
+	}
+	var wg sync.WaitGroup
+	var once sync.Once
+	first := make(chan struct{})
+	got := make([][]int, len(parts))
+	for i, c := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range c {
+				got[i] = append(got[i], v)
+				once.Do(func() { close(first) })
+			}
+		}()
+	}
+
+	for _, v := range []int{3, 4, 5, -1} {
+		tree.AddValues(v)
+	}
+	// Finish doesn't wait for the values still inside a streaming tree
+	<-first
+	tree.Finish()
+	wg.Wait()
+	n := 0
+	for i, vs := range got {
+		for _, v := range vs {
+			if p := (v%3 + 3) % 3; p != i {
+				t.Errorf("Expected %d in partition %d, got it in %d", v, p, i)
+			}
+		}
+		n += len(vs)
+	}
+	if n == 0 {
+		t.Error("Expected results in the partitions")
+	}
+	if treeduction.New(func(a, b int) int { return a + b }, 10, false, false).Partitions() != nil {
+		t.Error("Expected no partitions without a partitioner")
+	}
+}
+
+// TestOutputPartitionerAbort tests that Abort doesn't wait for the
+// consumers of the partitions.
+func TestOutputPartitionerAbort(t *testing.T) {
+	tree := treeduction.With(treeduction.WithOutputPartitioner(func(v int) int {
+		return v
+	}, 2)).New(func(a, b int) int {
+		return a + b
+	}, 0, false, false)
+	c := make(chan int) // Never closed
+	tree.Add(c)
+	c <- 1
+	c <- 2
+	tree.Abort()
+}
@@ -0,0 +1,42 @@
+package treeduction
+
+// Pause stops reading the inputs until Resume, which applies backpressure to
+// the producers without tearing the tree down. A value per input may still
+// be read after Pause returns. The values already inside the tree are still
+// reduced and emitted: in unordered mode a node passes a value on alone
+// rather than holding it until its pair is read. Finish resumes the tree,
+// since it can't finish otherwise.
+func (t *tree[T]) Pause() {
+	t.pauseMu.Lock()
+	defer t.pauseMu.Unlock()
+	if t.resume.Load() == nil {
+		c := make(chan struct{})
+		t.resume.Store(&c)
+		close(*t.pause.Load())
+	}
+}
+
+// Resume starts reading the inputs again after Pause.
+func (t *tree[T]) Resume() {
+	t.pauseMu.Lock()
+	defer t.pauseMu.Unlock()
+	if c := t.resume.Swap(nil); c != nil {
+		p := make(chan struct{})
+		t.pause.Store(&p)
+		close(*c)
+	}
+}
+
+// paused returns a channel that is closed on Resume, or nil if the tree
+// isn't paused.
+func (t *tree[T]) paused() <-chan struct{} {
+	if c := t.resume.Load(); c != nil {
+		return *c
+	}
+	return nil
+}
+
+// pausing returns a channel that is closed on Pause.
+func (t *tree[T]) pausing() <-chan struct{} {
+	return *t.pause.Load()
+}
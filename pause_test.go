@@ -0,0 +1,54 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestPause tests that a paused tree leaves the values in the inputs until
+// it is resumed.
+func TestPause(t *testing.T) {
+	for _, workers := range []int{0, 2} {
+		var opts []treeduction.Option
+		if workers > 0 {
+			opts = append(opts, treeduction.WithMaxWorkers(workers))
+		}
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false, opts...)
+
+		c := make(chan int, 10)
+		tree.Add(c)
+		tree.Pause()
+		for i := range 5 {
+			c <- i + 1
+		}
+		time.Sleep(20 * time.Millisecond)
+		// The reader may have been waiting for a value already
+		if len(c) < 4 {
+			t.Errorf("Expected the values to be left in the input, %d are left", len(c))
+		}
+
+		tree.Resume()
+		close(c)
+		tree.Finish()
+		if v := <-tree.Output(); v != 15 {
+			t.Errorf("Expected 15, got %d", v)
+		}
+	}
+}
+
+// TestPauseFinish tests that Finish doesn't wait for a paused tree forever.
+func TestPauseFinish(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	tree.Pause()
+	tree.AddValues(1, 2, 3)
+	tree.Finish()
+	if v := <-tree.Output(); v != 6 {
+		t.Errorf("Expected 6, got %d", v)
+	}
+}
@@ -0,0 +1,158 @@
+// Package pipe builds map and filter stages in front of a reduction tree, so
+// that the tree owns the whole pipeline: the stages stop with it, and a
+// failing stage fails it.
+package pipe
+
+import (
+	"context"
+	"iter"
+
+	"treeduction"
+)
+
+// Pipe is a pipeline over a set of sources. The stages only run once Reduce
+// is called, one goroutine per source.
+type Pipe[T any] struct {
+	ctx  context.Context
+	seqs []func(s *state) iter.Seq[T]
+}
+
+// state is shared by the stages of a running pipeline.
+type state struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+// Source starts a pipeline over the given channels.
+func Source[T any](chs ...<-chan T) Pipe[T] {
+	p := Pipe[T]{ctx: context.Background()}
+	for _, ch := range chs {
+		p.seqs = append(p.seqs, func(s *state) iter.Seq[T] {
+			return func(yield func(T) bool) {
+				for {
+					select {
+					case v, ok := <-ch:
+						if !ok || !yield(v) {
+							return
+						}
+					case <-s.ctx.Done():
+						return
+					}
+				}
+			}
+		})
+	}
+	return p
+}
+
+// WithContext runs the pipeline under ctx: cancelling it stops the stages
+// and the tree.
+func (p Pipe[T]) WithContext(ctx context.Context) Pipe[T] {
+	p.ctx = ctx
+	return p
+}
+
+// Map transforms every value of the pipeline with f.
+func (p Pipe[T]) Map(f func(T) T) Pipe[T] {
+	return Map(p, f)
+}
+
+// Filter drops the values for which keep returns false.
+func (p Pipe[T]) Filter(keep func(T) bool) Pipe[T] {
+	return stage(p, func(v T) (T, bool, error) {
+		return v, keep(v), nil
+	})
+}
+
+// Map transforms every value of the pipeline with f, which may change its
+// type.
+func Map[T, U any](p Pipe[T], f func(T) U) Pipe[U] {
+	return stage(p, func(v T) (U, bool, error) {
+		return f(v), true, nil
+	})
+}
+
+// TryMap is like Map, but f can fail. The first error stops the pipeline and
+// is reported by the tree, like an error of its combiner.
+func TryMap[T, U any](p Pipe[T], f func(T) (U, error)) Pipe[U] {
+	return stage(p, func(v T) (U, bool, error) {
+		u, err := f(v)
+		return u, true, err
+	})
+}
+
+func stage[T, U any](p Pipe[T], f func(T) (U, bool, error)) Pipe[U] {
+	q := Pipe[U]{ctx: p.ctx}
+	for _, seq := range p.seqs {
+		q.seqs = append(q.seqs, func(s *state) iter.Seq[U] {
+			return func(yield func(U) bool) {
+				for v := range seq(s) {
+					u, keep, err := f(v)
+					if err != nil {
+						s.cancel(err)
+						return
+					}
+					if keep && !yield(u) {
+						return
+					}
+				}
+			}
+		})
+	}
+	return q
+}
+
+// Reduce runs the pipeline into a new tree, see treeduction.New. The context
+// of the tree is set by the pipeline, see WithContext.
+func (p Pipe[T]) Reduce(combiner func(f T, s T) T, bufferSize int, waitForAll bool, ordered bool, opts ...treeduction.Option) treeduction.Tree[T] {
+	ctx, cancel := context.WithCancelCause(p.ctx)
+	s := &state{ctx: ctx, cancel: cancel}
+
+	t := treeduction.New(combiner, bufferSize, waitForAll, ordered, append(opts, treeduction.WithContext(ctx))...)
+	seqs := make([]iter.Seq[T], len(p.seqs))
+	for i, seq := range p.seqs {
+		seqs[i] = seq(s)
+	}
+	t.AddSeq(seqs...)
+	return &tree[T]{Tree: t, cancel: cancel}
+}
+
+// tree stops the stages once the tree is finished.
+type tree[T any] struct {
+	treeduction.Tree[T]
+	cancel context.CancelCauseFunc
+}
+
+func (t *tree[T]) Finish() error {
+	defer t.cancel(nil)
+	return t.Tree.Finish()
+}
+
+func (t *tree[T]) FinishContext(ctx context.Context) error {
+	defer t.cancel(nil)
+	return t.Tree.FinishContext(ctx)
+}
+
+func (t *tree[T]) Abort() error {
+	defer t.cancel(nil)
+	return t.Tree.Abort()
+}
+
+func (t *tree[T]) Results() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		defer t.cancel(nil)
+		t.Tree.Results()(yield)
+	}
+}
+
+func (t *tree[T]) Results2() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		defer t.cancel(nil)
+		t.Tree.Results2()(yield)
+	}
+}
+
+// Reset fails, since the stages of a pipeline only run once.
+func (t *tree[T]) Reset() error {
+	return treeduction.ErrFinished
+}
@@ -0,0 +1,62 @@
+package pipe_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"treeduction/pipe"
+)
+
+func source(vs ...int) <-chan int {
+	ch := make(chan int, len(vs))
+	for _, v := range vs {
+		ch <- v
+	}
+	close(ch)
+	return ch
+}
+
+// TestPipe tests a map and filter pipeline in front of a sum.
+func TestPipe(t *testing.T) {
+	tree := pipe.Source(source(1, 2, 3), source(4, 5, 6)).
+		Filter(func(v int) bool { return v%2 == 0 }).
+		Map(func(v int) int { return v * 10 }).
+		Reduce(func(a, b int) int { return a + b }, 10, true, false)
+
+	if err := tree.Finish(); err != nil {
+		t.Errorf("Unexpected error from Finish(): %v", err)
+	}
+	if result := <-tree.Output(); result != 120 {
+		t.Errorf("Expected result to be 120, got %d", result)
+	}
+}
+
+// TestPipeMapType tests a stage that changes the type of the values.
+func TestPipeMapType(t *testing.T) {
+	p := pipe.Map(pipe.Source(source(1, 2), source(3)), strconv.Itoa)
+	tree := p.Reduce(func(a, b string) string { return a + b }, 10, true, true)
+
+	var results []string
+	for v := range tree.Results() {
+		results = append(results, v)
+	}
+	if len(results) != 1 || results[0] != "132" {
+		t.Errorf("Expected results to be [132], got %v", results)
+	}
+}
+
+// TestPipeError tests that a failing stage fails the tree.
+func TestPipeError(t *testing.T) {
+	errBad := errors.New("bad value")
+	p := pipe.TryMap(pipe.Source(source(1, 2, 3)), func(v int) (int, error) {
+		if v == 2 {
+			return 0, errBad
+		}
+		return v, nil
+	})
+	tree := p.Reduce(func(a, b int) int { return a + b }, 10, true, false)
+
+	if err := tree.Finish(); !errors.Is(err, errBad) {
+		t.Errorf("Expected %v from Finish(), got %v", errBad, err)
+	}
+}
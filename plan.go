@@ -0,0 +1,274 @@
+package treeduction
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// TreePlan is the shape a tree would take, see Plan.
+type TreePlan struct {
+	// Inputs is the number of inputs.
+	Inputs int
+	// Nodes is the number of combining nodes, like Stats.Nodes.
+	Nodes int
+	// Depth is the number of levels of the tree, like Stats.Depth.
+	Depth int
+	// Goroutines is the number of goroutines run by the tree while its
+	// inputs are open, like Stats.Goroutines.
+	Goroutines int
+	// Buffers is the number of values the channels of the tree hold once
+	// they are full, the output included.
+	Buffers int
+	// Memory is an estimate in bytes of the memory taken by the tree with
+	// its buffers full: the values in the buffers (not what they point to),
+	// the channels, and a stack of 8 KiB per goroutine.
+	Memory int64
+}
+
+const (
+	// What a goroutine and a channel of the tree take, roughly
+	goroutineBytes = 8 << 10
+	channelBytes   = 96
+)
+
+// Plan returns the shape a tree created by New with the same arguments would
+// take once every call to Add in inputCounts (the number of channels of
+// each) returned, without creating it, e.g. for capacity planning. The
+// shape is the one of the tree before any input is closed or removed.
+// The goroutines of WithSink, WithOutputPartitioner and the producers of
+// Go and AddSeq aren't counted. With WithMaxWorkers, only the workers and
+// the goroutines reading the inputs are.
+func Plan[T any](inputCounts []int, bufferSize int, waitForAll bool, ordered bool, opts ...Option) TreePlan {
+	return Typed[T]{}.Plan(inputCounts, bufferSize, waitForAll, ordered, opts...)
+}
+
+// Plan is Plan for a tree created with the typed options.
+func (w Typed[T]) Plan(inputCounts []int, bufferSize int, waitForAll bool, ordered bool, opts ...Option) TreePlan {
+	if bufferSize < 0 {
+		panic("treeduction: buffer size can't be negative")
+	}
+	o := newOptions(waitForAll, opts)
+	applyTyped(&o, w.opts)
+	nodeBuffer, outputBuffer := bufferSize, bufferSize
+	if o.nodeBuffer >= 0 {
+		nodeBuffer = o.nodeBuffer
+	}
+	if o.outputBuffer >= 0 {
+		outputBuffer = o.outputBuffer
+	}
+
+	p := &planner{opts: o, bufSize: nodeBuffer}
+	p.Buffers = outputBuffer
+	p.channels = 1
+	switch {
+	case o.pooled():
+		p.pooled(inputCounts, ordered)
+	case ordered:
+		p.ordered(inputCounts, waitForAll)
+	default:
+		p.unordered(inputCounts)
+	}
+	if o.needsEmitter() {
+		p.Goroutines++
+		p.channel(nodeBuffer)
+	}
+	if o.idle > 0 {
+		p.Goroutines++
+	}
+	if o.heartbeat > 0 && !waitForAll {
+		p.Goroutines++
+	}
+
+	var zero T
+	p.Memory = int64(p.Buffers)*int64(unsafe.Sizeof(zero)) + int64(p.channels)*channelBytes + int64(p.Goroutines)*goroutineBytes
+	return p.TreePlan
+}
+
+// planner adds up the plan of a tree.
+type planner struct {
+	TreePlan
+	opts     options
+	bufSize  int
+	channels int
+}
+
+// channel counts a channel of the tree with the given buffer.
+func (p *planner) channel(size int) {
+	p.channels++
+	p.Buffers += size
+}
+
+// bufAt is tree.bufAt for the tree being planned.
+func (p *planner) bufAt(level int) int {
+	if p.opts.bufferPolicy == nil {
+		return p.bufSize
+	}
+	return max(p.opts.bufferPolicy(level), 0)
+}
+
+// unordered follows addOne and addChild.
+func (p *planner) unordered(inputCounts []int) {
+	arity := p.opts.arity()
+	if p.opts.sequential {
+		arity = 0
+	}
+	concurrency := max(p.opts.concurrency, 1)
+
+	var roots []bool
+	var open []int
+	var addOne func(level int)
+	addOne = func(level int) {
+		for len(roots) <= level {
+			roots = append(roots, false)
+			open = append(open, 0)
+		}
+		if arity != 2 {
+			if open[level] == 0 {
+				p.Nodes++
+				p.Goroutines += concurrency
+				p.channel(p.bufAt(level + 1))
+				p.channel(p.bufAt(level + 1))
+				roots[level] = true
+			}
+			open[level]++
+			p.Goroutines++
+			if arity > 0 && open[level] == arity {
+				// Sealed, and added to the next level
+				p.Goroutines++
+				open[level], roots[level] = 0, false
+				addOne(level + 1)
+			}
+			return
+		}
+		if !roots[level] {
+			roots[level] = true
+			return
+		}
+		roots[level] = false
+		if p.opts.lockstep() {
+			p.orderedNode(level + 1)
+			addOne(level + 1)
+			return
+		}
+		p.Nodes++
+		if p.opts.nodeFactory {
+			p.Goroutines++
+		} else {
+			p.Goroutines += 1 + concurrency
+		}
+		p.channel(p.bufAt(level + 1))
+		p.channel(p.bufAt(level + 1))
+		addOne(level + 1)
+	}
+
+	if size := p.opts.localSize; size > 0 {
+		// The inputs are read by the workers of their groups, a leaf each
+		total := 0
+		for _, n := range inputCounts {
+			total += n
+		}
+		p.Inputs = total
+		for range min((total+size-1)/size, p.opts.localWorkers) {
+			p.Goroutines++
+			p.channel(p.bufAt(0))
+			addOne(0)
+		}
+	} else {
+		for _, n := range inputCounts {
+			for range n {
+				p.input()
+				addOne(0)
+			}
+		}
+	}
+	collectors := 0
+	for i, r := range roots {
+		if r {
+			collectors++
+			p.Depth = i + 1
+		}
+	}
+	// A collector per root, or one for them all
+	if p.opts.lockstep() {
+		collectors = min(collectors, 1)
+	}
+	p.Goroutines += collectors
+}
+
+// ordered follows addOrdered.
+func (p *planner) ordered(inputCounts []int, waitForAll bool) {
+	type run struct{ size, depth int }
+	var runs []run
+	for _, n := range inputCounts {
+		if n == 0 {
+			continue
+		}
+		for range n {
+			p.input()
+		}
+		r := run{size: n, depth: bits.Len(uint(n-1)) + 1}
+		p.orderedNodes(n)
+		for len(runs) > 0 && runs[len(runs)-1].size <= r.size {
+			prev := runs[len(runs)-1]
+			runs = runs[:len(runs)-1]
+			r = run{size: prev.size + r.size, depth: max(prev.depth, r.depth) + 1}
+			p.orderedNode(r.depth)
+		}
+		runs = append(runs, r)
+	}
+	for _, r := range runs {
+		p.Depth = max(p.Depth, r.depth)
+	}
+	// The collector of the runs
+	if !waitForAll && len(runs) > 0 {
+		p.Goroutines++
+	}
+}
+
+// orderedNodes counts the nodes of buildOrdered over n leaves, and returns
+// their depth.
+func (p *planner) orderedNodes(n int) int {
+	if n == 1 {
+		return 1
+	}
+	d := max(p.orderedNodes(n/2), p.orderedNodes(n-n/2)) + 1
+	p.orderedNode(d)
+	return d
+}
+
+func (p *planner) orderedNode(level int) {
+	p.Nodes++
+	p.Goroutines++
+	p.channel(p.bufAt(level))
+}
+
+// pooled counts the workers and the readers of the inputs, and the nodes
+// the values are queued in.
+func (p *planner) pooled(inputCounts []int, ordered bool) {
+	if p.opts.shared != nil {
+		p.Goroutines++
+	} else {
+		p.Goroutines += p.opts.maxWorkers
+	}
+	total := 0
+	for _, n := range inputCounts {
+		total += n
+		if ordered && n > 0 {
+			p.Nodes += n - 1
+			p.Depth = max(p.Depth, bits.Len(uint(n-1))+1)
+		}
+	}
+	p.Inputs = total
+	p.Goroutines += (total + groupSize - 1) / groupSize
+	if !ordered {
+		p.Nodes = total - bits.OnesCount(uint(total))
+		p.Depth = bits.Len(uint(total))
+	}
+}
+
+// input counts an input, with the goroutine reading it and its leaf.
+func (p *planner) input() {
+	p.Inputs++
+	p.Goroutines++
+	p.channel(p.bufAt(0))
+}
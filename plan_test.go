@@ -0,0 +1,88 @@
+package treeduction_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestPlan tests that the plan of a tree matches the tree once its inputs
+// are added.
+func TestPlan(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		waitForAll bool
+		ordered    bool
+		opts       []treeduction.Option
+		typed      []treeduction.TypedOption[int]
+	}{
+		{"binary", false, false, nil, nil},
+		{"binary waitForAll", true, false, nil, nil},
+		{"3-ary", false, false, []treeduction.Option{treeduction.WithStrategy(treeduction.KAry(3))}, nil},
+		{"flat", false, false, []treeduction.Option{treeduction.WithStrategy(treeduction.Flat)}, nil},
+		{"concurrency", false, false, []treeduction.Option{treeduction.WithCombinerConcurrency(2)}, nil},
+		{"scan", false, false, []treeduction.Option{treeduction.WithScan()}, nil},
+		{"buffer policy", false, false, []treeduction.Option{treeduction.WithBufferPolicy(func(level int) int {
+			return 8 >> level
+		})}, nil},
+		{"ordered", false, true, nil, nil},
+		{"ordered waitForAll", true, true, nil, nil},
+		{"workers", false, false, []treeduction.Option{treeduction.WithMaxWorkers(4)}, nil},
+		{"node factory", false, false, nil, []treeduction.TypedOption[int]{treeduction.WithNodeFactory(func(int) treeduction.Node[int] {
+			return dedup{}
+		})}},
+		{"local groups", false, false, []treeduction.Option{treeduction.WithLocalGroups(2, 3)}, nil},
+		{"deterministic", false, false, []treeduction.Option{treeduction.WithDeterministicOrder()}, nil},
+	} {
+		for _, counts := range [][]int{{1}, {5}, {3, 4, 1}, {1500}} {
+			t.Run(fmt.Sprintf("%s %v", tt.name, counts), func(t *testing.T) {
+				w := treeduction.With(tt.typed...)
+				plan := w.Plan(counts, 4, tt.waitForAll, tt.ordered, tt.opts...)
+
+				tree := w.New(func(a, b int) int {
+					return a + b
+				}, 4, tt.waitForAll, tt.ordered, tt.opts...)
+				var inputs []chan int
+				for _, n := range counts {
+					chans := make([]<-chan int, n)
+					for i := range chans {
+						c := make(chan int)
+						inputs = append(inputs, c)
+						chans[i] = c
+					}
+					tree.Add(chans...)
+				}
+				defer func() {
+					for _, c := range inputs {
+						close(c)
+					}
+					tree.Finish()
+				}()
+
+				if plan.Inputs != len(inputs) {
+					t.Errorf("Expected %d inputs, got %d", len(inputs), plan.Inputs)
+				}
+				// The collectors replaced by Add take a moment to exit
+				var s treeduction.Stats[int]
+				for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+					if s = tree.Stats(); s.Goroutines == plan.Goroutines {
+						break
+					}
+				}
+				if s.Goroutines != plan.Goroutines {
+					t.Errorf("Expected %d goroutines, got %d", plan.Goroutines, s.Goroutines)
+				}
+				if s.Depth != plan.Depth {
+					t.Errorf("Expected a depth of %d, got %d", plan.Depth, s.Depth)
+				}
+				if tt.opts == nil && s.Nodes != plan.Nodes {
+					t.Errorf("Expected %d nodes, got %d", plan.Nodes, s.Nodes)
+				}
+				if plan.Memory <= 0 {
+					t.Errorf("Expected a memory estimate, got %d", plan.Memory)
+				}
+			})
+		}
+	}
+}
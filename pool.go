@@ -0,0 +1,623 @@
+package treeduction
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+// WithMaxWorkers caps the number of goroutines a tree uses, for trees with a
+// very large fan-in. Instead of a goroutine per input and per node, a reader
+// goroutine per 64 inputs hands the values to n workers, which run the
+// combines of the nodes the values pass through.
+func WithMaxWorkers(n int) Option {
+	if n <= 0 {
+		panic("treeduction: number of workers must be positive")
+	}
+	return func(o *options) {
+		o.maxWorkers = n
+	}
+}
+
+// pooled reports whether the tree runs on workers instead of a goroutine per
+// node.
+func (o options) pooled() bool {
+	return o.maxWorkers > 0 || o.shared != nil
+}
+
+type pkind int
+
+const (
+	pleaf pkind = iota
+	punordered
+	pordered
+	prounds
+)
+
+// pnode is a node of a pooled tree. It doesn't own a goroutine: values are
+// pushed into it by whichever worker read them, and pushed on to the parent
+// while holding the lock, so that a parent never sees them out of order.
+type pnode[T any] struct {
+	t      *tree[T]
+	kind   pkind
+	mu     sync.Mutex
+	parent *pnode[T]
+	side   int
+	open   int
+
+	// Unordered nodes hold at most one value waiting for a pair
+	held *T
+
+	// Ordered nodes queue values per side (0 and 1). The rounds node is the
+	// root of an ordered tree and has a side per run, in run order. The
+	// queues aren't bounded: a side grows while its sibling falls behind,
+	// and with waitForAll the rounds node holds every round until Finish.
+	queues map[int][]T
+	closed map[int]bool
+	order  []int
+	hold   bool
+
+	// Called once a combining node closes
+	done func()
+}
+
+func (t *tree[T]) newPnode(kind pkind, open int) *pnode[T] {
+	n := &pnode[T]{t: t, kind: kind, open: open}
+	if kind != pleaf {
+		t.pool.mu.Lock()
+		t.pool.live[n] = struct{}{}
+		t.pool.mu.Unlock()
+	}
+	if kind == punordered || kind == pordered {
+		n.done = t.nodeCreated()
+	}
+	if kind == pordered || kind == prounds {
+		n.queues = make(map[int][]T)
+		n.closed = make(map[int]bool)
+	}
+	if kind == pordered {
+		n.order = []int{0, 1}
+	}
+	return n
+}
+
+func (n *pnode[T]) push(side int, v T) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch n.kind {
+	case pleaf:
+		n.forward(v)
+	case punordered:
+		if n.t.opts.perValue() {
+			n.forward(v)
+			return
+		}
+		if n.held == nil {
+			n.held = &v
+			return
+		}
+		f := *n.held
+		n.held = nil
+		n.forward(n.t.combine(f, v))
+	default:
+		n.queues[side] = append(n.queues[side], v)
+		n.flushRounds(false)
+	}
+}
+
+// closeSide is called when one of the children won't push anymore.
+func (n *pnode[T]) closeSide(side int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.open--
+	switch n.kind {
+	case punordered:
+		if n.open == 0 && n.held != nil {
+			n.forward(*n.held)
+			n.held = nil
+		}
+	case pordered, prounds:
+		n.closed[side] = true
+		n.flushRounds(false)
+	}
+
+	if n.open == 0 && (n.kind == punordered || n.kind == pordered) {
+		n.release()
+	}
+	if n.open == 0 && n.parent != nil {
+		n.parent.closeSide(n.side)
+	}
+}
+
+// release drops a closed node from the stats.
+func (n *pnode[T]) release() {
+	n.done()
+	n.t.pool.mu.Lock()
+	defer n.t.pool.mu.Unlock()
+	delete(n.t.pool.live, n)
+}
+
+// flushRounds folds one value of every side from left to right, as long as
+// every side either has a value or is closed for good. With force, the sides
+// that are still open are skipped as well.
+func (n *pnode[T]) flushRounds(force bool) {
+	if n.hold && !force {
+		return
+	}
+	for {
+		values := make([]*T, len(n.order))
+		for i, side := range n.order {
+			q := n.queues[side]
+			if len(q) == 0 {
+				if !n.closed[side] && !force {
+					return
+				}
+				continue
+			}
+			values[i] = &q[0]
+		}
+		acc, have := n.t.foldPadded(values)
+		if !have {
+			return
+		}
+
+		for _, side := range n.order {
+			if q := n.queues[side]; len(q) > 0 {
+				n.queues[side] = q[1:]
+				if n.t.opts.perValue() {
+					n.forward(q[0])
+				}
+			}
+		}
+		if !n.t.opts.perValue() {
+			n.forward(acc)
+		}
+	}
+}
+
+func (n *pnode[T]) forward(v T) {
+	if n.parent != nil {
+		n.parent.push(n.side, v)
+		return
+	}
+	n.t.pooledRoot(v)
+}
+
+// adopt makes n the child of parent on the given side. It reports false if n
+// is already closed, in which case it is not adopted.
+func (n *pnode[T]) adopt(parent *pnode[T], side int) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.open == 0 {
+		return false
+	}
+	n.parent, n.side = parent, side
+	return true
+}
+
+// groupSize is the number of inputs a reader goroutine selects over.
+const groupSize = 64
+
+type pinput[T any] struct {
+	in   <-chan T
+	leaf *pnode[T]
+	src  *source
+}
+
+// pread is an input inside its reader group. It has at most one value in
+// flight, so that its leaf gets the values in order.
+type pread[T any] struct {
+	pinput[T]
+	busy    bool
+	removed bool
+	// When the reader was ready to read a value again
+	armed time.Time
+}
+
+// pwork is a value read from an input for a worker to push, or with done
+// the end of the input.
+type pwork[T any] struct {
+	r    *pread[T]
+	g    *pgroup[T]
+	v    T
+	done bool
+}
+
+// pgroup is a reader goroutine and the number of inputs it selects over.
+type pgroup[T any] struct {
+	add   chan pinput[T]
+	rearm chan *pread[T]
+	n     int
+}
+
+type prun[T any] struct {
+	n     *pnode[T]
+	id    int
+	size  int
+	depth int
+}
+
+type pool[T any] struct {
+	queue  chan pwork[T]
+	leaves sync.WaitGroup
+
+	// Guards the groups and the nodes that are still open, the queue is
+	// closed once cancelled and every reader is gone
+	mu      sync.Mutex
+	groups  []*pgroup[T]
+	readers int
+	stopped bool
+	live    map[*pnode[T]]struct{}
+
+	roots  []*pnode[T]
+	runs   []prun[T]
+	rounds *pnode[T]
+	nextID int
+}
+
+func (t *tree[T]) startPool(n int) {
+	p := &pool[T]{queue: make(chan pwork[T]), live: make(map[*pnode[T]]struct{})}
+	t.pool = p
+	if t.ordered {
+		p.rounds = t.newPnode(prounds, 0)
+		p.rounds.hold = t.waitForAll
+	}
+
+	if shared := t.opts.shared; shared != nil {
+		t.wg.Add(1)
+		t.spawn(func() {
+			t.dispatch(shared)
+		})
+	} else {
+		for range n {
+			t.wg.Add(1)
+			t.spawn(t.runWorker, "role", "worker")
+		}
+	}
+	context.AfterFunc(t.ctx, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.stopped = true
+		if p.readers == 0 {
+			close(p.queue)
+		}
+	})
+}
+
+// runWorker pushes the values read by the readers into their leaves, until
+// the tree is cancelled and every reader is gone.
+func (t *tree[T]) runWorker() {
+	defer t.wg.Done()
+
+	for w := range t.pool.queue {
+		t.waitResumed(w)
+		t.work(w)
+	}
+}
+
+// waitResumed holds a value while the tree is paused, the readers can't
+// read past it.
+func (t *tree[T]) waitResumed(w pwork[T]) {
+	if resume := t.paused(); resume != nil && !w.done {
+		select {
+		case <-resume:
+		case <-t.ctx.Done():
+		}
+	}
+}
+
+func (t *tree[T]) work(w pwork[T]) {
+	if !w.done {
+		w.r.leaf.push(0, w.v)
+		w.g.rearm <- w.r
+		return
+	}
+	if w.r.removed {
+		t.flushPooled(w.r.pinput)
+	}
+	w.r.leaf.closeSide(0)
+	t.forget(w.r.in, w.r.src)
+	t.pool.leaves.Done()
+}
+
+// group returns a reader with room for one more input, starting a new one
+// if they are all full. It returns nil once the tree is cancelled.
+func (t *tree[T]) group() *pgroup[T] {
+	p := t.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopped {
+		return nil
+	}
+	for _, g := range p.groups {
+		if g.n < groupSize {
+			g.n++
+			return g
+		}
+	}
+	g := &pgroup[T]{
+		add:   make(chan pinput[T]),
+		rearm: make(chan *pread[T], groupSize),
+		n:     1,
+	}
+	p.groups = append(p.groups, g)
+	p.readers++
+	t.spawn(func() { t.runReader(g) }, "role", "reader")
+	return g
+}
+
+// runReader selects over the inputs of a group and queues what it reads for
+// the workers, until the tree is cancelled.
+func (t *tree[T]) runReader(g *pgroup[T]) {
+	p := t.pool
+	defer func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.readers--
+		if p.stopped && p.readers == 0 {
+			close(p.queue)
+		}
+	}()
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(t.ctx.Done())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(g.add)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(g.rearm)},
+	}
+	var inputs []*pread[T]
+	end := func(k int) {
+		p.queue <- pwork[T]{r: inputs[k], done: true}
+		inputs = slices.Delete(inputs, k, k+1)
+		cases = slices.Delete(cases, 3+2*k, 5+2*k)
+		p.mu.Lock()
+		g.n--
+		p.mu.Unlock()
+	}
+
+	for {
+		i, v, ok := reflect.Select(cases)
+		switch {
+		case i == 0:
+			// The values in flight are pushed before the leaves close
+			for _, r := range inputs {
+				for r.busy {
+					(<-g.rearm).busy = false
+				}
+			}
+			for _, r := range inputs {
+				p.queue <- pwork[T]{r: r, done: true}
+			}
+			return
+		case i == 1:
+			r := &pread[T]{pinput: v.Interface().(pinput[T]), armed: time.Now()}
+			inputs = append(inputs, r)
+			cases = append(cases,
+				reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.in)},
+				reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.src.detach)},
+			)
+		case i == 2:
+			r := v.Interface().(*pread[T])
+			r.busy = false
+			k := slices.Index(inputs, r)
+			if r.removed {
+				end(k)
+			} else {
+				r.armed = time.Now()
+				cases[3+2*k].Chan = reflect.ValueOf(r.in)
+			}
+		default:
+			k := (i - 3) / 2
+			r := inputs[k]
+			switch {
+			case (i-3)%2 == 1:
+				// Removed, it ends once its value in flight is pushed
+				r.removed = true
+				if r.busy {
+					cases[i-1].Chan = reflect.Value{}
+					cases[i].Chan = reflect.Value{}
+				} else {
+					end(k)
+				}
+			case !ok && r.busy:
+				// Read while busy with WithDropWhenFull, same as removed
+				r.removed = true
+				cases[i].Chan = reflect.Value{}
+				cases[i+1].Chan = reflect.Value{}
+			case !ok:
+				end(k)
+			default:
+				var x T
+				if iv := v.Interface(); iv != nil {
+					x = iv.(T)
+				}
+				r.src.wait(r.armed)
+				t.consume(r.src)
+				if x, ok = t.admit(x, r.src); !ok {
+					continue
+				}
+				// With WithDropWhenFull the input is still read while its
+				// value is in flight, and what doesn't fit is dropped
+				if t.opts.dropWhenFull && !r.src.internal {
+					if r.busy {
+						t.drop(r.src)
+						r.armed = time.Now()
+						continue
+					}
+					select {
+					case p.queue <- pwork[T]{r: r, g: g, v: x}:
+						r.busy = true
+					default:
+						t.drop(r.src)
+					}
+					r.armed = time.Now()
+					continue
+				}
+				p.queue <- pwork[T]{r: r, g: g, v: x}
+				r.busy = true
+				cases[i].Chan = reflect.Value{}
+			}
+		}
+	}
+}
+
+func (t *tree[T]) flushPooled(in pinput[T]) {
+	for {
+		select {
+		case v, ok := <-in.in:
+			if !ok {
+				return
+			}
+			t.consume(in.src)
+			if v, ok := t.admit(v, in.src); ok {
+				in.leaf.push(0, v)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (t *tree[T]) addPooled(out []<-chan T) {
+	leaves := make([]*pnode[T], len(out))
+	for i := range out {
+		leaves[i] = t.newPnode(pleaf, 1)
+	}
+
+	// The structure is wired up before any value can flow
+	if t.ordered {
+		t.addPooledOrdered(leaves)
+	} else {
+		for _, l := range leaves {
+			t.addPooledOne(l, 0)
+		}
+	}
+
+	for i, o := range out {
+		in := pinput[T]{in: o, leaf: leaves[i], src: t.attach(o)}
+		t.pool.leaves.Add(1)
+		if g := t.group(); g != nil {
+			select {
+			case g.add <- in:
+				continue
+			case <-t.ctx.Done():
+				t.pool.mu.Lock()
+				g.n--
+				t.pool.mu.Unlock()
+			}
+		}
+		in.leaf.closeSide(0)
+		t.forget(in.in, in.src)
+		t.pool.leaves.Done()
+	}
+}
+
+// addPooledOne is addOne for pooled nodes. A root that is already closed
+// frees its level instead of taking part in a merge.
+func (t *tree[T]) addPooledOne(root *pnode[T], level int) {
+	p := t.pool
+	for i := len(p.roots); i <= level; i++ {
+		p.roots = append(p.roots, nil)
+	}
+
+	prev := p.roots[level]
+	if prev == nil {
+		p.roots[level] = root
+		return
+	}
+
+	n := t.newPnode(punordered, 2)
+	if !prev.adopt(n, 0) {
+		n.release()
+		p.roots[level] = root
+		return
+	}
+	root.adopt(n, 1)
+	p.roots[level] = nil
+	t.addPooledOne(n, level+1)
+}
+
+func (t *tree[T]) addPooledOrdered(leaves []*pnode[T]) {
+	if len(leaves) == 0 {
+		return
+	}
+	p := t.pool
+	root, depth := t.buildPooledOrdered(leaves)
+	r := prun[T]{n: root, size: len(leaves), depth: depth}
+
+	for len(p.runs) > 0 && p.runs[len(p.runs)-1].size <= r.size {
+		prev := p.runs[len(p.runs)-1]
+		p.runs = p.runs[:len(p.runs)-1]
+
+		n := t.newPnode(pordered, 2)
+		r.n.adopt(n, 1)
+		prev.n.mu.Lock()
+		p.rounds.mu.Lock()
+		n.queues[0] = p.rounds.queues[prev.id]
+		if prev.n.open == 0 {
+			n.open--
+			n.closed[0] = true
+		}
+		prev.n.parent, prev.n.side = n, 0
+		delete(p.rounds.queues, prev.id)
+		delete(p.rounds.closed, prev.id)
+		p.rounds.order = slices.DeleteFunc(p.rounds.order, func(id int) bool { return id == prev.id })
+		p.rounds.open--
+		p.rounds.mu.Unlock()
+		prev.n.mu.Unlock()
+
+		r = prun[T]{n: n, size: prev.size + r.size, depth: max(prev.depth, r.depth) + 1}
+	}
+
+	r.id = p.nextID
+	p.nextID++
+	p.rounds.mu.Lock()
+	p.rounds.order = append(p.rounds.order, r.id)
+	p.rounds.open++
+	p.rounds.mu.Unlock()
+	r.n.adopt(p.rounds, r.id)
+	p.runs = append(p.runs, r)
+}
+
+func (t *tree[T]) buildPooledOrdered(leaves []*pnode[T]) (*pnode[T], int) {
+	if len(leaves) == 1 {
+		return leaves[0], 1
+	}
+	mid := len(leaves) / 2
+	n := t.newPnode(pordered, 2)
+	f, fd := t.buildPooledOrdered(leaves[:mid])
+	s, sd := t.buildPooledOrdered(leaves[mid:])
+	f.adopt(n, 0)
+	s.adopt(n, 1)
+	return n, max(fd, sd) + 1
+}
+
+// pooledRoot receives the values that reach the root of a pooled tree.
+func (t *tree[T]) pooledRoot(v T) {
+	t.deliver(v, nil)
+}
+
+func (t *tree[T]) finishPooled() error {
+	p := t.pool
+	if t.waitForAll {
+		p.leaves.Wait()
+		if p.rounds != nil {
+			p.rounds.mu.Lock()
+			p.rounds.flushRounds(true)
+			p.rounds.mu.Unlock()
+		}
+	}
+	t.cancel()
+	t.wg.Wait()
+
+	if t.waitForAll {
+		t.emitFinal()
+	}
+	t.closeOutput()
+	return t.error()
+}
@@ -0,0 +1,104 @@
+package treeduction_test
+
+import (
+	"runtime"
+	"testing"
+	"treeduction"
+)
+
+// TestMaxWorkers tests a pooled reduction over a large fan-in.
+func TestMaxWorkers(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithMaxWorkers(4))
+
+	sum := 0
+	channels := make([]<-chan int, 1000)
+	for i := range channels {
+		ch := make(chan int, 1)
+		ch <- i
+		close(ch)
+		sum += i
+		channels[i] = ch
+	}
+	tree.Add(channels...)
+
+	// 4 workers and a reader per 64 inputs
+	if n := runtime.NumGoroutine(); n > before+4+16 {
+		t.Errorf("Expected at most 20 more goroutines, got %d more", n-before)
+	}
+
+	tree.Finish()
+	if result := <-tree.Output(); result != sum {
+		t.Errorf("Expected result to be %d, got %d", sum, result)
+	}
+}
+
+// TestMaxWorkersOrdered tests that a pooled tree keeps ordered mode semantics.
+func TestMaxWorkersOrdered(t *testing.T) {
+	tree := treeduction.New(func(a, b string) string {
+		return a + b
+	}, 10, true, true, treeduction.WithMaxWorkers(3))
+
+	for _, batch := range [][]string{{"a", "b"}, {"c"}, {"d", "e", "f"}, {"g"}} {
+		var channels []<-chan string
+		for _, v := range batch {
+			ch := make(chan string, 2)
+			ch <- v
+			ch <- v
+			close(ch)
+			channels = append(channels, ch)
+		}
+		tree.Add(channels...)
+	}
+
+	tree.Finish()
+	if result := <-tree.Output(); result != "abcdefgabcdefg" {
+		t.Errorf("Expected result to be %q, got %q", "abcdefgabcdefg", result)
+	}
+}
+
+// TestMaxWorkersStreaming tests a pooled tree without waitForAll.
+func TestMaxWorkersStreaming(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithMaxWorkers(2))
+
+	ch1 := make(chan int, 1)
+	ch2 := make(chan int, 1)
+	tree.Add(ch1, ch2)
+	ch1 <- 1
+	ch2 <- 2
+
+	if result := <-tree.Output(); result != 3 {
+		t.Errorf("Expected result to be 3, got %d", result)
+	}
+	tree.Finish()
+}
+
+// TestMaxWorkersRemove tests that removing a pooled input flushes the values
+// buffered in it.
+func TestMaxWorkersRemove(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithMaxWorkers(2))
+
+	ch1 := make(chan int, 1)
+	ch2 := make(chan int, 3) // Never closed
+	ch1 <- 1
+	close(ch1)
+	tree.Add(ch1, ch2)
+
+	ch2 <- 2
+	ch2 <- 3
+	if !tree.Remove(ch2) {
+		t.Fatal("Expected Remove() to report the channel")
+	}
+
+	tree.Finish()
+	if result := <-tree.Output(); result != 6 {
+		t.Errorf("Expected result to be 6, got %d", result)
+	}
+}
@@ -0,0 +1,152 @@
+package treeduction
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// AddWithPriority is like Add, but the values of the inputs are reduced
+// ahead of the inputs with a lower priority (Add has a priority of 0): the
+// inputs of each priority share a node right below the root, which combines
+// the values that are ready without waiting for a pair, and when several of
+// these nodes have a result ready, the one with the highest priority is
+// delivered first. A real-time stream then flows through with little delay
+// while a backfill is reduced by the rest of the tree. It returns
+// errors.ErrUnsupported in ordered mode and with WithMaxWorkers.
+func (t *tree[T]) AddWithPriority(p int, out ...<-chan T) error {
+	if p <= 0 {
+		return t.Add(out...)
+	}
+	if t.ordered || t.opts.pooled() {
+		return fmt.Errorf("treeduction: priorities need an unordered tree without workers: %w", errors.ErrUnsupported)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.finished.Load() {
+		t.fail(ErrFinished)
+		return ErrFinished
+	}
+	out, err := skipInputs(out, t.opts.duplicates, t.reading)
+	t.add(out, p)
+	return err
+}
+
+// lane is the node of the inputs of a priority.
+type lane[T any] struct {
+	*knode[T]
+	priority int
+}
+
+// lane returns the node of a priority, which takes inputs until the tree is
+// finished or rebalanced.
+func (t *tree[T]) lane(p int) *knode[T] {
+	i, found := slices.BinarySearchFunc(t.lanes, p, func(l *lane[T], p int) int {
+		return p - l.priority
+	})
+	if found {
+		return t.lanes[i].knode
+	}
+
+	n := &knode[T]{fanIn: make(chan T, t.bufAt(1)), out: make(chan T, t.bufAt(1))}
+	t.track(n.out, "priority")
+	t.track(n.fanIn, "")
+	closed := t.nodeCreated()
+	t.spawn(func() {
+		for v := range n.fanIn {
+			if t.opts.perValue() {
+				t.put(n.out, v)
+				continue
+			}
+			// Combine what is ready, but don't hold a value for its pair
+		ready:
+			for {
+				select {
+				case w, ok := <-n.fanIn:
+					if !ok {
+						break ready
+					}
+					v = t.combine(v, w)
+				default:
+					break ready
+				}
+			}
+			t.put(n.out, v)
+		}
+		closed()
+		t.untrack(n.out)
+		close(n.out)
+	})
+	t.lanes = slices.Insert(t.lanes, i, &lane[T]{knode: n, priority: p})
+	return n
+}
+
+// collectLanes delivers the results of the lanes until the next Add, the
+// highest priority first.
+func (t *tree[T]) collectLanes() {
+	outs := make([]<-chan T, len(t.lanes))
+	for i, l := range t.lanes {
+		outs[i] = l.out
+	}
+	stop := t.stop
+
+	t.wg.Add(1)
+	t.spawn(func() {
+		defer t.wg.Done()
+		if !t.waitBatch(stop) {
+			return
+		}
+		cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(stop)}}
+		for _, c := range outs {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)})
+		}
+		remove := func(i int) {
+			outs = slices.Delete(outs, i, i+1)
+			cases = slices.Delete(cases, i+1, i+2)
+		}
+
+		for len(outs) > 0 {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			ready := false
+			for i, c := range outs {
+				select {
+				case v, ok := <-c:
+					if ok {
+						t.deliver(v, nil)
+					} else {
+						remove(i)
+					}
+					ready = true
+				default:
+				}
+				if ready {
+					break
+				}
+			}
+			if ready {
+				continue
+			}
+
+			i, v, ok := reflect.Select(cases)
+			switch {
+			case i == 0:
+				return
+			case !ok:
+				remove(i - 1)
+			default:
+				var x T
+				if iv := v.Interface(); iv != nil {
+					x = iv.(T)
+				}
+				t.deliver(x, nil)
+			}
+		}
+	})
+}
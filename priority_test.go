@@ -0,0 +1,91 @@
+package treeduction_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestPriority tests that the results of the inputs with the highest
+// priority are delivered first.
+func TestPriority(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithOutputBuffer(0))
+
+	low, high := make(chan int), make(chan int)
+	tree.AddWithPriority(1, low)
+	tree.AddWithPriority(2, high)
+	waitFor := func(cond func() bool) {
+		for deadline := time.Now().Add(time.Second); !cond(); {
+			if time.Now().After(deadline) {
+				t.Fatal("Timed out")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// The first result is held until the output is read, once nothing is
+	// left in the channels of the tree for a while
+	low <- 100
+	idle := time.Now()
+	waitFor(func() bool {
+		if tree.Stats().Pending > 0 {
+			idle = time.Now()
+		}
+		return time.Since(idle) > 20*time.Millisecond
+	})
+	low <- 1
+	high <- 2
+	waitFor(func() bool {
+		var b strings.Builder
+		tree.Dump(&b)
+		return strings.Count(b.String(), "priority 1/") == 2
+	})
+
+	for _, want := range []int{100, 2, 1} {
+		if v := <-tree.Output(); v != want {
+			t.Errorf("Expected %d, got %d", want, v)
+		}
+	}
+	close(low)
+	close(high)
+	tree.Finish()
+}
+
+// TestPriorityReduce tests that the inputs with a priority are reduced with
+// the others, across Rebalance.
+func TestPriorityReduce(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	tree.AddValues(1, 2, 3)
+	c := make(chan int)
+	tree.AddWithPriority(1, c)
+	tree.AddWithPriority(5, c)
+	tree.Rebalance()
+	c <- 4
+	c <- 5
+	close(c)
+	tree.AddValues(6)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 21 {
+		t.Errorf("Expected 21, got %d", v)
+	}
+}
+
+// TestPriorityUnsupported tests that ordered trees refuse priorities.
+func TestPriorityUnsupported(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, true)
+	if err := tree.AddWithPriority(1, make(chan int)); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+	tree.Finish()
+}
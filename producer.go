@@ -0,0 +1,90 @@
+package treeduction
+
+import (
+	"context"
+	"runtime/debug"
+)
+
+// Go runs f in a goroutine of the tree, and reduces the values it emits
+// like the values of an input added with AddWithErr: an error returned by f
+// (or a panic, as a *PanicError) is handled according to the SourcePolicy.
+// Once the tree is cancelled, emit drops the values, so f should return
+// when it has nothing else to do. With waitForAll, Finish waits for f.
+func (t *tree[T]) Go(f func(emit func(T)) error) error {
+	c := make(chan T)
+	errs := make(chan error, 1)
+	if err := t.AddWithErr(c, errs); err != nil {
+		return err
+	}
+	t.spawn(func() {
+		produce(t, c, errs, f)
+	}, "role", "producer")
+	return nil
+}
+
+// produce runs a producer of the tree, and closes its channels when it
+// returns.
+func produce[T, A any](t *tree[A], c chan<- T, errs chan<- error, f func(emit func(T)) error) {
+	defer close(errs)
+	defer close(c)
+	defer func() {
+		if p := recover(); p != nil {
+			errs <- &PanicError{Value: p, Stack: debug.Stack()}
+		}
+	}()
+
+	err := f(func(v T) {
+		select {
+		case c <- v:
+		case <-t.ctx.Done():
+		}
+	})
+	if err != nil {
+		errs <- err
+	}
+}
+
+// AddProducer reduces the values of the channel returned by produce, which
+// is handed a context that is cancelled once the tree doesn't need the
+// values anymore: when it is finished or aborted, when it fails, or when the
+// channel is removed. The producer should then stop sending and close the
+// channel, so unlike with Go, Finish doesn't wait for producers that would
+// run forever (with waitForAll, what they sent before is still reduced).
+func (t *tree[T]) AddProducer(produce func(ctx context.Context) <-chan T) error {
+	return addProducer(t, produce, t.Add)
+}
+
+// AddProducer reduces the values of T of the channel returned by produce,
+// see Tree.AddProducer.
+func (f *Folder[T, A]) AddProducer(produce func(ctx context.Context) <-chan T) error {
+	return addProducer(f.tree, produce, f.Add)
+}
+
+// addProducer starts a producer with a context of its own, and adds its
+// channel with add.
+func addProducer[T, A any](t *tree[A], produce func(ctx context.Context) <-chan T, add func(...<-chan T) error) error {
+	if t.finished.Load() {
+		t.fail(ErrFinished)
+		return ErrFinished
+	}
+	ctx, cancel := context.WithCancel(t.producing)
+	in := produce(ctx)
+	t.prodMu.Lock()
+	t.producers[in] = cancel
+	t.prodMu.Unlock()
+	if err := add(in); err != nil {
+		cancel()
+		return err
+	}
+	return nil
+}
+
+// unproduce cancels the context of the producer of a channel, if any.
+func (t *tree[T]) unproduce(in any) {
+	t.prodMu.Lock()
+	defer t.prodMu.Unlock()
+	if cancel, ok := t.producers[in]; ok {
+		cancel()
+		delete(t.producers, in)
+	}
+}
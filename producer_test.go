@@ -0,0 +1,135 @@
+package treeduction_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestGo tests reducing the values of producers run by the tree.
+func TestGo(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	for i := range 4 {
+		tree.Go(func(emit func(int)) error {
+			for j := range 10 {
+				emit(i*10 + j)
+			}
+			return nil
+		})
+	}
+
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 780 {
+		t.Errorf("Expected 780, got %d", v)
+	}
+}
+
+// TestGoError tests that the error and the panic of a producer are
+// reported.
+func TestGoError(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithSourcePolicy(treeduction.CollectErrors))
+	tree.Go(func(emit func(int)) error {
+		emit(1)
+		return errProducer
+	})
+	tree.Go(func(emit func(int)) error {
+		panic("boom")
+	})
+
+	err := tree.Finish()
+	var perr *treeduction.PanicError
+	if !errors.Is(err, errProducer) || !errors.As(err, &perr) {
+		t.Errorf("Expected the error and the panic of the producers, got %v", err)
+	}
+	if v := <-tree.Output(); v != 1 {
+		t.Errorf("Expected 1, got %d", v)
+	}
+}
+
+// ticker is a producer that sends ones until its context is cancelled.
+func ticker(stopped chan<- struct{}) func(ctx context.Context) <-chan int {
+	return func(ctx context.Context) <-chan int {
+		c := make(chan int)
+		go func() {
+			defer close(stopped)
+			defer close(c)
+			for {
+				select {
+				case c <- 1:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return c
+	}
+}
+
+// TestAddProducer tests that the producers are stopped by Finish, and by
+// Remove.
+func TestAddProducer(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	first, second := make(chan struct{}), make(chan struct{})
+	var removed <-chan int
+	tree.AddProducer(func(ctx context.Context) <-chan int {
+		removed = ticker(first)(ctx)
+		return removed
+	})
+	var kept <-chan int
+	tree.AddProducer(func(ctx context.Context) <-chan int {
+		kept = ticker(second)(ctx)
+		return kept
+	})
+
+	tree.Remove(removed)
+	<-first
+	select {
+	case <-second:
+		t.Error("Expected the other producer to go on")
+	default:
+	}
+
+	for deadline := time.Now().Add(time.Second); tree.Stats().Consumed[kept] == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	<-second
+	if r := <-tree.Output(); r == 0 {
+		t.Error("Expected the values sent before Finish to be reduced")
+	}
+}
+
+// TestAddProducerAbort tests that the producers are stopped by Abort, and
+// that none is started after Finish.
+func TestAddProducerAbort(t *testing.T) {
+	tree := treeduction.NewCounted(func(a, b int) int {
+		return a + b
+	}, 10, false, false)
+
+	stopped := make(chan struct{})
+	tree.AddProducer(ticker(stopped))
+	<-tree.Output()
+	tree.Abort()
+	<-stopped
+
+	err := tree.AddProducer(func(ctx context.Context) <-chan int {
+		t.Error("Expected no producer to be started after Abort")
+		return nil
+	})
+	if !errors.Is(err, treeduction.ErrFinished) {
+		t.Errorf("Expected ErrFinished, got %v", err)
+	}
+}
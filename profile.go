@@ -0,0 +1,37 @@
+package treeduction
+
+import (
+	"context"
+	"runtime/pprof"
+	"strconv"
+)
+
+// WithProfilerLabels labels the goroutines of the tree for pprof, with the
+// name of the tree (see WithName) and what the goroutine does: reading an
+// input (numbered from 0 in the order the inputs were added), combining at
+// a level of the tree, collecting the roots, running a worker... so that
+// the trees of a process can be told apart in a profile.
+func WithProfilerLabels() Option {
+	return func(o *options) {
+		o.profilerLabels = true
+	}
+}
+
+// labeled returns f running with the profiler labels of the tree and the
+// given key value pairs, if the tree has any.
+func (t *tree[T]) labeled(f func(), labels []string) func() {
+	if !t.opts.profilerLabels {
+		return f
+	}
+	labels = append([]string{"tree", t.opts.name}, labels...)
+	return func() {
+		pprof.Do(context.Background(), pprof.Labels(labels...), func(context.Context) {
+			f()
+		})
+	}
+}
+
+// levelLabels are the labels of a node at a level of the tree.
+func levelLabels(level int) []string {
+	return []string{"role", "node", "level", strconv.Itoa(level)}
+}
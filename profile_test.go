@@ -0,0 +1,34 @@
+package treeduction_test
+
+import (
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestProfilerLabels tests that the goroutines of the tree are labeled.
+func TestProfilerLabels(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 0, true, false, treeduction.WithName("sums"), treeduction.WithProfilerLabels())
+	tree.Add(make(chan int), make(chan int)) // Never closed
+	defer tree.Abort()
+
+	// The goroutines may not have started yet
+	var profile string
+	for deadline := time.Now().Add(time.Second); !strings.Contains(profile, `"level":"1"`) && time.Now().Before(deadline); {
+		var b strings.Builder
+		if err := pprof.Lookup("goroutine").WriteTo(&b, 1); err != nil {
+			t.Fatal(err)
+		}
+		profile = b.String()
+		time.Sleep(time.Millisecond)
+	}
+	for _, label := range []string{`"tree":"sums"`, `"role":"input"`, `"input":"1"`, `"level":"1"`} {
+		if !strings.Contains(profile, label) {
+			t.Errorf("Expected the goroutines to be labeled with %s", label)
+		}
+	}
+}
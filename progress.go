@@ -0,0 +1,40 @@
+package treeduction
+
+import "time"
+
+// WithProgress calls f with the number of values read from the inputs and
+// the number of results put on the output, every time another every values
+// were read and once more when the tree finishes, e.g. to drive a progress
+// bar. The calls never overlap, but f runs on the goroutines of the tree so
+// it should return quickly.
+func WithProgress(every int, f func(consumed, produced int64)) Option {
+	if every <= 0 {
+		panic("treeduction: progress granularity must be positive")
+	}
+	return func(o *options) {
+		o.progressEvery = int64(every)
+		o.progress = f
+	}
+}
+
+// consume counts a value read from an input.
+func (t *tree[T]) consume(src *source) {
+	src.read.Add(1)
+	src.last.Store(time.Now().UnixNano())
+	if !src.internal {
+		t.alive.Add(1)
+	}
+	n := t.consumed.Add(1)
+	if t.opts.progress != nil && n%t.opts.progressEvery == 0 {
+		t.reportProgress()
+	}
+}
+
+func (t *tree[T]) reportProgress() {
+	if t.opts.progress == nil {
+		return
+	}
+	t.progressMu.Lock()
+	defer t.progressMu.Unlock()
+	t.opts.progress(t.consumed.Load(), t.emitted.Load())
+}
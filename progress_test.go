@@ -0,0 +1,39 @@
+package treeduction_test
+
+import (
+	"sync"
+	"testing"
+	"treeduction"
+)
+
+// TestProgress tests that progress is reported every few values and when the
+// tree finishes.
+func TestProgress(t *testing.T) {
+	var mu sync.Mutex
+	var calls [][2]int64
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithProgress(4, func(consumed, produced int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, [2]int64{consumed, produced})
+	}))
+
+	for i := range 10 {
+		tree.AddValues(i)
+	}
+	tree.Finish()
+	<-tree.Output()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3 progress reports, got %v", calls)
+	}
+	if calls[0][0] < 4 {
+		t.Errorf("Expected the first report after 4 values, got %v", calls[0])
+	}
+	if last := calls[2]; last != [2]int64{10, 1} {
+		t.Errorf("Expected the last report to be [10 1], got %v", last)
+	}
+}
@@ -0,0 +1,52 @@
+package treeduction
+
+// WithExpectedValues finishes the tree once n values were read from its
+// inputs, even if they are never closed, for long-lived producers that
+// can't close their channels per batch: the inputs aren't read past the
+// n-th value, and the tree then finishes itself like FinishContext with a
+// cancelled context, so the output is closed once the n values are reduced.
+// It has no effect on a Folder, nor with WithMaxWorkers, WithInline or
+// WithLocalGroups.
+func WithExpectedValues(n int) Option {
+	if n <= 0 {
+		panic("treeduction: expected values must be positive")
+	}
+	return func(o *options) {
+		o.expectedValues = int64(n)
+	}
+}
+
+// claim takes a slot and one of the expected values for a value about to be
+// read from an input. It returns false if done fired, the tree was
+// cancelled or the expected values were all read first.
+func (t *tree[T]) claim(done <-chan struct{}) bool {
+	if t.opts.expectedValues > 0 && t.quota.Add(-1) < 0 {
+		t.quota.Add(1)
+		return false
+	}
+	if !t.enter(done) {
+		t.unquota()
+		return false
+	}
+	return true
+}
+
+// unclaim gives back what claim took for a value that wasn't read.
+func (t *tree[T]) unclaim() {
+	t.vacate()
+	t.unquota()
+}
+
+func (t *tree[T]) unquota() {
+	if t.opts.expectedValues > 0 {
+		t.quota.Add(1)
+	}
+}
+
+// expect counts a value read from an input, and finishes the tree once it
+// is the last one expected.
+func (t *tree[T]) expect() {
+	if n := t.opts.expectedValues; n > 0 && t.expected.Add(1) == n {
+		t.spawn(t.finishItself, "role", "expected")
+	}
+}
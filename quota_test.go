@@ -0,0 +1,45 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestExpectedValues tests that the tree finishes itself once the expected
+// values were read, without reading past them.
+func TestExpectedValues(t *testing.T) {
+	for _, waitForAll := range []bool{true, false} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, waitForAll, false, treeduction.WithExpectedValues(5))
+		a, b := make(chan int, 10), make(chan int, 10)
+		for range 10 {
+			a <- 1
+			b <- 1
+		}
+		// Never closed
+		tree.Add(a, b)
+
+		sum := 0
+		timeout := time.After(time.Second)
+	read:
+		for {
+			select {
+			case v, ok := <-tree.Output():
+				if !ok {
+					break read
+				}
+				sum += v
+			case <-timeout:
+				t.Fatal("Expected the output to be closed")
+			}
+		}
+		if sum != 5 {
+			t.Errorf("Expected 5, got %d", sum)
+		}
+		if n := len(a) + len(b); n != 15 {
+			t.Errorf("Expected 15 values left in the inputs, got %d", n)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package treeduction
+
+import (
+	"sync"
+	"time"
+)
+
+// WithRateLimit caps the rate at which the tree reads values from all of
+// its inputs together to perSecond, so that it doesn't pull from fast
+// producers faster than the systems fed by the output can handle. The
+// values are read evenly spaced, without bursts. It has no effect with
+// WithMaxWorkers.
+func WithRateLimit(perSecond float64) Option {
+	if perSecond <= 0 {
+		panic("treeduction: rate limit must be positive")
+	}
+	return func(o *options) {
+		o.rate = perSecond
+	}
+}
+
+// WithSourceRateLimit caps the rate at which the tree reads values from
+// each of its inputs to perSecond, see WithRateLimit.
+func WithSourceRateLimit(perSecond float64) Option {
+	if perSecond <= 0 {
+		panic("treeduction: rate limit must be positive")
+	}
+	return func(o *options) {
+		o.sourceRate = perSecond
+	}
+}
+
+// bucket is a token bucket holding a single token.
+type bucket struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newBucket returns a bucket for perSecond tokens, nil if there is no limit.
+func newBucket(perSecond float64) *bucket {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &bucket{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// reserve takes the next token, and returns how long to wait for it.
+func (b *bucket) reserve() time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if b.next.Before(now) {
+		b.next = now
+	}
+	d := b.next.Sub(now)
+	b.next = b.next.Add(b.interval)
+	return d
+}
+
+// throttle waits for a token of the tree and of the input, if they are
+// limited. It returns false if done fired or the tree was cancelled first.
+func (t *tree[T]) throttle(own *bucket, done <-chan struct{}) bool {
+	d := max(t.limit.reserve(), own.reserve())
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-done:
+		return false
+	case <-t.ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,80 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+func ones(n int) <-chan int {
+	c := make(chan int, n)
+	for range n {
+		c <- 1
+	}
+	close(c)
+	return c
+}
+
+// TestRateLimit tests that the inputs together are read no faster than the
+// limit.
+func TestRateLimit(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithRateLimit(200))
+
+	start := time.Now()
+	tree.Add(ones(10), ones(10))
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 20 {
+		t.Errorf("Expected 20, got %d", v)
+	}
+	// The first value is read right away
+	if d := time.Since(start); d < 19*5*time.Millisecond {
+		t.Errorf("Expected at least 95ms for 20 values at 200/s, took %v", d)
+	}
+}
+
+// TestSourceRateLimit tests that each input is limited on its own.
+func TestSourceRateLimit(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithSourceRateLimit(200))
+
+	start := time.Now()
+	tree.Add(ones(10), ones(10), ones(10), ones(10))
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 40 {
+		t.Errorf("Expected 40, got %d", v)
+	}
+	d := time.Since(start)
+	if d < 9*5*time.Millisecond {
+		t.Errorf("Expected at least 45ms for 10 values per input at 200/s, took %v", d)
+	}
+	// The inputs don't wait for each other
+	if d > 170*time.Millisecond {
+		t.Errorf("Expected the inputs to be read in parallel, took %v", d)
+	}
+}
+
+// TestRateLimitFolder tests that the inputs of a Folder are limited.
+func TestRateLimitFolder(t *testing.T) {
+	f := treeduction.NewCounted(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithRateLimit(200))
+
+	start := time.Now()
+	f.Add(ones(10), ones(10))
+	if err := f.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-f.Output(); v.Count != 20 {
+		t.Errorf("Expected 20 values, got %d", v.Count)
+	}
+	if d := time.Since(start); d < 19*5*time.Millisecond {
+		t.Errorf("Expected at least 95ms for 20 values at 200/s, took %v", d)
+	}
+}
@@ -0,0 +1,40 @@
+package treeduction
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+)
+
+// AddReader reduces the values decoded from r, like a producer passed to Go:
+// decode is called until it returns io.EOF, which ends the input, and any
+// other error is handled according to the SourcePolicy. decode must return
+// io.EOF only when there is nothing left to read, a truncated value is an
+// io.ErrUnexpectedEOF. r is no longer read once the tree is cancelled.
+func (t *tree[T]) AddReader(r io.Reader, decode func(*bufio.Reader) (T, error)) error {
+	return t.Go(readAll(t.ctx, r, decode))
+}
+
+// AddReader reduces the values of T decoded from r, see Tree.AddReader.
+func (f *Folder[T, A]) AddReader(r io.Reader, decode func(*bufio.Reader) (T, error)) error {
+	return f.Go(readAll(f.ctx, r, decode))
+}
+
+// readAll returns a producer of the values decoded from r.
+func readAll[T any](ctx context.Context, r io.Reader, decode func(*bufio.Reader) (T, error)) func(emit func(T)) error {
+	return func(emit func(T)) error {
+		br := bufio.NewReader(r)
+		for ctx.Err() == nil {
+			v, err := decode(br)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			emit(v)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,57 @@
+package treeduction_test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"treeduction"
+)
+
+// decodeLine decodes an integer per line.
+func decodeLine(r *bufio.Reader) (int, error) {
+	line, err := r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(line))
+}
+
+// TestAddReader tests reducing the values decoded from readers.
+func TestAddReader(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	tree.AddReader(strings.NewReader("1\n2\n3\n"), decodeLine)
+	tree.AddReader(strings.NewReader("4\n5"), decodeLine)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 15 {
+		t.Errorf("Expected 15, got %d", v)
+	}
+}
+
+// TestAddReaderError tests that a decoding error is handled like the error
+// of a producer.
+func TestAddReaderError(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithSourcePolicy(treeduction.CollectErrors))
+
+	tree.AddReader(strings.NewReader("1\ntwo\n3\n"), decodeLine)
+	tree.AddReader(strings.NewReader("4\n"), decodeLine)
+	var numErr *strconv.NumError
+	if err := tree.Finish(); err == nil || !errors.As(err, &numErr) {
+		t.Errorf("Expected a *strconv.NumError, got %v", err)
+	}
+	if v := <-tree.Output(); v != 5 {
+		t.Errorf("Expected 5, got %d", v)
+	}
+}
@@ -0,0 +1,87 @@
+package treeduction
+
+import (
+	"maps"
+	"slices"
+)
+
+// Rebalance rebuilds the tree over the sources that are still being read, so
+// that closed and removed inputs don't leave long pass-through paths behind.
+// The old roots are added as leaves of the new tree. Ordered runs
+// are kept balanced on every Add, so in ordered mode it does nothing.
+func (t *tree[T]) Rebalance() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.ordered || t.finished.Load() {
+		return
+	}
+
+	t.srcMu.Lock()
+	var inputs []<-chan T
+	prioritized := make(map[int][]<-chan T)
+	for in, srcs := range t.sources {
+		// The inputs of a sealed epoch stay in their own tree
+		kept := srcs[:0]
+		for _, src := range srcs {
+			if src.sealed {
+				kept = append(kept, src)
+				continue
+			}
+			close(src.detach)
+			if src.priority > 0 {
+				prioritized[src.priority] = append(prioritized[src.priority], in)
+			} else {
+				inputs = append(inputs, in)
+			}
+		}
+		if len(kept) == 0 {
+			delete(t.sources, in)
+		} else {
+			t.sources[in] = kept
+		}
+	}
+	t.srcMu.Unlock()
+
+	// The old roots still hold values, so they become leaves of the new tree
+	if t.pool != nil {
+		old := slices.DeleteFunc(slices.Clone(t.pool.roots), func(n *pnode[T]) bool {
+			if n == nil {
+				return true
+			}
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			return n.open == 0
+		})
+		clear(t.pool.roots)
+		for _, n := range old {
+			t.addPooledOne(n, 0)
+		}
+		t.add(inputs, 0)
+		return
+	}
+
+	close(t.stop)
+	t.stop = make(chan struct{})
+	t.sealOpen()
+	for _, r := range t.levels.takeRoots() {
+		t.addOne(r, 0)
+	}
+	t.add(inputs, 0)
+	for _, p := range slices.Sorted(maps.Keys(prioritized)) {
+		t.add(prioritized[p], p)
+	}
+}
+
+// height is the number of levels of the unordered tree.
+func (t *tree[T]) height() int {
+	if t.pool == nil {
+		return t.levels.height()
+	}
+	for i := len(t.pool.roots) - 1; i >= 0; i-- {
+		if t.pool.roots[i] != nil {
+			return i + 1
+		}
+	}
+	return 0
+}
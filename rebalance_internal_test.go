@@ -0,0 +1,53 @@
+package treeduction
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRebalanceHeight tests that rebalancing shrinks a tree that lost most of
+// its inputs.
+func TestRebalanceHeight(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithMaxWorkers(2)}} {
+		tr := newTree[int](10, true, false, opts, nil)
+		tr.combiner = func(a, b int) int { return a + b }
+
+		var open []chan int
+		for i := range 64 {
+			ch := make(chan int)
+			if i < 62 {
+				close(ch)
+			} else {
+				open = append(open, ch)
+			}
+			tr.Add(ch)
+		}
+		if h := tr.height(); opts == nil && h != 7 {
+			t.Fatalf("Expected height to be 7, got %d", h)
+		}
+		for tr.live() > 2 {
+			time.Sleep(time.Millisecond)
+		}
+
+		// Two live inputs and what is left of the old roots
+		tr.Rebalance()
+		if h := tr.height(); h > 3 {
+			t.Errorf("Expected height to be at most 3 after Rebalance, got %d", h)
+		}
+
+		for _, ch := range open {
+			ch <- 1
+			close(ch)
+		}
+		tr.Finish()
+		if result := <-tr.Output(); result != 2 {
+			t.Errorf("Expected result to be 2, got %d", result)
+		}
+	}
+}
+
+func (t *tree[T]) live() int {
+	t.srcMu.Lock()
+	defer t.srcMu.Unlock()
+	return len(t.sources)
+}
@@ -0,0 +1,66 @@
+package treeduction_test
+
+import (
+	"testing"
+	"treeduction"
+)
+
+// TestRebalance tests that rebalancing keeps every value of the reduction.
+func TestRebalance(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	sum := 0
+	var open []chan int
+	for i := range 100 {
+		ch := make(chan int, 2)
+		ch <- i
+		sum += i
+		if i%2 == 0 {
+			close(ch)
+		} else {
+			open = append(open, ch)
+		}
+		tree.Add(ch)
+	}
+
+	tree.Rebalance()
+
+	for _, ch := range open {
+		ch <- 1
+		sum++
+		close(ch)
+	}
+
+	tree.Finish()
+	if result := <-tree.Output(); result != sum {
+		t.Errorf("Expected result to be %d, got %d", sum, result)
+	}
+}
+
+// TestOrderedManySingleAdds tests that ordered runs stay in order when they
+// are merged by many single Adds.
+func TestOrderedManySingleAdds(t *testing.T) {
+	tree := treeduction.New(func(a, b []int) []int {
+		return append(append([]int{}, a...), b...)
+	}, 10, true, true)
+
+	for i := range 100 {
+		ch := make(chan []int, 1)
+		ch <- []int{i}
+		close(ch)
+		tree.Add(ch)
+	}
+
+	tree.Finish()
+	result := <-tree.Output()
+	if len(result) != 100 {
+		t.Fatalf("Expected 100 values, got %d", len(result))
+	}
+	for i, v := range result {
+		if v != i {
+			t.Fatalf("Expected value %d at position %d, got %v", i, i, result)
+		}
+	}
+}
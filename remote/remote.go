@@ -0,0 +1,219 @@
+// Package remote connects reduction trees across processes: Serve streams
+// the results of a tree over the network, and Dial reads them back as an
+// input of another tree. Values are encoded with encoding/gob, so T must be
+// encodable by it, or with the treeduction.Codec passed to ServeCodec and
+// DialCodec on both sides.
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"treeduction"
+)
+
+// The kinds of the frames of a stream: a frame is its kind, the size of its
+// payload and the payload, a value or the error of the tree for the end.
+const (
+	valueFrame byte = iota
+	endFrame
+)
+
+// MaxFrameSize is the size of the largest payload Dial accepts, so that a
+// broken or hostile peer can't make it allocate without bound.
+var MaxFrameSize = 64 << 20
+
+// ErrFrameTooLarge is the error of a stream that sent a frame larger than
+// MaxFrameSize.
+var ErrFrameTooLarge = errors.New("remote: frame too large")
+
+// writeFrame writes a frame in a single write.
+func writeFrame(w io.Writer, kind byte, payload []byte) error {
+	frame := append([]byte{kind}, binary.AppendUvarint(nil, uint64(len(payload)))...)
+	_, err := w.Write(append(frame, payload...))
+	return err
+}
+
+// readFrame reads a frame.
+func readFrame(r *bufio.Reader) (byte, []byte, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if size > uint64(MaxFrameSize) {
+		return 0, nil, fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, size)
+	}
+	payload := make([]byte, size)
+	_, err = io.ReadFull(r, payload)
+	return kind, payload, err
+}
+
+// Serve sends the results of t to the connections accepted on lis, until
+// the output of t is closed and the connections were told so. Each result
+// goes to a single connection, so a tree is normally served to one
+// consumer, and a result that was being sent when its connection broke is
+// lost. Serve closes lis before returning.
+func Serve[T any](lis net.Listener, t treeduction.Tree[T]) error {
+	return ServeCodec(lis, t, treeduction.GobCodec[T]{})
+}
+
+// ServeCodec is Serve with the values encoded by codec.
+func ServeCodec[T any](lis net.Listener, t treeduction.Tree[T], codec treeduction.Codec[T]) error {
+	defer lis.Close()
+
+	done := make(chan struct{})
+	var once sync.Once
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			select {
+			case <-done:
+				return nil
+			default:
+				return err
+			}
+		}
+		go func() {
+			defer conn.Close()
+			if serve(conn, t, codec) {
+				once.Do(func() {
+					close(done)
+					lis.Close()
+				})
+			}
+		}()
+	}
+}
+
+// serve streams the results of t to conn, and reports whether the output
+// was closed.
+func serve[T any](conn net.Conn, t treeduction.Tree[T], codec treeduction.Codec[T]) bool {
+	var buf bytes.Buffer
+	for v := range t.Output() {
+		buf.Reset()
+		if err := codec.Encode(&buf, v); err != nil {
+			return false
+		}
+		if err := writeFrame(conn, valueFrame, buf.Bytes()); err != nil {
+			return false
+		}
+	}
+
+	var msg string
+	if err := t.Err(); err != nil {
+		msg = err.Error()
+	}
+	writeFrame(conn, endFrame, []byte(msg))
+	return true
+}
+
+// Source is the stream of results of a remote tree.
+type Source[T any] struct {
+	conn net.Conn
+	c    chan T
+	err  error
+}
+
+// Dial connects to a tree served with Serve on addr. Its results are sent
+// on C until the remote tree finishes, the connection breaks or ctx is done.
+func Dial[T any](ctx context.Context, addr string) (*Source[T], error) {
+	return DialCodec(ctx, addr, treeduction.GobCodec[T]{})
+}
+
+// DialCodec is Dial for a tree served with ServeCodec and the same codec.
+func DialCodec[T any](ctx context.Context, addr string, codec treeduction.Codec[T]) (*Source[T], error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Source[T]{conn: conn, c: make(chan T)}
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	go func() {
+		defer close(s.c)
+		defer conn.Close()
+		defer stop()
+
+		r := bufio.NewReader(conn)
+		for {
+			kind, payload, err := readFrame(r)
+			if err != nil {
+				switch {
+				case ctx.Err() != nil:
+					s.err = context.Cause(ctx)
+				case errors.Is(err, io.EOF):
+					s.err = io.ErrUnexpectedEOF
+				default:
+					s.err = err
+				}
+				return
+			}
+			if kind == endFrame {
+				if len(payload) > 0 {
+					s.err = errors.New(string(payload))
+				}
+				return
+			}
+			v, err := codec.Decode(bytes.NewReader(payload))
+			if err != nil {
+				s.err = err
+				return
+			}
+
+			select {
+			case s.c <- v:
+			case <-ctx.Done():
+				s.err = context.Cause(ctx)
+				return
+			}
+		}
+	}()
+	return s, nil
+}
+
+// C returns the channel of the results, which is closed when the stream
+// ends.
+func (s *Source[T]) C() <-chan T {
+	return s.c
+}
+
+// Err reports why the stream ended, once C is closed: the error of the
+// remote tree, or the failure of the connection or ctx, in which case the
+// results received are incomplete. It is nil if the remote tree finished
+// successfully.
+func (s *Source[T]) Err() error {
+	return s.err
+}
+
+// Add dials addr and adds the results of the remote tree as an input of t.
+// The returned Source reports whether the stream was complete.
+func Add[T any](ctx context.Context, t treeduction.Tree[T], addr string) (*Source[T], error) {
+	return AddCodec(ctx, t, addr, treeduction.GobCodec[T]{})
+}
+
+// AddCodec is Add for a tree served with ServeCodec and the same codec.
+func AddCodec[T any](ctx context.Context, t treeduction.Tree[T], addr string, codec treeduction.Codec[T]) (*Source[T], error) {
+	s, err := DialCodec(ctx, addr, codec)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Add(s.C()); err != nil {
+		s.conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
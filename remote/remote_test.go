@@ -0,0 +1,129 @@
+package remote_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"treeduction"
+	"treeduction/remote"
+)
+
+// TestRemote tests reducing the result of a tree served by another one.
+func TestRemote(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worker := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	worker.AddValues(1, 2, 3)
+	go worker.Finish()
+	served := make(chan error)
+	go func() {
+		served <- remote.Serve(lis, worker)
+	}()
+
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	tree.AddValues(4)
+	src, err := remote.Add(context.Background(), tree, lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.Finish()
+
+	if v := <-tree.Output(); v != 10 {
+		t.Errorf("Expected 10, got %d", v)
+	}
+	if err := src.Err(); err != nil {
+		t.Errorf("Expected the stream to be complete, got %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Expected Serve to return nil, got %v", err)
+	}
+}
+
+// TestRemoteBroken tests that a stream that ends early is reported.
+func TestRemoteBroken(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		conn, err := lis.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	src, err := remote.Dial[int](context.Background(), lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range src.C() {
+	}
+	if src.Err() == nil {
+		t.Error("Expected an error for a broken stream")
+	}
+}
+
+// TestRemoteFrameTooLarge tests that a frame larger than MaxFrameSize ends
+// the stream instead of being allocated.
+func TestRemoteFrameTooLarge(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		conn, err := lis.Accept()
+		if err == nil {
+			conn.Write(binary.AppendUvarint([]byte{0}, 1<<62))
+			conn.Close()
+		}
+	}()
+
+	src, err := remote.Dial[int](context.Background(), lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range src.C() {
+	}
+	if err := src.Err(); !errors.Is(err, remote.ErrFrameTooLarge) {
+		t.Errorf("Expected ErrFrameTooLarge, got %v", err)
+	}
+}
+
+// TestRemoteCodec tests streaming the results with another codec.
+func TestRemoteCodec(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	concat := func(a, b []string) []string {
+		return append(a, b...)
+	}
+	codec := treeduction.JSONCodec[[]string]{}
+
+	worker := treeduction.New(concat, 10, true, false)
+	worker.AddValues([]string{"a"}, []string{"b", "c"})
+	go worker.Finish()
+	go remote.ServeCodec(lis, worker, codec)
+
+	tree := treeduction.New(concat, 10, true, false)
+	src, err := remote.AddCodec(context.Background(), tree, lis.Addr().String(), codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.Finish()
+	if v := <-tree.Output(); len(v) != 3 {
+		t.Errorf("Expected 3 strings, got %v", v)
+	}
+	if err := src.Err(); err != nil {
+		t.Errorf("Expected the stream to be complete, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package treeduction_test
+
+import (
+	"testing"
+	"treeduction"
+)
+
+// TestRemove tests that a removed source no longer holds back Finish.
+func TestRemove(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	ch1 := make(chan int, 5)
+	ch2 := make(chan int, 5) // Never closed
+	ch3 := make(chan int, 5)
+	tree.Add(ch1, ch2, ch3)
+
+	ch1 <- 1
+	ch3 <- 3
+	close(ch1)
+	close(ch3)
+
+	if !tree.Remove(ch2) {
+		t.Error("Expected ch2 to be removed")
+	}
+	if tree.Remove(ch2) {
+		t.Error("Expected second Remove to report false")
+	}
+
+	tree.Finish()
+	if result := <-tree.Output(); result != 4 {
+		t.Errorf("Expected result to be 4, got %d", result)
+	}
+}
+
+// TestRemoveUnknown tests that removing a channel that was never added is a no-op.
+func TestRemoveUnknown(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false)
+	defer tree.Finish()
+
+	if tree.Remove(make(chan int)) {
+		t.Error("Expected Remove of an unknown channel to report false")
+	}
+}
+
+// TestRemoveOrdered tests that removing an ordered input passes its sibling's
+// values through instead of dropping them.
+func TestRemoveOrdered(t *testing.T) {
+	tree := treeduction.New(func(a, b string) string {
+		return a + b
+	}, 10, true, true)
+
+	ch1 := make(chan string) // Never closed
+	ch2 := make(chan string, 2)
+	tree.Add(ch1, ch2)
+
+	tree.Remove(ch1)
+	ch2 <- "b"
+	ch2 <- "y"
+	close(ch2)
+
+	tree.Finish()
+	if result := <-tree.Output(); result != "by" {
+		t.Errorf("Expected result to be %q, got %q", "by", result)
+	}
+}
+
+// TestRemoveFlush tests that values buffered in a removed input are reduced.
+func TestRemoveFlush(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	ch := make(chan int, 5) // Never closed
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	tree.Add(ch)
+	tree.Remove(ch)
+
+	tree.Finish()
+	if result := <-tree.Output(); result != 6 {
+		t.Errorf("Expected result to be 6, got %d", result)
+	}
+}
+
+// TestRemoveThenSend tests that a value sent after Remove returned stays in
+// the channel.
+func TestRemoveThenSend(t *testing.T) {
+	for range 50 {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false)
+		ch := make(chan int, 1) // Never closed
+		tree.Add(ch)
+		tree.Remove(ch)
+		ch <- 50
+		tree.AddValues(1)
+
+		tree.Finish()
+		if result := <-tree.Output(); result != 1 {
+			t.Fatalf("Expected result to be 1, got %d", result)
+		}
+		if len(ch) != 1 {
+			t.Fatal("Expected the value to stay in the channel")
+		}
+	}
+}
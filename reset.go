@@ -0,0 +1,62 @@
+package treeduction
+
+// Reset returns a finished tree to its state right after New, with the same
+// combiner and options, so that a batch job can reuse it instead of building
+// a new one. The inputs, results, error and counters of the previous run are
+// dropped and Output returns a new channel. Reset waits for the goroutines
+// of the previous run to exit, and returns ErrNotFinished if neither Finish
+// nor Abort was called. It must not be called concurrently with the other
+// methods.
+func (t *tree[T]) Reset() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.finished.Load() {
+		return ErrNotFinished
+	}
+	// The goroutines of the previous run still read the fields below
+	t.running.Wait()
+	<-t.done
+
+	t.levels = levels[T]{}
+	t.frame, t.folding = nil, nil
+	t.runs, t.runsDone = t.runs[:0], nil
+	t.pool = nil
+	t.emitDone = nil
+	t.epochDone = nil
+	t.batchDone = nil
+	t.loop.Store(nil)
+	t.inlined = nil
+	t.local, t.grouped = nil, 0
+	clear(t.sources)
+	t.subtrees = nil
+	clear(t.indexes)
+	t.bufMu.Lock()
+	clear(t.buffers)
+	t.bufMu.Unlock()
+
+	var zero T
+	t.acc, t.folded = zero, false
+	t.err, t.errs = nil, nil
+	t.dropped.Store(0)
+	t.consumed.Store(0)
+	t.emitted.Store(0)
+	t.expired.Store(0)
+	t.rejected.Store(0)
+	t.duplicates.Store(0)
+	if t.latencies != nil {
+		t.latencies.reset()
+	}
+	t.alive.Store(0)
+	t.seq = 0
+	if t.wal != nil {
+		if err := t.wal.truncate(); err != nil {
+			return err
+		}
+	}
+
+	t.start(cap(t.output))
+	t.aborted.Store(false)
+	t.finished.Store(false)
+	return nil
+}
@@ -0,0 +1,42 @@
+package treeduction_test
+
+import (
+	"errors"
+	"testing"
+	"treeduction"
+)
+
+// TestReset tests that a finished tree can be run again.
+func TestReset(t *testing.T) {
+	for _, ordered := range []bool{false, true} {
+		for _, workers := range []int{0, 2} {
+			var opts []treeduction.Option
+			if workers > 0 {
+				opts = append(opts, treeduction.WithMaxWorkers(workers))
+			}
+			tree := treeduction.New(func(a, b int) int {
+				return a + b
+			}, 10, true, ordered, opts...)
+
+			if err := tree.Reset(); !errors.Is(err, treeduction.ErrNotFinished) {
+				t.Errorf("Expected ErrNotFinished, got %v", err)
+			}
+			for run := range 3 {
+				tree.AddValues(1, 2, 3)
+				tree.AddSlice([]int{run})
+				if err := tree.Finish(); err != nil {
+					t.Fatal(err)
+				}
+				if v := <-tree.Output(); v != 6+run {
+					t.Errorf("Expected %d, got %d", 6+run, v)
+				}
+				if err := tree.Reset(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if stats := tree.Stats(); stats.Goroutines > workers+1 || stats.Emitted != 0 {
+				t.Errorf("Expected a fresh tree, got %+v", stats)
+			}
+		}
+	}
+}
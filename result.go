@@ -0,0 +1,76 @@
+package treeduction
+
+import "errors"
+
+// Result is a value or the error of the producer that failed to compute it,
+// see NewResult.
+type Result[T any] struct {
+	Val T
+	Err error
+
+	// Set on the results of a combine, whether Val holds a value
+	merged, ok bool
+}
+
+// Ok reports whether Val holds a value: for a result of the tree, whether
+// at least one of the values reduced into it had no error.
+func (r Result[T]) Ok() bool {
+	if r.merged {
+		return r.ok
+	}
+	return r.Err == nil
+}
+
+// ResultPolicy decides what a tree made by NewResult does with the results
+// that hold an error.
+type ResultPolicy int
+
+const (
+	// FailOnError fails the tree with the first error read, like a combiner
+	// error.
+	FailOnError ResultPolicy = iota
+	// IgnoreErrors drops the results with an error.
+	IgnoreErrors
+	// JoinErrors reduces the values of the results without an error, and
+	// joins the errors of the other ones with errors.Join into the Err of
+	// the result they are reduced into.
+	JoinErrors
+)
+
+// NewResult is like New for inputs of values that come with an error, as
+// they are often produced, so that handling the errors is part of the
+// reduction: combiner reduces the values, and policy decides what happens to
+// the errors.
+func NewResult[T any](combiner func(f T, s T) T, policy ResultPolicy, bufferSize int, waitForAll bool, ordered bool, opts ...Option) Tree[Result[T]] {
+	t := newTree[Result[T]](bufferSize, waitForAll, ordered, opts, nil)
+	t.combiner = t.guard(func(f Result[T], s Result[T]) (Result[T], error) {
+		r := Result[T]{Err: errors.Join(f.Err, s.Err), merged: true, ok: true}
+		switch fok, sok := f.Ok(), s.Ok(); {
+		case fok && sok:
+			r.Val = combiner(f.Val, s.Val)
+		case fok:
+			r.Val = f.Val
+		case sok:
+			r.Val = s.Val
+		default:
+			r.ok = false
+		}
+		return r, nil
+	})
+
+	if policy == JoinErrors {
+		return t
+	}
+	keep := t.filter
+	t.filter = func(r Result[T]) bool {
+		if r.Err != nil {
+			if policy == FailOnError {
+				t.fail(r.Err)
+				t.kill()
+			}
+			return false
+		}
+		return keep == nil || keep(r)
+	}
+	return t
+}
@@ -0,0 +1,73 @@
+package treeduction_test
+
+import (
+	"errors"
+	"testing"
+	"treeduction"
+)
+
+var errShard = errors.New("shard unavailable")
+
+func results(policy treeduction.ResultPolicy) treeduction.Tree[treeduction.Result[int]] {
+	tree := treeduction.NewResult(func(a, b int) int {
+		return a + b
+	}, policy, 10, true, false)
+	tree.AddValues(
+		treeduction.Result[int]{Val: 1},
+		treeduction.Result[int]{Err: errShard},
+		treeduction.Result[int]{Val: 2},
+		treeduction.Result[int]{Err: errShard},
+		treeduction.Result[int]{Val: 3},
+	)
+	return tree
+}
+
+// TestResultFailOnError tests that an error fails the tree.
+func TestResultFailOnError(t *testing.T) {
+	tree := results(treeduction.FailOnError)
+	if err := tree.Finish(); !errors.Is(err, errShard) {
+		t.Errorf("Expected %v from Finish(), got %v", errShard, err)
+	}
+}
+
+// TestResultIgnoreErrors tests that the values with an error are dropped.
+func TestResultIgnoreErrors(t *testing.T) {
+	tree := results(treeduction.IgnoreErrors)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	r := <-tree.Output()
+	if r.Val != 6 || r.Err != nil || !r.Ok() {
+		t.Errorf("Expected 6 without an error, got %d, %v", r.Val, r.Err)
+	}
+}
+
+// TestResultJoinErrors tests that the errors are joined into the result.
+func TestResultJoinErrors(t *testing.T) {
+	tree := results(treeduction.JoinErrors)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	r := <-tree.Output()
+	if r.Val != 6 || !errors.Is(r.Err, errShard) || !r.Ok() {
+		t.Errorf("Expected 6 with %v, got %d, %v", errShard, r.Val, r.Err)
+	}
+	var joined interface{ Unwrap() []error }
+	if !errors.As(r.Err, &joined) {
+		t.Errorf("Expected joined errors, got %v", r.Err)
+	}
+}
+
+// TestResultOnlyErrors tests that a result without any value isn't Ok.
+func TestResultOnlyErrors(t *testing.T) {
+	tree := treeduction.NewResult(func(a, b int) int {
+		return a + b
+	}, treeduction.JoinErrors, 10, true, false)
+	tree.AddValues(treeduction.Result[int]{Err: errShard}, treeduction.Result[int]{Err: errShard})
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-tree.Output(); r.Ok() || !errors.Is(r.Err, errShard) {
+		t.Errorf("Expected no value and %v, got %d, %v", errShard, r.Val, r.Err)
+	}
+}
@@ -0,0 +1,10 @@
+package treeduction
+
+// WithScan makes the output emit the running reduction after every input
+// value, like a prefix sum. Since every input value needs its own total, the
+// nodes only fan the values in, and they are folded one by one at the root.
+func WithScan() Option {
+	return func(o *options) {
+		o.scan = true
+	}
+}
@@ -0,0 +1,91 @@
+package treeduction_test
+
+import (
+	"testing"
+	"treeduction"
+)
+
+// TestScan tests that scan mode emits the running reduction.
+func TestScan(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithScan())
+
+	ch := make(chan int, 4)
+	tree.Add(ch)
+
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	ch <- 4
+	close(ch)
+
+	var totals []int
+	for range 4 {
+		totals = append(totals, <-tree.Output())
+	}
+	tree.Finish()
+
+	expected := []int{1, 3, 6, 10}
+	for i := range expected {
+		if totals[i] != expected[i] {
+			t.Fatalf("Expected totals to be %v, got %v", expected, totals)
+		}
+	}
+}
+
+// TestScanManyInputs tests that the last running value is the full reduction.
+func TestScanManyInputs(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithScan())
+
+	sum := 0
+	for i := range 50 {
+		ch := make(chan int, 1)
+		ch <- i
+		close(ch)
+		sum += i
+		tree.Add(ch)
+	}
+
+	last, prev := 0, -1
+	for last != sum {
+		last = <-tree.Output()
+		if last < prev {
+			t.Fatalf("Running total went down from %d to %d", prev, last)
+		}
+		prev = last
+	}
+	tree.Finish()
+}
+
+// TestScanPerInput tests that scan mode emits a total after every input value,
+// even when the values come from several channels of one Add.
+func TestScanPerInput(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithScan())
+
+	channels := make([]<-chan int, 4)
+	for i := range channels {
+		ch := make(chan int, 1)
+		ch <- 1
+		close(ch)
+		channels[i] = ch
+	}
+	tree.Add(channels...)
+
+	totals := make([]int, 4)
+	for i := range totals {
+		totals[i] = <-tree.Output()
+	}
+	tree.Finish()
+
+	expected := []int{1, 2, 3, 4}
+	for i := range expected {
+		if totals[i] != expected[i] {
+			t.Fatalf("Expected totals to be %v, got %v", expected, totals)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package treeduction
+
+import (
+	"context"
+	"iter"
+)
+
+// AddSeq starts reducing the values of the given iterators. Each iterator is
+// pulled in its own goroutine until it ends or the tree is cancelled.
+func (t *tree[T]) AddSeq(seqs ...iter.Seq[T]) error {
+	out := make([]<-chan T, len(seqs))
+	chans := make([]chan T, len(seqs))
+	for i := range seqs {
+		chans[i] = make(chan T, t.bufSize)
+		out[i] = chans[i]
+	}
+	if err := t.Add(out...); err != nil {
+		return err
+	}
+
+	for i, seq := range seqs {
+		c := chans[i]
+		t.spawn(func() {
+			defer close(c)
+			for v := range seq {
+				select {
+				case c <- v:
+				case <-t.ctx.Done():
+					return
+				}
+			}
+		})
+	}
+	return nil
+}
+
+// Results returns an iterator over the output. With waitForAll it finishes
+// the tree first. Breaking out of the loop finishes the tree and discards the
+// rest of the output.
+func (t *tree[T]) Results() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range t.Results2() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Results2 is like Results, but the error of the tree is yielded after the
+// last result, with the zero value.
+func (t *tree[T]) Results2() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		if t.waitForAll && t.markFinished() {
+			t.finish()
+			t.settle()
+		}
+
+		for v := range t.output {
+			if !yield(v, nil) {
+				t.spawn(func() {
+					for range t.output {
+					}
+				})
+				if t.markFinished() {
+					t.finish()
+					t.settle()
+				}
+				return
+			}
+		}
+		if err := t.error(); err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// Drain finishes the tree with FinishContext and returns every result left
+// on the output, with the error FinishContext returns.
+func (t *tree[T]) Drain(ctx context.Context) ([]T, error) {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- t.FinishContext(ctx)
+	}()
+
+	var vs []T
+	for v := range t.output {
+		vs = append(vs, v)
+	}
+	return vs, <-errc
+}
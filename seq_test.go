@@ -0,0 +1,151 @@
+package treeduction_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestAddSeq tests reducing iterators.
+func TestAddSeq(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	count := func(n int) iter.Seq[int] {
+		return func(yield func(int) bool) {
+			for i := 1; i <= n; i++ {
+				if !yield(i) {
+					return
+				}
+			}
+		}
+	}
+	if err := tree.AddSeq(slices.Values([]int{1, 2, 3}), count(4)); err != nil {
+		t.Errorf("Unexpected error from AddSeq(): %v", err)
+	}
+
+	tree.Finish()
+	if result := <-tree.Output(); result != 16 {
+		t.Errorf("Expected result to be 16, got %d", result)
+	}
+}
+
+// TestAddSeqCancel tests that an endless iterator stops being pulled once the
+// tree is finished.
+func TestAddSeqCancel(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false)
+
+	stopped := make(chan struct{})
+	tree.AddSeq(func(yield func(int) bool) {
+		defer close(stopped)
+		for yield(1) {
+		}
+	})
+
+	<-tree.Output()
+	go func() {
+		for range tree.Output() {
+		}
+	}()
+	tree.Finish()
+	<-stopped
+}
+
+// TestResults tests ranging over the output of a waitForAll tree.
+func TestResults(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	tree.AddValues(1, 2, 3)
+
+	var results []int
+	for v := range tree.Results() {
+		results = append(results, v)
+	}
+	if !slices.Equal(results, []int{6}) {
+		t.Errorf("Expected results to be [6], got %v", results)
+	}
+}
+
+// TestResultsBreak tests that breaking out of the results finishes the tree.
+func TestResultsBreak(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false)
+
+	ch := make(chan int) // Never closed
+	tree.Add(ch)
+	ch <- 1
+
+	for v := range tree.Results() {
+		if v != 1 {
+			t.Errorf("Expected result to be 1, got %d", v)
+		}
+		break
+	}
+	if err := tree.Add(ch); !errors.Is(err, treeduction.ErrFinished) {
+		t.Errorf("Expected ErrFinished from Add(), got %v", err)
+	}
+}
+
+// TestResults2 tests that the error of the tree is yielded last.
+func TestResults2(t *testing.T) {
+	errOdd := errors.New("odd")
+	tree := treeduction.NewFallible(func(a, b int) (int, error) {
+		return a + b, errOdd
+	}, 10, true, false)
+	tree.AddValues(1, 2)
+
+	var err error
+	for _, e := range tree.Results2() {
+		err = e
+	}
+	if !errors.Is(err, errOdd) {
+		t.Errorf("Expected %v from Results2(), got %v", errOdd, err)
+	}
+}
+
+// TestDrain tests that Drain returns every result.
+func TestDrain(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	tree.AddValues(1, 2, 3)
+	vs, err := tree.Drain(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 1 || vs[0] != 6 {
+		t.Errorf("Expected [6], got %v", vs)
+	}
+}
+
+// TestDrainCancel tests that Drain stops reading the inputs once ctx is
+// done, and returns the partial result.
+func TestDrainCancel(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	c := make(chan int, 2)
+	c <- 1
+	c <- 2
+	tree.Add(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	vs, err := tree.Drain(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if len(vs) != 1 || vs[0] != 3 {
+		t.Errorf("Expected [3], got %v", vs)
+	}
+}
@@ -0,0 +1,60 @@
+package treeduction
+
+// WithSequentialFallback reduces every value in a single goroutine, with a
+// left fold in the order the values are read, instead of a tree, behind the
+// same API. A combiner that should be associative can then be checked
+// against the same calling code: with waitForAll the result is
+// combiner(...combiner(combiner(v1, v2), v3)..., vn), without it every
+// result folds the values that were ready together. It has no effect in
+// ordered mode or with WithMaxWorkers, and the priorities of the inputs are
+// ignored.
+func WithSequentialFallback() Option {
+	return func(o *options) {
+		o.sequential = true
+	}
+}
+
+// sequence returns the node that folds every value, creating it first. The
+// roots left by Rebalance become its first children.
+func (t *tree[T]) sequence() *knode[T] {
+	if t.folding != nil {
+		return t.folding
+	}
+
+	n := &knode[T]{fanIn: make(chan T, t.bufAt(1)), out: make(chan T, t.bufAt(1))}
+	t.track(n.out, "sequential")
+	t.track(n.fanIn, "")
+	closed := t.nodeCreated()
+	t.spawn(func() {
+		for v := range n.fanIn {
+			// With waitForAll the values are folded one by one at the root
+			if t.waitForAll || t.opts.perValue() {
+				t.put(n.out, v)
+				continue
+			}
+		ready:
+			for {
+				select {
+				case w, ok := <-n.fanIn:
+					if !ok {
+						break ready
+					}
+					v = t.combine(v, w)
+				default:
+					break ready
+				}
+			}
+			t.put(n.out, v)
+		}
+		closed()
+		t.untrack(n.out)
+		close(n.out)
+	})
+
+	for _, r := range t.levels.takeRoots() {
+		t.adopt(n, r)
+	}
+	t.levels.get(0).root = n.out
+	t.folding = n
+	return n
+}
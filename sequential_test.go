@@ -0,0 +1,58 @@
+package treeduction_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"treeduction"
+)
+
+// TestSequentialFallback tests that the values are folded from the left one
+// by one.
+func TestSequentialFallback(t *testing.T) {
+	var partials atomic.Int64
+	tree := treeduction.New(func(a, b int) int {
+		// The right side is always a value read from an input
+		if b != 1 {
+			partials.Add(1)
+		}
+		return a + b
+	}, 10, true, false, treeduction.WithSequentialFallback())
+
+	inputs := make([]<-chan int, 8)
+	for i := range inputs {
+		inputs[i] = ones(10)
+	}
+	tree.Add(inputs[:4]...)
+	tree.Add(inputs[4:]...)
+	tree.Rebalance()
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 80 {
+		t.Errorf("Expected 80, got %d", v)
+	}
+	if n := partials.Load(); n != 0 {
+		t.Errorf("Expected only values on the right, got %d partials", n)
+	}
+}
+
+// TestSequentialFallbackOrder tests that a non-associative combiner sees the
+// values of an input in order.
+func TestSequentialFallbackOrder(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a*10 + b
+	}, 10, true, false, treeduction.WithSequentialFallback())
+
+	c := make(chan int, 5)
+	for i := 1; i <= 5; i++ {
+		c <- i
+	}
+	close(c)
+	tree.Add(c)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 12345 {
+		t.Errorf("Expected 12345, got %d", v)
+	}
+}
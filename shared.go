@@ -0,0 +1,61 @@
+package treeduction
+
+import "sync"
+
+// Pool is a set of workers shared by trees, see WithPool.
+type Pool struct {
+	work chan func()
+	wg   sync.WaitGroup
+}
+
+// NewPool starts n workers, which run until Close.
+func NewPool(n int) *Pool {
+	if n <= 0 {
+		panic("treeduction: number of workers must be positive")
+	}
+	p := &Pool{work: make(chan func())}
+	p.wg.Add(n)
+	for range n {
+		go func() {
+			defer p.wg.Done()
+			for f := range p.work {
+				f()
+			}
+		}()
+	}
+	return p
+}
+
+// Close stops the workers. The trees that use the pool must be finished
+// first.
+func (p *Pool) Close() {
+	close(p.work)
+	p.wg.Wait()
+}
+
+// WithPool runs the tree on the workers of p, like WithMaxWorkers but
+// without starting and stopping workers for every tree, for services that
+// create many short-lived trees. A tree whose output is full and blocks
+// holds a worker of the pool until the output is read.
+func WithPool(p *Pool) Option {
+	return func(o *options) {
+		o.shared = p
+	}
+}
+
+// dispatch hands the values read by the readers to the workers of the pool,
+// until the tree is cancelled and every reader is gone.
+func (t *tree[T]) dispatch(shared *Pool) {
+	defer t.wg.Done()
+
+	var running sync.WaitGroup
+	defer running.Wait()
+	for w := range t.pool.queue {
+		t.waitResumed(w)
+		running.Add(1)
+		shared.work <- func() {
+			defer running.Done()
+			t.work(w)
+		}
+	}
+}
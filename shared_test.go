@@ -0,0 +1,34 @@
+package treeduction_test
+
+import (
+	"sync"
+	"testing"
+	"treeduction"
+)
+
+// TestPool tests trees sharing the workers of a pool.
+func TestPool(t *testing.T) {
+	pool := treeduction.NewPool(4)
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tree := treeduction.New(func(a, b int) int {
+				return a + b
+			}, 10, true, i%2 == 0, treeduction.WithPool(pool))
+			for j := range 10 {
+				tree.AddValues(j, i)
+			}
+			if err := tree.Finish(); err != nil {
+				t.Error(err)
+			}
+			if v := <-tree.Output(); v != 45+10*i {
+				t.Errorf("Expected %d, got %d", 45+10*i, v)
+			}
+		}()
+	}
+	wg.Wait()
+}
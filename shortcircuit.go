@@ -0,0 +1,22 @@
+package treeduction
+
+// WithShortCircuit stops reading the inputs as soon as done returns true for
+// the running reduction: the accumulated result with waitForAll, a value
+// sent to the output otherwise. The values already inside the tree are still
+// reduced, and Finish no longer waits for the inputs to close, e.g. to stop
+// searching the remaining shards once a threshold is crossed. Like the
+// values of an input removed from the tree, the values of the inputs that
+// weren't read are lost. In ordered mode with waitForAll, the result is only
+// reduced by Finish.
+func WithShortCircuit[T any](done func(T) bool) TypedOption[T] {
+	return func(_ *options, ty *typed[T]) {
+		ty.satisfied = done
+	}
+}
+
+// check stops reading the inputs if v satisfies the short circuit.
+func (t *tree[T]) check(v T) {
+	if t.satisfied != nil && t.satisfied(v) {
+		t.cancel()
+	}
+}
@@ -0,0 +1,80 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// endless returns an input that never closes by itself, and a function to
+// stop it.
+func endless() (<-chan int, func()) {
+	c := make(chan int)
+	done := make(chan struct{})
+	go func() {
+		defer close(c)
+		for {
+			select {
+			case c <- 1:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return c, func() { close(done) }
+}
+
+// TestShortCircuit tests that Finish returns once the result crossed the
+// threshold, though the inputs never close.
+func TestShortCircuit(t *testing.T) {
+	tree := treeduction.With(treeduction.WithShortCircuit(func(v int) bool {
+		return v >= 1000
+	})).New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	for range 4 {
+		c, stop := endless()
+		defer stop()
+		tree.Add(c)
+	}
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v < 1000 {
+		t.Errorf("Expected at least 1000, got %d", v)
+	}
+}
+
+// TestShortCircuitStreaming tests that the inputs stop being read once an
+// output value satisfies the predicate.
+func TestShortCircuitStreaming(t *testing.T) {
+	tree := treeduction.With(treeduction.WithShortCircuit(func(v int) bool {
+		return v >= 50
+	})).New(func(a, b int) int {
+		return max(a, b)
+	}, 10, false, false)
+
+	c := make(chan int)
+	tree.Add(c)
+	go func() {
+		for range tree.Output() {
+		}
+	}()
+	sent := 0
+	for ; sent < 1000; sent++ {
+		select {
+		case c <- sent:
+			continue
+		case <-time.After(100 * time.Millisecond):
+		}
+		break
+	}
+	close(c)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if sent <= 50 || sent == 1000 {
+		t.Errorf("Expected the input to stop being read after 50, stopped after %d", sent)
+	}
+}
@@ -0,0 +1,64 @@
+package treeduction
+
+import "time"
+
+// Retry is how a sink is retried, see WithSink.
+type Retry struct {
+	// Attempts is the number of calls for a result, 1 if it is lower
+	Attempts int
+	// Backoff is the wait after the first failed call, doubled after every
+	// other one up to MaxBackoff if it is set
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// WithSink calls sink for every result instead of leaving it on the output
+// (with waitForAll, for the final result), so that no goroutine is needed to
+// move the results to a database or a queue. A call that fails is retried
+// according to retry, and the error of the last attempt fails the tree. The
+// results are passed to sink one at a time, and the tree waits for sink like
+// for a consumer of the output, so Finish returns once the last result was
+// written. The output is read by the tree and closed when it finishes, it
+// must not be read.
+func WithSink[T any](sink func(T) error, retry Retry) TypedOption[T] {
+	return func(o *options, ty *typed[T]) {
+		ty.sink = sink
+		o.retry = retry
+	}
+}
+
+// runSink writes the results of the output to the sink until the output is
+// closed. Once the tree failed or was aborted, the results are dropped.
+func (t *tree[T]) runSink(out <-chan T, done chan<- struct{}) {
+	defer close(done)
+	for v := range out {
+		if t.aborted.Load() || t.life.Err() != nil || t.error() != nil {
+			continue
+		}
+		if err := t.write(v); err != nil {
+			t.fail(err)
+		}
+	}
+}
+
+// write calls the sink with a result until it succeeds or runs out of
+// attempts.
+func (t *tree[T]) write(v T) error {
+	r := t.opts.retry
+	backoff := r.Backoff
+	for attempt := 1; ; attempt++ {
+		err := t.sink(v)
+		if err == nil || attempt >= r.Attempts {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-t.life.Done():
+			return err
+		}
+		backoff *= 2
+		if r.MaxBackoff > 0 {
+			backoff = min(backoff, r.MaxBackoff)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package treeduction_test
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestSink tests that the results are written to the sink, with retries.
+func TestSink(t *testing.T) {
+	var mu sync.Mutex
+	var written []int
+	calls := 0
+	sink := func(v int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls%2 == 1 {
+			return errors.New("unavailable")
+		}
+		written = append(written, v)
+		return nil
+	}
+	tree := treeduction.With(treeduction.WithSink(sink, treeduction.Retry{Attempts: 2, Backoff: time.Millisecond})).New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	tree.AddValues(1, 2, 3)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(written, []int{6}) || calls != 2 {
+		t.Errorf("Expected 6 to be written at the second call, got %v after %d calls", written, calls)
+	}
+	if _, ok := <-tree.Output(); ok {
+		t.Error("Expected the output to be closed")
+	}
+}
+
+// TestSinkFailure tests that a sink that keeps failing fails the tree.
+func TestSinkFailure(t *testing.T) {
+	unavailable := errors.New("unavailable")
+	calls := 0
+	tree := treeduction.With(treeduction.WithSink(func(int) error {
+		calls++
+		return unavailable
+	}, treeduction.Retry{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond})).New(func(a, b int) int {
+		return a + b
+	}, 10, false, false)
+
+	tree.AddValues(1)
+	for deadline := time.Now().Add(time.Second); tree.Err() == nil && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if err := tree.Finish(); !errors.Is(err, unavailable) {
+		t.Errorf("Expected the error of the sink, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+}
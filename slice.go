@@ -0,0 +1,75 @@
+package treeduction
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ReduceSlice reduces data in parallel without a tree of channels: it is
+// split into parallelism chunks (GOMAXPROCS if it isn't positive) that are
+// folded sequentially, and the results of the chunks are combined two by two
+// like an ordered tree. The combiner is always called with the earlier value
+// on the left, so it only needs to be associative. It returns the zero value
+// for an empty slice, and a panic of the combiner is raised again in the
+// caller.
+func ReduceSlice[T any](combiner func(f T, s T) T, data []T, parallelism int) T {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	parallelism = min(parallelism, len(data))
+	if parallelism == 0 {
+		var zero T
+		return zero
+	}
+
+	var mu sync.Mutex
+	var panicked any
+	parts := make([]T, parallelism)
+	run := func(wg *sync.WaitGroup, f func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if p := recover(); p != nil {
+					mu.Lock()
+					panicked = p
+					mu.Unlock()
+				}
+			}()
+			f()
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for i := range parts {
+		chunk := data[i*len(data)/parallelism : (i+1)*len(data)/parallelism]
+		run(&wg, func() {
+			acc := chunk[0]
+			for _, v := range chunk[1:] {
+				acc = combiner(acc, v)
+			}
+			parts[i] = acc
+		})
+	}
+	wg.Wait()
+
+	// Combine the neighbours of each level until one is left
+	for len(parts) > 1 && panicked == nil {
+		next := make([]T, (len(parts)+1)/2)
+		for i := range next {
+			if 2*i+1 == len(parts) {
+				next[i] = parts[2*i]
+				continue
+			}
+			run(&wg, func() {
+				next[i] = combiner(parts[2*i], parts[2*i+1])
+			})
+		}
+		wg.Wait()
+		parts = next
+	}
+	if panicked != nil {
+		panic(panicked)
+	}
+	return parts[0]
+}
@@ -0,0 +1,57 @@
+package treeduction_test
+
+import (
+	"strings"
+	"testing"
+	"treeduction"
+)
+
+// TestReduceSlice tests the parallel reduction of a slice, for every
+// parallelism up to more chunks than values.
+func TestReduceSlice(t *testing.T) {
+	data := make([]int, 1000)
+	for i := range data {
+		data[i] = i + 1
+	}
+	for parallelism := -1; parallelism <= 1001; parallelism += 7 {
+		v := treeduction.ReduceSlice(func(a, b int) int {
+			return a + b
+		}, data, parallelism)
+		if v != 500500 {
+			t.Errorf("Expected 500500 with parallelism %d, got %d", parallelism, v)
+		}
+	}
+}
+
+// TestReduceSliceOrder tests that the values are combined in order.
+func TestReduceSliceOrder(t *testing.T) {
+	data := strings.Split("abcdefghijklmnopqrstuvwxyz", "")
+	v := treeduction.ReduceSlice(func(a, b string) string {
+		return a + b
+	}, data, 5)
+	if v != "abcdefghijklmnopqrstuvwxyz" {
+		t.Errorf("Expected the alphabet, got %s", v)
+	}
+}
+
+// TestReduceSliceEmpty tests that an empty slice reduces to the zero value.
+func TestReduceSliceEmpty(t *testing.T) {
+	if v := treeduction.ReduceSlice(func(a, b int) int {
+		return a + b
+	}, nil, 4); v != 0 {
+		t.Errorf("Expected 0, got %d", v)
+	}
+}
+
+// TestReduceSlicePanic tests that a panic of the combiner reaches the
+// caller.
+func TestReduceSlicePanic(t *testing.T) {
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("Expected the panic of the combiner, got %v", p)
+		}
+	}()
+	treeduction.ReduceSlice(func(a, b int) int {
+		panic("boom")
+	}, []int{1, 2, 3, 4}, 2)
+}
@@ -0,0 +1,54 @@
+package treeduction
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// SourceStats is a snapshot of the reading of an input, see Stats.
+type SourceStats struct {
+	// Read is the number of values read from the input.
+	Read int64
+	// Last is when the last value was read, zero before the first one.
+	Last time.Time
+	// Waited is the time the tree spent waiting for the input to send a
+	// value, while it was ready to read one.
+	Waited time.Duration
+	// Dropped is the number of values dropped with WithDropWhenFull or
+	// WithFaultInjection.
+	Dropped int64
+}
+
+// wait counts the time the tree waited for a value of the source since it
+// was ready to read one.
+func (src *source) wait(since time.Time) {
+	src.waited.Add(int64(time.Since(since)))
+}
+
+func (src *source) stats() SourceStats {
+	s := SourceStats{
+		Read:    src.read.Load(),
+		Waited:  time.Duration(src.waited.Load()),
+		Dropped: src.dropped.Load(),
+	}
+	if last := src.last.Load(); last != 0 {
+		s.Last = time.Unix(0, last)
+	}
+	return s
+}
+
+// SlowestSources returns up to k of the inputs that are still being read,
+// the ones the tree waited the longest for first. In ordered mode they are
+// the inputs that hold up the pairing of the rounds.
+func (t *tree[T]) SlowestSources(k int) []<-chan T {
+	sources := t.Stats().Sources
+	slowest := make([]<-chan T, 0, len(sources))
+	for in := range sources {
+		slowest = append(slowest, in)
+	}
+	slices.SortFunc(slowest, func(a, b <-chan T) int {
+		return cmp.Compare(sources[b].Waited, sources[a].Waited)
+	})
+	return slowest[:min(k, len(slowest))]
+}
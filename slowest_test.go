@@ -0,0 +1,48 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestSlowestSources tests that the input the tree waits for is found.
+func TestSlowestSources(t *testing.T) {
+	for _, opts := range [][]treeduction.Option{nil, {treeduction.WithMaxWorkers(2)}} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, false, true, opts...)
+
+		fast, slow := make(chan int, 3), make(chan int)
+		for i := range 3 {
+			fast <- i
+		}
+		start := time.Now()
+		tree.Add(fast, slow)
+		for i := range 3 {
+			time.Sleep(20 * time.Millisecond)
+			slow <- i
+			<-tree.Output()
+		}
+
+		s := tree.Stats()
+		for deadline := time.Now().Add(time.Second); s.Sources[slow].Read < 3 && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+			s = tree.Stats()
+		}
+		if got := tree.SlowestSources(1); len(got) != 1 || got[0] != slow {
+			t.Errorf("Expected the slow input to be the slowest, got %v", got)
+		}
+		if n := len(tree.SlowestSources(5)); n != 2 {
+			t.Errorf("Expected 2 inputs, got %d", n)
+		}
+		if w := s.Sources[slow].Waited; w < 50*time.Millisecond {
+			t.Errorf("Expected to wait at least 50ms for the slow input, got %v", w)
+		}
+		if ss := s.Sources[fast]; ss.Read != 3 || ss.Last.Before(start) {
+			t.Errorf("Expected 3 values read from the fast input after the start, got %+v", ss)
+		}
+
+		tree.Abort()
+	}
+}
@@ -0,0 +1,14 @@
+package treeduction
+
+// Snapshot returns the reduction of the values that made it through the
+// whole tree so far, without stopping it, e.g. to show the progress of a long
+// aggregation. It reports false if no value did yet. Only waitForAll trees
+// have a snapshot: otherwise those values are the results on the output. In
+// ordered mode the rounds are folded by Finish, so there is none before.
+func (t *tree[T]) Snapshot() (T, bool) {
+	if !t.waitForAll {
+		var zero T
+		return zero, false
+	}
+	return t.final()
+}
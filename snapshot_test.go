@@ -0,0 +1,55 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestSnapshot tests reading the partial reduction of a running tree.
+func TestSnapshot(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	if _, ok := tree.Snapshot(); ok {
+		t.Error("Expected no snapshot before any value")
+	}
+
+	ch := make(chan int) // Closed later
+	tree.AddValues(1)
+	tree.AddValues(2)
+	tree.Add(ch)
+
+	// The values of the closed inputs reach the root before ch is closed
+	deadline := time.Now().Add(time.Second)
+	v, ok := tree.Snapshot()
+	for v != 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		v, ok = tree.Snapshot()
+	}
+	if !ok || v != 3 {
+		t.Errorf("Expected the snapshot to be 3, got %d (%v)", v, ok)
+	}
+
+	ch <- 4
+	close(ch)
+	tree.Finish()
+	if result := <-tree.Output(); result != 7 {
+		t.Errorf("Expected result to be 7, got %d", result)
+	}
+}
+
+// TestSnapshotStreaming tests that a tree without waitForAll has no snapshot.
+func TestSnapshotStreaming(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false)
+	defer tree.Finish()
+
+	tree.AddValues(1, 2)
+	<-tree.Output()
+	if _, ok := tree.Snapshot(); ok {
+		t.Error("Expected no snapshot without waitForAll")
+	}
+}
@@ -0,0 +1,284 @@
+// Package soak runs a streaming tree for a long time under churn, with
+// inputs added, removed and closed at random, and checks after every round
+// that nothing was lost or duplicated and that the tree didn't leak
+// goroutines, to gain confidence before running a tree in a long-lived
+// daemon:
+//
+//	go test treeduction/soak -soak 2h
+package soak
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"treeduction"
+)
+
+// Tally is the value reduced by the tree: every value sent by an input
+// counts 1, with a random Sum.
+type Tally struct {
+	Count int64
+	Sum   int64
+}
+
+func add(a, b Tally) Tally {
+	return Tally{a.Count + b.Count, a.Sum + b.Sum}
+}
+
+// Config is a soak test. The zero values get the defaults.
+type Config struct {
+	// How long to run, 10s by default
+	Duration time.Duration
+	// How long the inputs churn before the harness waits for the tree to
+	// settle and checks it, 1s by default
+	Round time.Duration
+	// The most inputs open at the same time, 32 by default
+	MaxInputs int
+	// The buffer size and the options of the tree
+	Buffer  int
+	Options []treeduction.Option
+	Typed   []treeduction.TypedOption[Tally]
+	// How many goroutines a round may leave behind, on top of the ones
+	// running after the first round, 8 by default
+	Slack int
+	// How long the tree may take to settle, 10s by default
+	Settle time.Duration
+	// The seed of the churn, to replay a failing run
+	Seed uint64
+	// Called after every round, e.g. to log the progress of a long run
+	Progress func(Report)
+}
+
+// Report sums up a run so far.
+type Report struct {
+	Rounds  int
+	Added   int
+	Removed int
+	Closed  int
+	// What the inputs sent, and what came out of the tree
+	Sent, Received Tally
+	// The goroutines running after each round
+	Goroutines []int
+}
+
+// input is an input of the tree and the goroutine feeding it.
+type input struct {
+	c    chan Tally
+	stop chan struct{}
+	done chan struct{}
+}
+
+// run holds the state of a soak test.
+type run struct {
+	cfg    Config
+	tree   treeduction.Tree[Tally]
+	rng    *rand.Rand
+	inputs []*input
+	report Report
+
+	count, sum atomic.Int64
+
+	mu       sync.Mutex
+	received Tally
+}
+
+// Run runs the soak test described by cfg until cfg.Duration is over or ctx
+// is done, and returns the report with the first invariant that broke.
+func Run(ctx context.Context, cfg Config) (Report, error) {
+	if cfg.Duration <= 0 {
+		cfg.Duration = 10 * time.Second
+	}
+	if cfg.Round <= 0 {
+		cfg.Round = time.Second
+	}
+	if cfg.MaxInputs <= 0 {
+		cfg.MaxInputs = 32
+	}
+	if cfg.Slack <= 0 {
+		cfg.Slack = 8
+	}
+	if cfg.Settle <= 0 {
+		cfg.Settle = 10 * time.Second
+	}
+
+	before := runtime.NumGoroutine()
+	r := &run{
+		cfg:  cfg,
+		tree: treeduction.With(cfg.Typed...).New(add, cfg.Buffer, false, false, cfg.Options...),
+		rng:  rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+	}
+	received := make(chan struct{})
+	go func() {
+		defer close(received)
+		for v := range r.tree.Output() {
+			r.mu.Lock()
+			r.received = add(r.received, v)
+			r.mu.Unlock()
+		}
+	}()
+
+	err := r.rounds(ctx, time.Now().Add(cfg.Duration))
+	r.stopInputs()
+	if ferr := r.tree.Finish(); err == nil && ferr != nil {
+		err = fmt.Errorf("soak: finish: %w", ferr)
+	}
+	<-received
+	if err == nil {
+		if n, ok := settle(cfg.Settle, before); !ok {
+			err = fmt.Errorf("soak: %d goroutines left after Finish, %d before New", n, before)
+		}
+	}
+	return r.report, err
+}
+
+// rounds churns the inputs round after round until the deadline.
+func (r *run) rounds(ctx context.Context, deadline time.Time) error {
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		end := time.Now().Add(r.cfg.Round)
+		if end.After(deadline) {
+			end = deadline
+		}
+		r.churn(ctx, end)
+		if err := r.check(); err != nil {
+			return err
+		}
+		if r.cfg.Progress != nil {
+			r.cfg.Progress(r.report)
+		}
+	}
+	return nil
+}
+
+// churn adds, removes and closes inputs at random until end.
+func (r *run) churn(ctx context.Context, end time.Time) {
+	for time.Now().Before(end) && ctx.Err() == nil {
+		switch n := r.rng.IntN(10); {
+		case len(r.inputs) == 0 || n < 4 && len(r.inputs) < r.cfg.MaxInputs:
+			r.addInputs(1 + r.rng.IntN(4))
+		case n < 6:
+			in := r.take()
+			r.tree.Remove(in.c)
+			close(in.stop)
+			<-in.done
+			r.report.Removed++
+		case n < 8:
+			in := r.take()
+			close(in.stop)
+			<-in.done
+			r.report.Closed++
+		case n < 9:
+			r.tree.Rebalance()
+		}
+		time.Sleep(time.Duration(r.rng.IntN(1000)) * time.Microsecond)
+	}
+}
+
+// addInputs adds n inputs in one Add, each sending values at its own pace
+// until it is stopped or runs out of values.
+func (r *run) addInputs(n int) {
+	n = min(n, r.cfg.MaxInputs-len(r.inputs))
+	chans := make([]<-chan Tally, n)
+	for i := range n {
+		in := &input{c: make(chan Tally), stop: make(chan struct{}), done: make(chan struct{})}
+		go r.produce(in, r.rng.Uint64(), 1+r.rng.IntN(1000))
+		r.inputs = append(r.inputs, in)
+		chans[i] = in.c
+	}
+	r.tree.Add(chans...)
+	r.report.Added += n
+}
+
+// produce sends up to n values on in, counting the ones the tree took.
+func (r *run) produce(in *input, seed uint64, n int) {
+	defer close(in.done)
+	defer close(in.c)
+	rng := rand.New(rand.NewPCG(seed, seed))
+	for range n {
+		v := Tally{1, rng.Int64N(1000)}
+		select {
+		case in.c <- v:
+			r.count.Add(v.Count)
+			r.sum.Add(v.Sum)
+		case <-in.stop:
+			return
+		}
+		if d := rng.IntN(200); d < 100 {
+			time.Sleep(time.Duration(d) * time.Microsecond)
+		}
+	}
+}
+
+// take picks an input at random and forgets it.
+func (r *run) take() *input {
+	i := r.rng.IntN(len(r.inputs))
+	in := r.inputs[i]
+	r.inputs[i] = r.inputs[len(r.inputs)-1]
+	r.inputs = r.inputs[:len(r.inputs)-1]
+	return in
+}
+
+// stopInputs closes the remaining inputs once their goroutines are done.
+func (r *run) stopInputs() {
+	for _, in := range r.inputs {
+		close(in.stop)
+		<-in.done
+	}
+	r.report.Closed += len(r.inputs)
+	r.inputs = nil
+}
+
+// check ends a round: every input is closed, and once the tree has settled
+// everything that was sent must have come out, without more goroutines
+// than after the first round.
+func (r *run) check() error {
+	r.stopInputs()
+	r.report.Rounds++
+	r.report.Sent = Tally{r.count.Load(), r.sum.Load()}
+
+	for start := time.Now(); ; time.Sleep(time.Millisecond) {
+		r.mu.Lock()
+		r.report.Received = r.received
+		r.mu.Unlock()
+		if r.report.Received == r.report.Sent {
+			break
+		}
+		if r.report.Received.Count > r.report.Sent.Count {
+			return fmt.Errorf("soak: round %d: received %+v, more than the %+v sent", r.report.Rounds, r.report.Received, r.report.Sent)
+		}
+		if time.Since(start) > r.cfg.Settle {
+			return fmt.Errorf("soak: round %d: received %+v after %v, sent %+v", r.report.Rounds, r.report.Received, r.cfg.Settle, r.report.Sent)
+		}
+	}
+
+	limit := runtime.NumGoroutine()
+	if len(r.report.Goroutines) > 0 {
+		limit = r.report.Goroutines[0] + r.cfg.Slack
+	}
+	n, ok := settle(r.cfg.Settle, limit)
+	r.report.Goroutines = append(r.report.Goroutines, n)
+	if !ok {
+		return fmt.Errorf("soak: round %d: %d goroutines, %d after the first round", r.report.Rounds, n, r.report.Goroutines[0])
+	}
+	return nil
+}
+
+// settle waits up to d for the number of goroutines to drop to limit, and
+// returns the last one seen.
+func settle(d time.Duration, limit int) (int, bool) {
+	deadline := time.Now().Add(d)
+	for {
+		n := runtime.NumGoroutine()
+		if n <= limit {
+			return n, true
+		}
+		if time.Now().After(deadline) {
+			return n, false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
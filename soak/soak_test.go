@@ -0,0 +1,58 @@
+package soak_test
+
+import (
+	"context"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"treeduction"
+	"treeduction/soak"
+)
+
+var duration = flag.Duration("soak", 2*time.Second, "how long to run the soak tests")
+
+func TestSoak(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []treeduction.Option
+	}{
+		{"binary", nil},
+		{"batches", []treeduction.Option{treeduction.WithBatchSize(8)}},
+		{"kary", []treeduction.Option{treeduction.WithStrategy(treeduction.KAry(4))}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := soak.Run(context.Background(), soak.Config{
+				Duration: *duration,
+				Round:    *duration / 4,
+				Buffer:   4,
+				Options:  tt.opts,
+				Seed:     1,
+				Progress: func(r soak.Report) {
+					t.Logf("round %d: %d values, %d goroutines", r.Rounds, r.Received.Count, r.Goroutines[len(r.Goroutines)-1])
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Rounds == 0 || r.Added == 0 || r.Sent.Count == 0 {
+				t.Errorf("Expected some churn, got %+v", r)
+			}
+		})
+	}
+}
+
+// TestLostValues tests that the harness notices values lost by the tree.
+func TestLostValues(t *testing.T) {
+	_, err := soak.Run(context.Background(), soak.Config{
+		Duration: 100 * time.Millisecond,
+		Settle:   100 * time.Millisecond,
+		Typed: []treeduction.TypedOption[soak.Tally]{treeduction.WithFilter(func(v soak.Tally) bool {
+			return v.Sum%2 == 0
+		})},
+	})
+	if err == nil || !strings.Contains(err.Error(), "received") {
+		t.Errorf("Expected an error about the values received, got %v", err)
+	}
+}
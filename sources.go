@@ -0,0 +1,110 @@
+package treeduction
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// SourcePolicy decides what the tree does when an input reports an error,
+// see AddWithErr.
+type SourcePolicy int
+
+const (
+	// FailFast fails the tree with the first error of an input, like a
+	// combiner error (the default).
+	FailFast SourcePolicy = iota
+	// SkipSource stops reading the input that failed, as if it was removed,
+	// and goes on with the other ones.
+	SkipSource
+	// CollectErrors keeps reading the input that failed.
+	CollectErrors
+)
+
+// WithSourcePolicy sets what happens when an input added with AddWithErr
+// reports an error, see SourcePolicy.
+func WithSourcePolicy(p SourcePolicy) Option {
+	return func(o *options) {
+		o.sourcePolicy = p
+	}
+}
+
+// AddWithErr is like Add for a single input, whose producer reports its
+// failures on errs. What happens then depends on the SourcePolicy. The
+// errors that don't fail the tree are collected, and returned by Finish and
+// Errors. The errors sent on errs before Finish are all reported.
+func (t *tree[T]) AddWithErr(in <-chan T, errs <-chan error) error {
+	if err := t.Add(in); err != nil {
+		return err
+	}
+	t.watch(errs, func() {
+		t.Remove(in)
+	})
+	return nil
+}
+
+// watch handles the errors of an input until errs is closed or the tree is
+// cancelled, remove stops reading the input.
+func (t *tree[T]) watch(errs <-chan error, remove func()) {
+	t.watchers.Add(1)
+	t.spawn(func() {
+		defer t.watchers.Done()
+		for {
+			select {
+			case err, ok := <-errs:
+				if !ok {
+					return
+				}
+				t.sourceFailed(err, remove)
+			case <-t.ctx.Done():
+				for {
+					select {
+					case err, ok := <-errs:
+						if !ok {
+							return
+						}
+						t.sourceFailed(err, remove)
+					default:
+						return
+					}
+				}
+			}
+		}
+	})
+}
+
+func (t *tree[T]) sourceFailed(err error, remove func()) {
+	switch t.opts.sourcePolicy {
+	case FailFast:
+		t.fail(err)
+		t.kill()
+	case SkipSource:
+		remove()
+		fallthrough
+	default:
+		t.log(slog.LevelWarn, "input failed", "error", err)
+		t.errMu.Lock()
+		t.errs = append(t.errs, err)
+		t.errMu.Unlock()
+	}
+}
+
+// Errors returns every error of the tree: the first error reported by Err,
+// followed by the errors of the inputs that didn't fail the tree.
+func (t *tree[T]) Errors() []error {
+	t.errMu.Lock()
+	defer t.errMu.Unlock()
+	var errs []error
+	if t.err != nil {
+		errs = append(errs, t.err)
+	}
+	return append(errs, t.errs...)
+}
+
+// joinErrors returns the first error and the collected ones, t.errMu must
+// be held.
+func (t *tree[T]) joinErrors() error {
+	if len(t.errs) == 0 {
+		return t.err
+	}
+	return errors.Join(append([]error{t.err}, t.errs...)...)
+}
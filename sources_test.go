@@ -0,0 +1,65 @@
+package treeduction_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"treeduction"
+)
+
+var errProducer = errors.New("producer failed")
+
+// produce sends the values then the error of a producer.
+func produce(vs []int, err error) (<-chan int, <-chan error) {
+	c := make(chan int)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(c)
+		for _, v := range vs {
+			c <- v
+		}
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return c, errs
+}
+
+// TestSourceFailFast tests that the error of an input fails the tree by
+// default.
+func TestSourceFailFast(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	tree.AddWithErr(produce([]int{1, 2}, errProducer))
+
+	if err := tree.Finish(); !errors.Is(err, errProducer) {
+		t.Errorf("Expected the error of the producer, got %v", err)
+	}
+}
+
+// TestSourceCollectErrors tests that the errors of the inputs are collected
+// while the reduction goes on.
+func TestSourceCollectErrors(t *testing.T) {
+	for _, p := range []treeduction.SourcePolicy{treeduction.SkipSource, treeduction.CollectErrors} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false, treeduction.WithSourcePolicy(p))
+		err1, err2 := errors.New("first"), errors.New("second")
+		tree.AddWithErr(produce([]int{1, 2}, err1))
+		tree.AddWithErr(produce([]int{3}, err2))
+		tree.AddWithErr(produce([]int{4}, nil))
+
+		err := tree.Finish()
+		if !errors.Is(err, err1) || !errors.Is(err, err2) {
+			t.Errorf("Expected both errors, got %v", err)
+		}
+		if errs := tree.Errors(); len(errs) != 2 || !slices.Contains(errs, err1) {
+			t.Errorf("Expected both errors, got %v", errs)
+		}
+		if v := <-tree.Output(); v != 10 {
+			t.Errorf("Expected 10, got %d", v)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package treeduction
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"iter"
+)
+
+// SourceSet collects inputs of different kinds (channels, slices,
+// iterators, readers, producers), to add them to a tree at once with
+// AddSources. The methods return the set, so that they can be chained.
+type SourceSet[T any] struct {
+	inputs []setInput[T]
+}
+
+// setInput is an input of a SourceSet: a channel, with the channel of its
+// errors, or a producer started once the set is added.
+type setInput[T any] struct {
+	in      <-chan T
+	errs    <-chan error
+	produce func(ctx context.Context, emit func(T)) error
+}
+
+// NewSourceSet returns an empty set of inputs.
+func NewSourceSet[T any]() *SourceSet[T] {
+	return &SourceSet[T]{}
+}
+
+// Len returns the number of inputs in the set.
+func (s *SourceSet[T]) Len() int {
+	return len(s.inputs)
+}
+
+// Chan adds channels to the set, like Add.
+func (s *SourceSet[T]) Chan(chs ...<-chan T) *SourceSet[T] {
+	for _, ch := range chs {
+		s.inputs = append(s.inputs, setInput[T]{in: ch})
+	}
+	return s
+}
+
+// ChanWithErr adds a channel whose producer reports its failures on errs,
+// like AddWithErr.
+func (s *SourceSet[T]) ChanWithErr(in <-chan T, errs <-chan error) *SourceSet[T] {
+	s.inputs = append(s.inputs, setInput[T]{in: in, errs: errs})
+	return s
+}
+
+// Slice adds the values of vs as a single input, like AddSlice.
+func (s *SourceSet[T]) Slice(vs []T) *SourceSet[T] {
+	c := make(chan T, len(vs))
+	for _, v := range vs {
+		c <- v
+	}
+	close(c)
+	return s.Chan(c)
+}
+
+// Seq adds iterators to the set, like AddSeq.
+func (s *SourceSet[T]) Seq(seqs ...iter.Seq[T]) *SourceSet[T] {
+	for _, seq := range seqs {
+		s.inputs = append(s.inputs, setInput[T]{produce: func(ctx context.Context, emit func(T)) error {
+			for v := range seq {
+				if ctx.Err() != nil {
+					return nil
+				}
+				emit(v)
+			}
+			return nil
+		}})
+	}
+	return s
+}
+
+// Reader adds the values decoded from r, like AddReader.
+func (s *SourceSet[T]) Reader(r io.Reader, decode func(*bufio.Reader) (T, error)) *SourceSet[T] {
+	s.inputs = append(s.inputs, setInput[T]{produce: func(ctx context.Context, emit func(T)) error {
+		return readAll(ctx, r, decode)(emit)
+	}})
+	return s
+}
+
+// Go adds a producer run in a goroutine of the tree, like Go.
+func (s *SourceSet[T]) Go(f func(emit func(T)) error) *SourceSet[T] {
+	s.inputs = append(s.inputs, setInput[T]{produce: func(_ context.Context, emit func(T)) error {
+		return f(emit)
+	}})
+	return s
+}
+
+// AddSources adds every input of set in a single Add, so that they all take
+// part in the reduction or, if the tree is finished, none of them does. The
+// producers of the set are only started once their inputs are added. A nil
+// channel is skipped and reported with an InputError, like with Add.
+func (t *tree[T]) AddSources(set *SourceSet[T]) error {
+	return addSources(t, t.Add, t.Remove, set)
+}
+
+// AddSources adds every input of set at once, see Tree.AddSources.
+func (f *Folder[T, A]) AddSources(set *SourceSet[T]) error {
+	return addSources(f.tree, f.Add, f.Remove, set)
+}
+
+// addSources adds the inputs of set to t with add, and then starts their
+// producers and the handling of their errors.
+func addSources[T, A any](t *tree[A], add func(...<-chan T) error, remove func(<-chan T) bool, set *SourceSet[T]) error {
+	chans := make([]<-chan T, len(set.inputs))
+	var starts []func()
+	for i, in := range set.inputs {
+		if in.produce == nil {
+			chans[i] = in.in
+			if in.errs != nil {
+				starts = append(starts, func() {
+					t.watch(in.errs, func() {
+						remove(in.in)
+					})
+				})
+			}
+			continue
+		}
+
+		c := make(chan T)
+		errs := make(chan error, 1)
+		chans[i] = c
+		starts = append(starts, func() {
+			t.watch(errs, func() {
+				remove(c)
+			})
+			ctx := t.ctx
+			t.spawn(func() {
+				produce(t, c, errs, func(emit func(T)) error {
+					return in.produce(ctx, emit)
+				})
+			}, "role", "producer")
+		})
+	}
+
+	err := add(chans...)
+	var inputErr *InputError
+	if err != nil && !errors.As(err, &inputErr) {
+		return err
+	}
+	for _, start := range starts {
+		start()
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+package treeduction_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"treeduction"
+)
+
+// TestSourceSet tests that the inputs of every kind of a set are reduced.
+func TestSourceSet(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	ch := make(chan int, 1)
+	ch <- 1
+	close(ch)
+	set := treeduction.NewSourceSet[int]().
+		Chan(ch).
+		Slice([]int{2, 3}).
+		Seq(slices.Values([]int{4})).
+		Reader(strings.NewReader("5\n6\n"), decodeLine).
+		Go(func(emit func(int)) error {
+			emit(7)
+			return nil
+		})
+	if n := set.Len(); n != 5 {
+		t.Errorf("Expected 5 inputs, got %d", n)
+	}
+	if err := tree.AddSources(set); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 28 {
+		t.Errorf("Expected 28, got %d", v)
+	}
+}
+
+// TestSourceSetFinished tests that none of the inputs of a set is added to
+// a finished tree.
+func TestSourceSetFinished(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	tree.Finish()
+
+	var started atomic.Bool
+	set := treeduction.NewSourceSet[int]().Slice([]int{1}).Go(func(emit func(int)) error {
+		started.Store(true)
+		return nil
+	})
+	if err := tree.AddSources(set); !errors.Is(err, treeduction.ErrFinished) {
+		t.Errorf("Expected %v, got %v", treeduction.ErrFinished, err)
+	}
+	if started.Load() {
+		t.Error("Expected the producer not to be started")
+	}
+}
+
+// TestSourceSetErrors tests that the errors of the inputs of a set are
+// handled like the ones of AddWithErr.
+func TestSourceSetErrors(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	errBroken := errors.New("broken")
+	errs := make(chan error, 1)
+	errs <- errBroken
+	close(errs)
+	ch := make(chan int)
+	close(ch)
+	set := treeduction.NewSourceSet[int]().
+		ChanWithErr(ch, errs).
+		Reader(strings.NewReader("1\n2\n"), decodeLine)
+	tree.AddSources(set)
+	if err := tree.Finish(); !errors.Is(err, errBroken) {
+		t.Errorf("Expected %v, got %v", errBroken, err)
+	}
+}
+
+// TestFolderSourceSet tests that a Folder folds the inputs of a set.
+func TestFolderSourceSet(t *testing.T) {
+	tree := treeduction.Fold(0, func(n int, s string) int {
+		return n + len(s)
+	}, func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	set := treeduction.NewSourceSet[string]().Slice([]string{"ab", "c"}).Seq(slices.Values([]string{"def"}))
+	if err := tree.AddSources(set); err != nil {
+		t.Fatal(err)
+	}
+	tree.Finish()
+	if v := <-tree.Output(); v != 6 {
+		t.Errorf("Expected 6, got %d", v)
+	}
+}
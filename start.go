@@ -0,0 +1,70 @@
+package treeduction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// WithDeferredStart separates building the tree from running it: New and
+// Add build the nodes and buffers, so that Stats and Dump describe the tree,
+// but no goroutine of the tree runs and no input is read until Start. Finish
+// and Abort start a tree that wasn't, and Reset returns it to the state
+// before Start. The other methods that wait for the tree, e.g. Seal or
+// Checkpoint, must only be called once it is started.
+func WithDeferredStart() Option {
+	return func(o *options) {
+		o.deferredStart = true
+	}
+}
+
+// Start runs a tree created with WithDeferredStart. Cancelling ctx afterwards
+// stops the tree like cancelling the context of WithContext does, and its
+// cause is the error of the tree. It returns ErrStarted if the tree was
+// started already, and ErrFinished if it was finished.
+func (t *tree[T]) Start(ctx context.Context) error {
+	if !t.opts.deferredStart {
+		return fmt.Errorf("treeduction: only trees created with WithDeferredStart are started: %w", errors.ErrUnsupported)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished.Load() {
+		return ErrFinished
+	}
+	if !t.launch() {
+		return ErrStarted
+	}
+	t.unstart = context.AfterFunc(ctx, func() {
+		t.fail(context.Cause(ctx))
+		t.kill()
+	})
+	return nil
+}
+
+// postpone keeps f for Start if the tree isn't started, it reports false if f
+// must run now.
+func (t *tree[T]) postpone(f func()) bool {
+	t.startMu.Lock()
+	defer t.startMu.Unlock()
+	if t.launched {
+		return false
+	}
+	t.waiting = append(t.waiting, f)
+	return true
+}
+
+// launch runs the goroutines postponed until then, it reports false if the tree
+// was started already.
+func (t *tree[T]) launch() bool {
+	t.startMu.Lock()
+	defer t.startMu.Unlock()
+	if t.launched {
+		return false
+	}
+	t.launched = true
+	for _, f := range t.waiting {
+		go f()
+	}
+	t.waiting = nil
+	return true
+}
@@ -0,0 +1,83 @@
+package treeduction_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestDeferredStart tests that nothing is read before Start, while the tree
+// can already be dumped.
+func TestDeferredStart(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithDeferredStart())
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	close(ch)
+	tree.Add(ch)
+	tree.AddValues(4)
+
+	time.Sleep(10 * time.Millisecond)
+	if n := len(ch); n != 3 {
+		t.Errorf("Expected the input not to be read before Start, %d values left", n)
+	}
+	var b strings.Builder
+	if err := tree.Dump(&b); err != nil || b.Len() == 0 {
+		t.Errorf("Expected a dump before Start, got %q (%v)", b.String(), err)
+	}
+
+	if err := tree.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Start(context.Background()); !errors.Is(err, treeduction.ErrStarted) {
+		t.Errorf("Expected %v, got %v", treeduction.ErrStarted, err)
+	}
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 10 {
+		t.Errorf("Expected 10, got %d", v)
+	}
+
+	// Reset returns to the state before Start
+	if err := tree.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	tree.AddValues(5)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 5 {
+		t.Errorf("Expected 5, got %d", v)
+	}
+}
+
+// TestDeferredStartCancel tests that cancelling the context of Start stops
+// the tree with its cause.
+func TestDeferredStartCancel(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithDeferredStart())
+	tree.Add(make(chan int)) // Never closed
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := tree.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := tree.Finish(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+
+	other := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	if err := other.Start(context.Background()); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected %v, got %v", errors.ErrUnsupported, err)
+	}
+}
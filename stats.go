@@ -0,0 +1,184 @@
+package treeduction
+
+// Stats is a snapshot of the internals of a tree, for debugging a reduction
+// that stalls.
+type Stats[T any] struct {
+	// Nodes is the number of combining nodes that are still open.
+	Nodes int
+	// Goroutines is the number of goroutines run by the tree.
+	Goroutines int
+	// Consumed is the number of values read so far from each input that is
+	// still being read.
+	Consumed map[<-chan T]int64
+	// Sources is the reading of each input that is still being read, an
+	// input added more than once is summed up.
+	Sources map[<-chan T]SourceStats
+	// Emitted is the number of results put on the output.
+	Emitted int64
+	// Depth is the number of levels of the tree.
+	Depth int
+	// Levels are the levels of an unordered tree that hold a root waiting
+	// for a sibling, the output of a subtree of that height, from the
+	// lowest: after n inputs with the binary strategy, the bits set in n.
+	// The trees with WithMaxWorkers leave it empty.
+	Levels []int
+	// Pending is the number of values buffered inside the tree.
+	Pending int
+	// Expired is the number of values dropped for being too old, see
+	// NewTimed.
+	Expired int64
+	// Rejected is the number of values that didn't convert, see AddAny.
+	Rejected int64
+	// Duplicates is the number of values dropped as duplicates, see
+	// WithDedup.
+	Duplicates int64
+	// Latency is the time the oldest value of each result took to reach
+	// the output, see NewMeasured.
+	Latency Histogram
+}
+
+// Stats returns a snapshot of the internals of the tree. The numbers are
+// read while the tree is running, so they may be slightly out of sync.
+func (t *tree[T]) Stats() Stats[T] {
+	s := Stats[T]{
+		Nodes:      int(t.nodes.Load()),
+		Goroutines: int(t.goroutines.Load()),
+		Consumed:   make(map[<-chan T]int64),
+		Sources:    make(map[<-chan T]SourceStats),
+		Emitted:    t.emitted.Load(),
+		Expired:    t.expired.Load(),
+		Rejected:   t.rejected.Load(),
+		Duplicates: t.duplicates.Load(),
+	}
+
+	if t.latencies != nil {
+		s.Latency = t.latencies.snapshot()
+	}
+
+	t.srcMu.Lock()
+	for in, srcs := range t.sources {
+		for _, src := range srcs {
+			s.Consumed[in] += src.read.Load()
+			ss, one := s.Sources[in], src.stats()
+			ss.Read += one.Read
+			ss.Waited += one.Waited
+			ss.Dropped += one.Dropped
+			if one.Last.After(ss.Last) {
+				ss.Last = one.Last
+			}
+			s.Sources[in] = ss
+		}
+	}
+	t.srcMu.Unlock()
+
+	t.mu.Lock()
+	s.Depth = t.depth()
+	if !t.ordered {
+		s.Levels = t.levels.occupied()
+	}
+	t.mu.Unlock()
+
+	t.bufMu.Lock()
+	for c := range t.buffers {
+		s.Pending += len(c)
+	}
+	t.bufMu.Unlock()
+
+	// Node locks are never taken while holding the pool lock
+	if t.pool != nil {
+		t.pool.mu.Lock()
+		live := make([]*pnode[T], 0, len(t.pool.live))
+		for n := range t.pool.live {
+			live = append(live, n)
+		}
+		t.pool.mu.Unlock()
+		for _, n := range live {
+			s.Pending += n.pending()
+		}
+	}
+	return s
+}
+
+// depth is the number of levels of the tree.
+func (t *tree[T]) depth() int {
+	if !t.ordered {
+		return t.height()
+	}
+
+	d := 0
+	if t.pool != nil {
+		for _, r := range t.pool.runs {
+			d = max(d, r.depth)
+		}
+	} else {
+		for _, r := range t.runs {
+			d = max(d, r.depth)
+		}
+	}
+	return d
+}
+
+// buffer is a channel inside the tree, with the channels it reads from.
+type buffer[T any] struct {
+	label    string
+	children []<-chan T
+}
+
+// spawn runs f in a goroutine of the tree, with the given profiler labels
+// (see WithProfilerLabels).
+func (t *tree[T]) spawn(f func(), labels ...string) {
+	t.goroutines.Add(1)
+	t.running.Add(1)
+	exited := func() {}
+	if t.opts.leakGrace > 0 {
+		exited = t.started()
+	}
+	f = t.labeled(f, labels)
+	run := func() {
+		defer t.running.Done()
+		defer t.goroutines.Add(-1)
+		defer exited()
+		f()
+	}
+	if !t.postpone(run) {
+		go run()
+	}
+}
+
+// track adds a channel inside the tree to the pending values of the stats,
+// and to the dump under the given label unless it is empty.
+func (t *tree[T]) track(c <-chan T, label string, children ...<-chan T) {
+	t.bufMu.Lock()
+	defer t.bufMu.Unlock()
+	t.buffers[c] = buffer[T]{label: label, children: children}
+}
+
+// link adds a child to a tracked channel.
+func (t *tree[T]) link(c <-chan T, child <-chan T) {
+	t.bufMu.Lock()
+	defer t.bufMu.Unlock()
+	if b, ok := t.buffers[c]; ok {
+		b.children = append(b.children, child)
+		t.buffers[c] = b
+	}
+}
+
+func (t *tree[T]) untrack(c <-chan T) {
+	t.bufMu.Lock()
+	defer t.bufMu.Unlock()
+	delete(t.buffers, c)
+}
+
+func (n *pnode[T]) pending() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	p := 0
+	if n.held != nil {
+		p++
+	}
+	for _, q := range n.queues {
+		p += len(q)
+	}
+	return p
+}
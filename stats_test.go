@@ -0,0 +1,134 @@
+package treeduction_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestStats tests the snapshot of a running tree.
+func TestStats(t *testing.T) {
+	for _, opts := range [][]treeduction.Option{nil, {treeduction.WithMaxWorkers(2)}} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, false, false, opts...)
+
+		channels := make([]chan int, 4) // Never closed
+		for i := range channels {
+			channels[i] = make(chan int)
+			tree.Add(channels[i])
+		}
+		for i := 1; i <= 4; i++ {
+			channels[0] <- i
+		}
+		channels[1] <- 5
+		<-tree.Output()
+
+		// The last values may still be on their way into the tree
+		s := tree.Stats()
+		for deadline := time.Now().Add(time.Second); (s.Consumed[channels[0]] < 4 || s.Consumed[channels[1]] == 0) && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+			s = tree.Stats()
+		}
+		if s.Nodes != 3 {
+			t.Errorf("Expected 3 nodes, got %d", s.Nodes)
+		}
+		if s.Depth != 3 {
+			t.Errorf("Expected depth to be 3, got %d", s.Depth)
+		}
+		if s.Emitted != 1 {
+			t.Errorf("Expected 1 emitted result, got %d", s.Emitted)
+		}
+		if s.Goroutines == 0 {
+			t.Error("Expected the tree to run goroutines")
+		}
+		if n := s.Consumed[channels[0]]; n != 4 {
+			t.Errorf("Expected 4 values consumed from the first input, got %d", n)
+		}
+		if n := s.Consumed[channels[1]]; n != 1 {
+			t.Errorf("Expected 1 value consumed from the second input, got %d", n)
+		}
+
+		tree.Finish()
+		for range tree.Output() {
+		}
+		deadline := time.Now().Add(time.Second)
+		for tree.Stats().Goroutines > 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if n := tree.Stats().Goroutines; n != 0 {
+			t.Errorf("Expected no goroutines after Finish, got %d", n)
+		}
+	}
+}
+
+// TestStatsPending tests that values waiting for a sibling are reported.
+func TestStatsPending(t *testing.T) {
+	for _, opts := range [][]treeduction.Option{nil, {treeduction.WithMaxWorkers(2)}} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, true, opts...)
+
+		ch1 := make(chan int)
+		ch2 := make(chan int)
+		tree.Add(ch1, ch2)
+		for i := range 3 {
+			ch1 <- i
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for tree.Stats().Pending < 2 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if n := tree.Stats().Pending; n < 2 {
+			t.Errorf("Expected at least 2 pending values, got %d", n)
+		}
+
+		close(ch1)
+		close(ch2)
+		tree.Finish()
+	}
+}
+
+// TestStatsLevels tests that the levels holding a root follow the number of
+// inputs, however many there are, and that the dump shows them.
+func TestStatsLevels(t *testing.T) {
+	for _, tc := range []struct {
+		inputs int
+		levels []int
+	}{
+		{1, []int{0}},
+		{5, []int{0, 2}},
+		{6, []int{1, 2}},
+		{100, []int{2, 5, 6}},
+		{1 << 12, []int{12}},
+	} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 0, false, false)
+		chans := make([]<-chan int, tc.inputs)
+		closed := make(chan int)
+		close(closed)
+		for i := range chans {
+			chans[i] = closed
+		}
+		tree.Add(chans...)
+		s := tree.Stats()
+		if !slices.Equal(s.Levels, tc.levels) {
+			t.Errorf("Expected the levels %v for %d inputs, got %v", tc.levels, tc.inputs, s.Levels)
+		}
+		if s.Depth != tc.levels[len(tc.levels)-1]+1 {
+			t.Errorf("Expected a depth of %d for %d inputs, got %d", tc.levels[len(tc.levels)-1]+1, tc.inputs, s.Depth)
+		}
+		if tc.inputs < 1000 {
+			var b strings.Builder
+			tree.Dump(&b)
+			if n := strings.Count(b.String(), "(level "); n != len(tc.levels) {
+				t.Errorf("Expected %d roots in the dump of %d inputs, got %d", len(tc.levels), tc.inputs, n)
+			}
+		}
+		tree.Abort()
+	}
+}
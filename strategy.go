@@ -0,0 +1,172 @@
+package treeduction
+
+import "sync"
+
+// Strategy is the shape of an unordered tree, see WithStrategy.
+type Strategy int
+
+const (
+	// Flat combines every input at a single node: the fewest hops, but a
+	// single goroutine runs all the combines.
+	Flat Strategy = -1
+	// Binary combines the inputs two by two (the default).
+	Binary Strategy = 2
+)
+
+// KAry combines the inputs k by k, so the tree has fewer levels than a
+// binary one and each node combines more values.
+func KAry(k int) Strategy {
+	if k < 2 {
+		panic("treeduction: arity must be at least 2")
+	}
+	return Strategy(k)
+}
+
+// WithStrategy sets the shape of the tree, trading the parallelism of a
+// binary tree for the latency of fewer levels on small fan-ins. It only
+// applies to unordered trees without WithMaxWorkers.
+func WithStrategy(s Strategy) Option {
+	return func(o *options) {
+		o.strategy = s
+	}
+}
+
+// arity is the number of children of the nodes of an unordered tree, or 0
+// for a single node.
+func (o options) arity() int {
+	switch o.strategy {
+	case 0:
+		return 2
+	case Flat:
+		return 0
+	default:
+		return int(o.strategy)
+	}
+}
+
+// knode is a node that takes children until it is sealed.
+type knode[T any] struct {
+	fanIn    chan T
+	out      chan T
+	n        int
+	children sync.WaitGroup
+	// The number of children of a node of a frame, see WithExpectedInputs
+	size   int
+	parent *knode[T]
+
+	// Closed while every child of the node is closed, so that a value isn't
+	// held for a pair that may never come
+	mu      sync.Mutex
+	live    int
+	drained chan struct{}
+	// The number of open children under which drained is closed, 1 once a
+	// node of a frame won't get a pair for the values of its last child
+	lone int
+}
+
+// release stops holding the values of the last open child of the node.
+func (n *knode[T]) release() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.lone = 1
+	if n.live == 1 {
+		close(n.drained)
+	}
+}
+
+// idle returns a channel closed while every child of the node is closed.
+func (n *knode[T]) idle() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.drained
+}
+
+// openNode creates a node of the given level.
+func (t *tree[T]) openNode(level int) *knode[T] {
+	n := &knode[T]{fanIn: make(chan T, t.bufAt(level)), out: make(chan T, t.bufAt(level)), drained: make(chan struct{})}
+	close(n.drained)
+	t.track(n.out, "unordered")
+	t.track(n.fanIn, "")
+	closed := t.nodeCreated()
+	t.spawn(func() {
+		t.combineNode(n.fanIn, n.out, n.idle)
+		closed()
+		t.untrack(n.out)
+		close(n.out)
+	})
+	return n
+}
+
+// adopt adds a child to the node.
+func (t *tree[T]) adopt(n *knode[T], child <-chan T) {
+	n.n++
+	n.children.Add(1)
+	n.mu.Lock()
+	if n.live == n.lone {
+		n.drained = make(chan struct{})
+	}
+	n.live++
+	n.mu.Unlock()
+	t.link(n.out, child)
+	t.spawn(func() {
+		defer n.children.Done()
+		for v := range child {
+			t.forward(n.fanIn, v)
+		}
+		n.mu.Lock()
+		if n.live--; n.live == n.lone {
+			close(n.drained)
+		}
+		n.mu.Unlock()
+	})
+}
+
+// seal closes the node once its children are closed, it takes no more.
+func (t *tree[T]) seal(n *knode[T]) {
+	t.spawn(func() {
+		n.children.Wait()
+		t.untrack(n.fanIn)
+		close(n.fanIn)
+	})
+}
+
+// addChild is addOne for the trees that aren't binary: the node of each
+// level takes children until it has as many as the arity, and is then
+// added to the next level.
+func (t *tree[T]) addChild(child <-chan T, level int) {
+	l := t.levels.get(level)
+	n := l.open
+	if n == nil {
+		n = t.openNode(level + 1)
+		l.open, l.root = n, n.out
+	}
+	t.adopt(n, child)
+
+	if k := t.opts.arity(); k > 0 && n.n == k {
+		t.seal(n)
+		l.open, l.root = nil, nil
+		t.addChild(n.out, level+1)
+	}
+}
+
+// sealOpen seals the nodes that still take children, since no more are
+// coming.
+func (t *tree[T]) sealOpen() {
+	for i := range t.levels.at {
+		if n := t.levels.at[i].open; n != nil {
+			t.seal(n)
+			t.levels.at[i].open = nil
+		}
+	}
+	t.sealFrame()
+	if t.folding != nil {
+		t.seal(t.folding)
+		t.folding = nil
+	}
+	// The lanes become plain roots
+	for _, l := range t.lanes {
+		t.seal(l.knode)
+		t.levels.loose = append(t.levels.loose, l.out)
+	}
+	t.lanes = nil
+}
@@ -0,0 +1,88 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestStrategy tests that the shapes of the tree give the same result.
+func TestStrategy(t *testing.T) {
+	for _, s := range []treeduction.Strategy{treeduction.Flat, treeduction.Binary, treeduction.KAry(3)} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false, treeduction.WithStrategy(s))
+
+		for i := range 10 {
+			c := make(chan int, 10)
+			for j := range 10 {
+				c <- i*10 + j + 1
+			}
+			close(c)
+			tree.Add(c)
+		}
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		if v := <-tree.Output(); v != 5050 {
+			t.Errorf("Expected 5050 with strategy %d, got %d", s, v)
+		}
+	}
+}
+
+// TestStrategyDepth tests the number of levels of the shapes of the tree.
+func TestStrategyDepth(t *testing.T) {
+	for s, depth := range map[treeduction.Strategy]int{treeduction.Flat: 1, treeduction.Binary: 5, treeduction.KAry(4): 3} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, false, false, treeduction.WithStrategy(s))
+		inputs := make([]chan int, 16)
+		for i := range inputs {
+			inputs[i] = make(chan int)
+			tree.Add(inputs[i])
+		}
+
+		if d := tree.Stats().Depth; d != depth {
+			t.Errorf("Expected a depth of %d with strategy %d, got %d", depth, s, d)
+		}
+		// In a flat tree any two values are paired at the single node
+		if s == treeduction.Flat {
+			inputs[0] <- 1
+			inputs[15] <- 2
+			if v := <-tree.Output(); v != 3 {
+				t.Errorf("Expected 3, got %d", v)
+			}
+		}
+		for _, c := range inputs {
+			close(c)
+		}
+		tree.Finish()
+	}
+}
+
+// TestStrategyClosedInputs tests that a node still taking children doesn't
+// hold a value for a pair once its inputs are closed.
+func TestStrategyClosedInputs(t *testing.T) {
+	for _, s := range []treeduction.Strategy{treeduction.Flat, treeduction.KAry(4)} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, false, false, treeduction.WithStrategy(s))
+		for range 3 {
+			c := make(chan int, 1)
+			c <- 1
+			close(c)
+			tree.Add(c)
+		}
+
+		total := 0
+		for total < 3 {
+			select {
+			case v := <-tree.Output():
+				total += v
+			case <-time.After(time.Second):
+				t.Fatalf("Expected 3 with strategy %d before Finish, got %d", s, total)
+			}
+		}
+		tree.Finish()
+	}
+}
@@ -0,0 +1,109 @@
+package treeduction
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WithStrictClose reports the inputs a waitForAll tree still reads grace
+// after Finish started waiting for them, the usual sign of a producer that
+// forgot to close its channel: they are logged as a warning with WithLogger,
+// and with fail Finish stops waiting, like FinishContext with a cancelled
+// context, and returns an *UnclosedError listing them with the partial
+// result on the output. Without fail Finish keeps waiting. It has no effect
+// on a Folder.
+func WithStrictClose(grace time.Duration, fail bool) Option {
+	if grace <= 0 {
+		panic("treeduction: strict close grace must be positive")
+	}
+	return func(o *options) {
+		o.strictGrace = grace
+		o.strictFail = fail
+	}
+}
+
+// UnclosedInput is an input that wasn't closed, see WithStrictClose.
+type UnclosedInput struct {
+	// Index is the number of the input in the order the inputs were added,
+	// from 0.
+	Index int
+	// Read is the number of values read from it.
+	Read int64
+	// Idle is the time since its last value was read, or since it was
+	// added if none was.
+	Idle time.Duration
+}
+
+// UnclosedError is reported by Finish for the inputs still open at the end
+// of the grace of WithStrictClose, sorted by index.
+type UnclosedError struct {
+	Inputs []UnclosedInput
+}
+
+func (e *UnclosedError) Error() string {
+	var b strings.Builder
+	for i, in := range e.Inputs {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%d (idle for %v)", in.Index, in.Idle.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("treeduction: %d inputs not closed: %s", len(e.Inputs), b.String())
+}
+
+// unclosed returns the inputs that are still being read.
+func (t *tree[T]) unclosed() []UnclosedInput {
+	now := time.Now()
+	t.srcMu.Lock()
+	defer t.srcMu.Unlock()
+	var ins []UnclosedInput
+	for _, srcs := range t.sources {
+		for _, src := range srcs {
+			if src.internal {
+				continue
+			}
+			since := src.added
+			if last := src.last.Load(); last != 0 {
+				since = time.Unix(0, last)
+			}
+			ins = append(ins, UnclosedInput{Index: src.index, Read: src.read.Load(), Idle: now.Sub(since)})
+		}
+	}
+	slices.SortFunc(ins, func(a, b UnclosedInput) int {
+		return a.Index - b.Index
+	})
+	return ins
+}
+
+// watchClose reports the inputs that are still open once the grace of
+// WithStrictClose is over, until the returned function is called.
+func (t *tree[T]) watchClose() func() {
+	if !t.waitForAll || t.opts.strictGrace <= 0 {
+		return func() {}
+	}
+	stop := make(chan struct{})
+	t.spawn(func() {
+		select {
+		case <-time.After(t.opts.strictGrace):
+		case <-stop:
+			return
+		}
+		ins := t.unclosed()
+		if len(ins) == 0 {
+			return
+		}
+		indexes := make([]int, len(ins))
+		for i, in := range ins {
+			indexes[i] = in.Index
+		}
+		t.log(slog.LevelWarn, "inputs not closed", "inputs", indexes)
+		if t.opts.strictFail {
+			t.fail(&UnclosedError{Inputs: ins})
+			t.cancel()
+		}
+	}, "role", "strict")
+	return func() { close(stop) }
+}
@@ -0,0 +1,50 @@
+package treeduction_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestStrictClose tests that Finish reports the inputs that weren't closed
+// in time, and stops waiting for them with fail.
+func TestStrictClose(t *testing.T) {
+	for _, fail := range []bool{true, false} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false, treeduction.WithStrictClose(20*time.Millisecond, fail))
+		open := make(chan int, 1)
+		open <- 2
+		tree.AddValues(1)
+		tree.Add(open)
+
+		done := make(chan error)
+		go func() {
+			done <- tree.Finish()
+		}()
+		if !fail {
+			select {
+			case err := <-done:
+				t.Fatalf("Expected Finish to wait, got %v", err)
+			case <-time.After(50 * time.Millisecond):
+			}
+			close(open)
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		var uerr *treeduction.UnclosedError
+		if err := <-done; !errors.As(err, &uerr) {
+			t.Fatalf("Expected an UnclosedError, got %v", err)
+		}
+		if len(uerr.Inputs) != 1 || uerr.Inputs[0].Index != 1 || uerr.Inputs[0].Read != 1 {
+			t.Errorf("Expected input 1 with a value read, got %+v", uerr.Inputs)
+		}
+		if v := <-tree.Output(); v != 3 {
+			t.Errorf("Expected the partial result 3, got %d", v)
+		}
+	}
+}
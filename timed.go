@@ -0,0 +1,59 @@
+package treeduction
+
+import "time"
+
+// Timed is a result with the time the newest value combined into it was
+// read from its input, see NewTimed. At is zero when every value expired.
+type Timed[T any] struct {
+	Value T
+	At    time.Time
+
+	// Whether Value holds a value yet, for the local folds
+	full bool
+}
+
+// NewTimed is like New, but every value is timestamped when it is read from
+// its input, and the values older than ttl are dropped instead of combined,
+// so that the stale partials of a lagging input don't pollute the aggregate
+// of the current interval. A result is as old as the newest value combined
+// into it, so with waitForAll the result accumulated so far expires too
+// when no value reached it for ttl. The dropped values are counted by
+// Stats.Expired.
+func NewTimed[T any](combiner func(f T, s T) T, ttl time.Duration, bufferSize int, waitForAll bool, ordered bool, opts ...Option) *Folder[T, Timed[T]] {
+	var f *Folder[T, Timed[T]]
+	fresh := func(v Timed[T], now time.Time) bool {
+		if !v.full {
+			return false
+		}
+		if now.Sub(v.At) > ttl {
+			f.expired.Add(1)
+			return false
+		}
+		return true
+	}
+	f = Fold(Timed[T]{}, func(acc Timed[T], v T) Timed[T] {
+		if acc.full {
+			return Timed[T]{Value: combiner(acc.Value, v), At: time.Now(), full: true}
+		}
+		return Timed[T]{Value: v, At: time.Now(), full: true}
+	}, func(a Timed[T], b Timed[T]) Timed[T] {
+		now := time.Now()
+		switch aok, bok := fresh(a, now), fresh(b, now); {
+		case aok && bok:
+			return Timed[T]{Value: combiner(a.Value, b.Value), At: latest(a.At, b.At), full: true}
+		case aok:
+			return a
+		case bok:
+			return b
+		}
+		return Timed[T]{}
+	}, bufferSize, waitForAll, ordered, opts...)
+	return f
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
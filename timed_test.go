@@ -0,0 +1,52 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestTimed tests that a value that waited longer than the TTL is dropped
+// instead of combined.
+func TestTimed(t *testing.T) {
+	tree := treeduction.NewTimed(func(a, b int) int {
+		return a + b
+	}, 50*time.Millisecond, 10, true, false)
+
+	c := make(chan int)
+	tree.Add(c)
+	c <- 1
+	time.Sleep(100 * time.Millisecond)
+	c <- 2
+	c <- 3
+	close(c)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-tree.Output(); r.Value != 5 || r.At.IsZero() {
+		t.Errorf("Expected 5, got %d at %v", r.Value, r.At)
+	}
+	if n := tree.Stats().Expired; n != 1 {
+		t.Errorf("Expected 1 expired value, got %d", n)
+	}
+}
+
+// TestTimedFresh tests that values within the TTL are all combined.
+func TestTimedFresh(t *testing.T) {
+	tree := treeduction.NewTimed(func(a, b int) int {
+		return a + b
+	}, time.Minute, 10, true, false)
+
+	start := time.Now()
+	tree.AddValues(1, 2, 3, 4)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	r := <-tree.Output()
+	if r.Value != 10 || r.At.Before(start) {
+		t.Errorf("Expected 10 read after %v, got %d at %v", start, r.Value, r.At)
+	}
+	if n := tree.Stats().Expired; n != 0 {
+		t.Errorf("Expected no expired value, got %d", n)
+	}
+}
@@ -0,0 +1,54 @@
+package treeduction
+
+import "context"
+
+// Tracer starts the spans of a tree, see WithTracing. It is small enough to
+// be implemented on top of any tracing library, like OpenTelemetry.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttrs(attrs ...Attr)
+	End()
+}
+
+// Attr is an attribute of a span.
+type Attr struct {
+	Key   string
+	Value int
+}
+
+// WithTracing traces the tree with tr: a span per Add (with the number of
+// inputs and the depth of the tree), per combining node (from its creation
+// until it closes, as a child of the Add that created it) and for Finish.
+func WithTracing(tr Tracer) Option {
+	return func(o *options) {
+		o.tracer = tr
+	}
+}
+
+type span struct {
+	ctx context.Context
+	s   Span
+}
+
+// startSpan starts a span if the tree is traced.
+func (t *tree[T]) startSpan(parent context.Context, name string, attrs ...Attr) span {
+	if t.opts.tracer == nil {
+		return span{}
+	}
+	ctx, s := t.opts.tracer.Start(parent, name, attrs...)
+	return span{ctx: ctx, s: s}
+}
+
+func (s span) end(attrs ...Attr) {
+	if s.s == nil {
+		return
+	}
+	if len(attrs) > 0 {
+		s.s.SetAttrs(attrs...)
+	}
+	s.s.End()
+}
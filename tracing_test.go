@@ -0,0 +1,95 @@
+package treeduction_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"treeduction"
+)
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+type testSpan struct {
+	name   string
+	parent *testSpan
+	attrs  map[string]int
+	ended  bool
+}
+
+type spanKey struct{}
+
+func (tr *testTracer) Start(ctx context.Context, name string, attrs ...treeduction.Attr) (context.Context, treeduction.Span) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	s := &testSpan{name: name, attrs: make(map[string]int)}
+	s.parent, _ = ctx.Value(spanKey{}).(*testSpan)
+	s.SetAttrs(attrs...)
+	tr.spans = append(tr.spans, s)
+	return context.WithValue(ctx, spanKey{}, s), &lockedSpan{tr, s}
+}
+
+type lockedSpan struct {
+	tr *testTracer
+	s  *testSpan
+}
+
+func (l *lockedSpan) SetAttrs(attrs ...treeduction.Attr) {
+	l.tr.mu.Lock()
+	defer l.tr.mu.Unlock()
+	l.s.SetAttrs(attrs...)
+}
+
+func (l *lockedSpan) End() {
+	l.tr.mu.Lock()
+	defer l.tr.mu.Unlock()
+	l.s.ended = true
+}
+
+func (s *testSpan) SetAttrs(attrs ...treeduction.Attr) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+// TestTracing tests the spans of a traced tree.
+func TestTracing(t *testing.T) {
+	tr := &testTracer{}
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false, treeduction.WithTracing(tr))
+
+	for range 2 {
+		ch1 := make(chan int, 1)
+		ch2 := make(chan int, 1)
+		ch1 <- 1
+		ch2 <- 2
+		close(ch1)
+		close(ch2)
+		tree.Add(ch1, ch2)
+	}
+	tree.Finish()
+	<-tree.Output()
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	count := make(map[string]int)
+	for _, s := range tr.spans {
+		count[s.name]++
+		if !s.ended {
+			t.Errorf("Expected span %s to be ended", s.name)
+		}
+		if s.name == "treeduction.node" && (s.parent == nil || s.parent.name != "treeduction.Add") {
+			t.Errorf("Expected a node span to be a child of an Add span")
+		}
+	}
+	if count["treeduction.Add"] != 2 || count["treeduction.node"] != 3 || count["treeduction.Finish"] != 1 {
+		t.Errorf("Expected 2 Add, 3 node and 1 Finish spans, got %v", count)
+	}
+	if a := tr.spans[0].attrs; a["width"] != 2 || a["depth"] != 2 {
+		t.Errorf("Expected the first Add span to have width 2 and depth 2, got %v", a)
+	}
+}
@@ -2,219 +2,1106 @@ package treeduction
 
 import (
 	"context"
+	"io"
+	"log/slog"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
 )
 
 type tree[T any] struct {
-	combiner   func(f T, s T) T
-	roots      []<-chan T
+	// Serializes the changes to the structure of the tree
+	mu sync.Mutex
+
+	combiner  func(f T, s T) T
+	filter    func(T) bool
+	hook      func(a, b, result T, d time.Duration)
+	satisfied func(T) bool
+	logger    *slog.Logger
+	// Set with WithPadding
+	padding *T
+	// Set with WithWAL
+	wal *wal[T]
+	// Set with WithSink, and closed once it wrote the last result
+	sink     func(T) error
+	sinkDone chan struct{}
+	// Set with WithOutputPartitioner, and closed once the results are routed
+	partition   func(T) int
+	partitions  []chan T
+	partitioned chan struct{}
+
+	// The nodes of the inputs added with a priority, highest first
+	lanes []*lane[T]
+
+	// Set by NewEnveloped, tag marks a value with the input it was read from
+	// and stamp with its place in the output
+	tag   func(v T, input int) T
+	stamp func(v T, seq uint64) T
+	// Set by NewMeasured, observe is called with every result put on the
+	// output
+	observe    func(v T)
+	latencies  *histogram
+	seqMu      sync.Mutex
+	seq        uint64
+	indexes    map[<-chan T]int
+	levels     levels[T]
 	bufSize    int
 	output     chan T
 	stop       chan struct{}
 	ctx        context.Context
 	cancel     context.CancelFunc
+	life       context.Context
+	kill       context.CancelFunc
 	wg         sync.WaitGroup
 	waitForAll bool
 	ordered    bool
+	opts       options
+
+	// With waitForAll the values that reach the root are folded into acc
+	accMu  sync.Mutex
+	acc    T
+	folded bool
+
+	// Values that reach the root are delivered to rootIn, which is the output
+	// itself unless an emitter goroutine has to transform them first
+	rootIn   chan T
+	emitDone chan struct{}
+
+	// The first error of the tree, reported by Err and Finish
+	errMu    sync.Mutex
+	err      error
+	errs     []error
+	watchers sync.WaitGroup
+	dropped  atomic.Int64
+	finished atomic.Bool
+	aborted  atomic.Bool
+	unwatch  func() bool
+	// Closed once the first Finish, FinishContext or Abort returns
+	settled chan struct{}
+	// Closed once the goroutines of the tree exited after that, see Done
+	done chan struct{}
+	// The trees added with AddTree
+	subtrees []Tree[T]
+	// The context of the producers added with AddProducer, cancelled by
+	// Finish, and their own cancel by channel, for Remove
+	producing     context.Context
+	stopProducing context.CancelFunc
+	prodMu        sync.Mutex
+	producers     map[any]context.CancelFunc
+	// The number of sources attached so far, under srcMu
+	attached int
+
+	// Closed by Resume, nil unless the tree is paused. The pause channel is
+	// closed while the tree is paused.
+	pauseMu sync.Mutex
+	resume  atomic.Pointer[chan struct{}]
+	pause   atomic.Pointer[chan struct{}]
+
+	// For Checkpoint, the values read but not folded into the root yet, and
+	// the goroutines reading the inputs and how many of them are paused
+	alive   atomic.Int64
+	readers atomic.Int64
+	parked  atomic.Int64
+
+	// Set with WithRateLimit, shared by all the inputs
+	limit *bucket
+
+	// Set with WithMaxInFlight, a slot per value inside the tree. The
+	// starve channel is closed while readers wait for a slot.
+	slots    chan struct{}
+	starveMu sync.Mutex
+	starve   atomic.Pointer[chan struct{}]
+	starved  int
+
+	// Set with WithWeighter, the weight of the values inside the tree
+	weigh   func(T) int64
+	weights *weights
+
+	// Set with WithFaultInjection
+	faults *faults
+
+	// Set with WithHeartbeat
+	beat func() T
+	// Set with WithNodeFactory
+	newNode func(level int) Node[T]
+	// Set with WithExpectedValues, the values that may still be read and
+	// the ones read
+	quota    atomic.Int64
+	expected atomic.Int64
+	// Set with WithDedup
+	dedupKey func(T) uint64
+	seen     *seen
+	// Set with WithValidation, the record of the current epoch if it is
+	// validated, and the one each input adds its values to
+	equal     func(a, b T) bool
+	recMu     sync.Mutex
+	record    *record[T]
+	recording map[*source]*record[T]
+	// Set with WithClassifier
+	classify func(T) string
+	classes  *classes[T]
+
+	// Reported by Stats, the buffers are the channels inside the tree
+	goroutines atomic.Int64
+	running    sync.WaitGroup
+
+	// Set with WithLeakCheck, the running goroutines by where they started
+	leakMu     sync.Mutex
+	stages     map[string]int
+	nodes      atomic.Int64
+	consumed   atomic.Int64
+	emitted    atomic.Int64
+	expired    atomic.Int64
+	rejected   atomic.Int64
+	duplicates atomic.Int64
+	progressMu sync.Mutex
+	bufMu      sync.Mutex
+	buffers    map[<-chan T]buffer[T]
+
+	// Set with WithTracing, the span of the running Add is the parent of
+	// the nodes it creates
+	spanCtx context.Context
+
+	// The sources that are still being read, the internal ones are fed by
+	// a Folder
+	srcMu    sync.Mutex
+	sources  map[<-chan T][]*source
+	internal map[<-chan T]struct{}
+
+	// Set with WithMaxWorkers, instead of a goroutine per input and node
+	pool *pool[T]
+
+	// Set with WithAdaptiveBatching
+	cost cost
+
+	// Set with WithExpectedInputs
+	frame *frame[T]
+	// Set with WithParallelism, a slot per combine running
+	cpus chan struct{}
+	// Set with WithSequentialFallback, the node that folds every value
+	folding *knode[T]
+
+	// Ordered mode keeps balanced runs of inputs instead of roots
+	runs     []run[T]
+	runsDone chan roundState[T]
+
+	// Closed when the result of the last sealed epoch was emitted
+	epochs    sync.WaitGroup
+	epochDone chan struct{}
+	// Closed once the results of the last isolated batch are delivered, see
+	// WithBatchIsolation
+	batchDone chan struct{}
+	// The results fed back into the tree, see Loop
+	loop atomic.Pointer[loop[T]]
+	// The inputs left for Run, see WithInline
+	inlined []inlined[T]
+	// The workers of the groups of inputs and the number of inputs they
+	// were given, see WithLocalGroups
+	local   []*lworker[T]
+	grouped int
+	// The goroutines waiting for Start and the cancellation of its context,
+	// see WithDeferredStart
+	startMu  sync.Mutex
+	waiting  []func()
+	launched bool
+	unstart  func() bool
 }
 
+// Tree is a reduction tree, made of the Producer half that feeds it, the
+// Consumer half that reads its results, and the methods that manage it.
 type Tree[T any] interface {
-	Add(out ...<-chan T)
-	Output() <-chan T
-	Finish() error
-}
-
-func New[T any](combiner func(f T, s T) T, bufferSize int, waitForAll bool, ordered bool) Tree[T] {
-	ctx, cancel := context.WithCancel(context.Background())
-	return &tree[T]{
-		combiner:   combiner,
-		roots:      make([]<-chan T, 20),
-		bufSize:    bufferSize,
-		output:     make(chan T, bufferSize),
-		stop:       make(chan struct{}),
-		ctx:        ctx,
-		cancel:     cancel,
+	Producer[T]
+	Consumer[T]
+	Remove(in <-chan T) bool
+	Rebalance()
+	AttachGroup(g *errgroup.Group, ctx context.Context)
+	Snapshot() (T, bool)
+	Stats() Stats[T]
+	SlowestSources(k int) []<-chan T
+	Dump(w io.Writer) error
+	Reset() error
+	Pause()
+	Resume()
+	Checkpoint(w io.Writer, codec Codec[T]) error
+	Restore(r io.Reader, codec Codec[T]) error
+	SetCombiner(combiner func(f T, s T) T) error
+	Loop(converged func(T) bool) error
+	Run(ctx context.Context) error
+	Start(ctx context.Context) error
+}
+
+func New[T any](combiner func(f T, s T) T, bufferSize int, waitForAll bool, ordered bool, opts ...Option) Tree[T] {
+	return Typed[T]{}.New(combiner, bufferSize, waitForAll, ordered, opts...)
+}
+
+// New is New with the typed options.
+func (w Typed[T]) New(combiner func(f T, s T) T, bufferSize int, waitForAll bool, ordered bool, opts ...Option) Tree[T] {
+	t := newTree(bufferSize, waitForAll, ordered, opts, w.opts)
+	t.combiner = t.guard(func(f T, s T) (T, error) {
+		return combiner(f, s), nil
+	})
+	return t
+}
+
+func newTree[T any](bufferSize int, waitForAll bool, ordered bool, opts []Option, typedOpts []TypedOption[T]) *tree[T] {
+	if bufferSize < 0 {
+		panic("treeduction: buffer size can't be negative")
+	}
+	o := newOptions(waitForAll, opts)
+	ty := applyTyped(&o, typedOpts)
+	nodeBuffer, outputBuffer := bufferSize, bufferSize
+	if o.nodeBuffer >= 0 {
+		nodeBuffer = o.nodeBuffer
+	}
+	if o.outputBuffer >= 0 {
+		outputBuffer = o.outputBuffer
+	}
+	// Finish puts the single result on the output before it is read
+	if waitForAll {
+		outputBuffer = max(outputBuffer, 1)
+	}
+
+	t := &tree[T]{
+		bufSize:    nodeBuffer,
 		waitForAll: waitForAll,
 		ordered:    ordered,
+		opts:       o,
+		sources:    make(map[<-chan T][]*source),
+		internal:   make(map[<-chan T]struct{}),
+		buffers:    make(map[<-chan T]buffer[T]),
+		filter:     ty.filter,
+		hook:       ty.hook,
+		satisfied:  ty.satisfied,
+		logger:     loggerOf(o),
+		sink:       ty.sink,
+		partition:  ty.partition,
+		padding:    ty.padding,
+		weigh:      ty.weigh,
+		faults:     newFaults(o.faults),
+		beat:       ty.beat,
+		newNode:    ty.newNode,
+		dedupKey:   ty.dedupKey,
+		equal:      ty.equal,
+		classify:   ty.classify,
+	}
+	if n := o.parallelism; n > 0 {
+		t.cpus = make(chan struct{}, n)
+	}
+	if o.strictGrace > 0 {
+		t.indexes = make(map[<-chan T]int)
+	}
+	if codec := ty.walCodec; codec != nil {
+		if w, err := openWAL(o.walDir, codec); err != nil {
+			t.fail(err)
+		} else {
+			t.wal = w
+		}
+	}
+	t.start(outputBuffer)
+	return t
+}
+
+// start sets up a fresh run of the tree, with no inputs yet.
+func (t *tree[T]) start(outputBuffer int) {
+	t.launched = !t.opts.deferredStart
+	t.unstart = nil
+	t.life, t.kill = context.WithCancel(t.opts.parent())
+	t.ctx, t.cancel = context.WithCancel(t.life)
+	t.producing, t.stopProducing = context.WithCancel(t.ctx)
+	t.producers = make(map[any]context.CancelFunc)
+	t.output = make(chan T, outputBuffer)
+	if t.sink != nil {
+		t.sinkDone = make(chan struct{})
+		out, done := t.output, t.sinkDone
+		t.spawn(func() { t.runSink(out, done) }, "role", "sink")
+	}
+	if t.partition != nil {
+		t.startPartitions(outputBuffer)
+	}
+	t.stop = make(chan struct{})
+	t.settled = make(chan struct{})
+	t.done = make(chan struct{})
+	pause := make(chan struct{})
+	t.pause.Store(&pause)
+	t.limit = newBucket(t.opts.rate)
+	t.quota.Store(t.opts.expectedValues)
+	t.expected.Store(0)
+	if t.dedupKey != nil {
+		t.seen = newSeen(t.opts.dedupWindow)
+	}
+	if t.classify != nil {
+		t.classes = &classes[T]{m: make(map[string]*class[T])}
+	}
+	if t.validating() {
+		t.record = t.sampleRecord()
+		t.recording = make(map[*source]*record[T])
+	}
+	if t.opts.idle > 0 {
+		t.spawn(t.watchIdle, "role", "idle")
+	}
+	if t.beat != nil && !t.waitForAll {
+		t.watchers.Add(1)
+		t.spawn(t.heartbeat, "role", "heartbeat")
+	}
+	if n := t.opts.maxInFlight; n > 0 && !t.ordered && !t.opts.pooled() {
+		t.slots = make(chan struct{}, n)
+	}
+	if t.weigh != nil && !t.ordered && !t.opts.pooled() {
+		t.weights = newWeights(t.opts.maxWeight)
+	}
+	starve := make(chan struct{})
+	t.starve.Store(&starve)
+
+	if ctx := t.opts.ctx; ctx != nil {
+		t.unwatch = context.AfterFunc(ctx, func() {
+			t.fail(context.Cause(ctx))
+		})
+	}
+	if t.opts.pooled() {
+		t.startPool(t.opts.maxWorkers)
+	}
+
+	t.rootIn = t.output
+	if t.opts.needsEmitter() {
+		t.rootIn = make(chan T, t.bufSize)
+		t.track(t.rootIn, "emitter")
+		t.emitDone = make(chan struct{})
+		t.spawn(t.runEmitter, "role", "emitter")
 	}
 }
 
-func (t *tree[T]) Add(out ...<-chan T) {
+// Add starts reducing the values of the given channels. The channels are not
+// read after Finish, which is reported with ErrFinished. A nil channel is
+// skipped and reported with an InputError (so is a channel that is already
+// read, depending on WithDuplicatePolicy), and a closed one is an input
+// without values. It is safe to call Add from multiple goroutines.
+func (t *tree[T]) Add(out ...<-chan T) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.finished.Load() {
+		t.fail(ErrFinished)
+		return ErrFinished
+	}
+	out, err := skipInputs(out, t.opts.duplicates, t.reading)
+
+	span := t.startSpan(t.life, "treeduction.Add", Attr{Key: "width", Value: len(out)})
+	t.spanCtx = span.ctx
+	t.add(out, 0)
+	t.spanCtx = nil
+	span.end(Attr{Key: "depth", Value: t.depth()})
+	return err
+}
+
+func (t *tree[T]) add(out []<-chan T, priority int) {
+	t.replayWAL()
+	t.log(slog.LevelDebug, "inputs added", "inputs", len(out), "priority", priority)
+	if t.classes != nil {
+		t.addClassified(out)
+		return
+	}
+	if t.pool != nil {
+		t.addPooled(out)
+		return
+	}
+	if t.inlining() {
+		t.addInline(out)
+		return
+	}
+	if t.localizing() {
+		if leaves := t.addLocal(out); len(leaves) > 0 {
+			t.plant(leaves, 0)
+		}
+		return
+	}
+	if t.isolating() {
+		t.isolate()
+	}
+
+	leaves := make([]<-chan T, 0, len(out))
 	for _, o := range out {
-		c := make(chan T, t.bufSize)
+		c := make(chan T, t.bufAt(0))
+		src := t.attach(o)
+		src.priority = priority
 
 		// Wraping <-o in a select which checks for ctx.Done()
-		go func(o <-chan T) {
+		t.track(c, "input")
+		// The channels of a Folder are internal, it reads the inputs itself
+		pausing, enter, vacate, throttle := t.pausing, t.claim, t.unclaim, t.throttle
+		if src.internal {
+			pausing = func() <-chan struct{} { return nil }
+			enter = func(<-chan struct{}) bool { return true }
+			vacate = func() {}
+			throttle = func(*bucket, <-chan struct{}) bool { return true }
+		} else {
+			t.readers.Add(1)
+		}
+		t.spawn(func() {
+			defer t.forget(o, src)
+			if !src.internal {
+				defer t.readers.Add(-1)
+			}
 		loop:
 			for {
+				if resume := t.paused(); resume != nil && !src.internal {
+					t.parked.Add(1)
+					select {
+					case <-resume:
+					case <-src.detach:
+						t.parked.Add(-1)
+						t.flush(o, c, src)
+						break loop
+					case <-t.ctx.Done():
+						t.parked.Add(-1)
+						break loop
+					}
+					t.parked.Add(-1)
+				}
+				if !throttle(src.limit, src.detach) || !enter(src.detach) {
+					select {
+					case <-src.detach:
+						t.flush(o, c, src)
+					default:
+					}
+					break loop
+				}
+				since := time.Now()
 				select {
 				case v, ok := <-o:
 					if !ok {
+						vacate()
 						break loop
 					}
-					c <- v
+					src.wait(since)
+					t.consume(src)
+					if !src.internal {
+						t.expect()
+					}
+					if v, ok := t.admit(v, src); ok {
+						t.offer(c, v, src)
+					}
+				case <-pausing():
+					vacate()
+				case <-src.detach:
+					vacate()
+					t.flush(o, c, src)
+					break loop
 				case <-t.ctx.Done():
 					break loop
 				}
 			}
+			t.untrack(c)
 			close(c)
-		}(o)
+		}, "role", "input", "input", strconv.Itoa(src.n))
 
-		t.addOne(c, 0)
+		leaves = append(leaves, c)
+	}
+	t.plant(leaves, priority)
+}
+
+// plant adds the leaves of new inputs to the tree.
+func (t *tree[T]) plant(leaves []<-chan T, priority int) {
+	// Stop the previous collector goroutines
+	close(t.stop)
+	t.stop = make(chan struct{})
+
+	switch {
+	case t.ordered:
+		t.addOrdered(leaves)
+	case t.opts.lockstep():
+		for _, c := range leaves {
+			t.addOne(c, 0)
+		}
+	case t.opts.sequential:
+		n := t.sequence()
+		for _, c := range leaves {
+			t.adopt(n, c)
+		}
+	case priority > 0:
+		n := t.lane(priority)
+		for _, c := range leaves {
+			t.adopt(n, c)
+		}
+		t.countLanes(len(leaves))
+	case len(leaves) >= wideAdd && t.opts.arity() == 2 && t.opts.expected == 0:
+		t.addWide(leaves)
+	default:
+		for _, c := range leaves {
+			if !t.place(c) {
+				t.addOne(c, 0)
+			}
+		}
 	}
 	// Update the root receivers
 	t.updateCollectors()
 }
 
+// Remove stops reading from a channel that was passed to Add. The values
+// already buffered in it are flushed into the tree, the ones sent later are
+// left in the channel: Remove returns once the readers of the channel let
+// go of it, after handing their values to the tree. It reports whether the
+// channel was being read. Removing inputs doesn't restructure the tree, see
+// Rebalance.
+func (t *tree[T]) Remove(in <-chan T) bool {
+	t.unproduce(in)
+	t.srcMu.Lock()
+	srcs, ok := t.sources[in]
+	dones := make([]chan struct{}, len(srcs))
+	for i, src := range srcs {
+		close(src.detach)
+		dones[i] = src.done
+	}
+	delete(t.sources, in)
+	t.srcMu.Unlock()
+
+	// The inputs of Run are only read while it runs
+	if !t.inlining() {
+		t.awaitReaders(dones)
+	}
+	return ok
+}
+
+// awaitReaders waits for the readers of removed inputs to stop, unless they
+// didn't start yet, see WithDeferredStart.
+func (t *tree[T]) awaitReaders(dones []chan struct{}) {
+	t.startMu.Lock()
+	launched := t.launched
+	t.startMu.Unlock()
+	if !launched {
+		return
+	}
+	for _, done := range dones {
+		select {
+		case <-done:
+		case <-t.life.Done():
+		}
+	}
+}
+
+// flush moves the values buffered in a removed input into its leaf.
+func (t *tree[T]) flush(in <-chan T, leaf chan<- T, src *source) {
+	vacate := t.unclaim
+	if src.internal {
+		vacate = t.vacate
+	}
+	for {
+		if !src.internal && !t.claim(nil) {
+			return
+		}
+		select {
+		case v, ok := <-in:
+			if !ok {
+				vacate()
+				return
+			}
+			t.consume(src)
+			if !src.internal {
+				t.expect()
+			}
+			if v, ok := t.admit(v, src); ok {
+				t.forward(leaf, v)
+			}
+		default:
+			vacate()
+			return
+		}
+	}
+}
+
+// source is one registration of an input, a channel added twice has two.
+type source struct {
+	detach chan struct{}
+	// Closed once the reader stopped reading the input, see Remove
+	done     chan struct{}
+	read     atomic.Int64
+	last     atomic.Int64
+	waited   atomic.Int64
+	dropped  atomic.Int64
+	internal bool
+	sealed   bool
+	// Reduced from the log of WithWAL, so it isn't logged again
+	replayed bool
+	priority int
+	limit    *bucket
+	// The number of the input in the order they were added, see NewEnveloped
+	index int
+	// The number of the source in the order it was attached, for the
+	// profiler labels
+	n int
+	// When it was attached, see WithStrictClose
+	added time.Time
+}
+
+func (t *tree[T]) attach(in <-chan T) *source {
+	t.srcMu.Lock()
+	defer t.srcMu.Unlock()
+
+	_, internal := t.internal[in]
+	src := &source{detach: make(chan struct{}), done: make(chan struct{}), internal: internal, index: t.number(in), n: t.attached, added: time.Now()}
+	src.replayed = t.wal != nil && in == t.wal.replay
+	t.attached++
+	if !internal {
+		src.limit = newBucket(t.opts.sourceRate)
+	}
+	t.sources[in] = append(t.sources[in], src)
+	t.recordSource(src)
+	return src
+}
+
+// forget drops a source whose reader stopped
+func (t *tree[T]) forget(in <-chan T, src *source) {
+	defer close(src.done)
+	t.unrecordSource(src)
+	t.srcMu.Lock()
+	defer t.srcMu.Unlock()
+
+	srcs := t.sources[in]
+	for i, s := range srcs {
+		if s == src {
+			srcs = append(srcs[:i], srcs[i+1:]...)
+			break
+		}
+	}
+	if len(srcs) == 0 {
+		delete(t.sources, in)
+	} else {
+		t.sources[in] = srcs
+	}
+}
+
 func (t *tree[T]) Output() <-chan T {
 	return t.output
 }
 
+// Done returns a channel closed once the tree has fully finished: Finish,
+// FinishContext or Abort returned, the output is closed and every goroutine
+// of the tree exited, the ones of WithSink and WithOutputPartitioner
+// included. Unlike the end of the output, it is seen by any number of
+// goroutines, e.g. to shut down the components around the tree while
+// several of them read the output.
+func (t *tree[T]) Done() <-chan struct{} {
+	return t.done
+}
+
+// Finish is only run by its first call, like the first of FinishContext and
+// Abort: the other calls wait for it to return, and return ErrFinished, so
+// that a deferred Finish after the one of the happy path is safe.
 func (t *tree[T]) Finish() error {
+	if !t.markFinished() {
+		return t.finishedBefore()
+	}
+	defer t.settle()
+	return t.checkLeaks(t.finish())
+}
+
+// markFinished reports whether the tree wasn't finished yet, the caller must
+// then call settle once it is. It waits for a running Add, any later one
+// fails.
+func (t *tree[T]) markFinished() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished.Swap(true) {
+		return false
+	}
+	t.stopProducing()
+	t.launch()
+	return true
+}
+
+func (t *tree[T]) settle() {
+	close(t.settled)
+	done := t.done
+	go func() {
+		t.running.Wait()
+		close(done)
+	}()
+}
+
+// finishedBefore waits for the call that finished the tree to return. The
+// repeated call is only reported to its caller, it isn't an error of the
+// tree.
+func (t *tree[T]) finishedBefore() error {
+	<-t.settled
+	return ErrFinished
+}
+
+func (t *tree[T]) finish() (err error) {
+	span := t.startSpan(t.life, "treeduction.Finish", Attr{Key: "depth", Value: t.depth()})
+	defer span.end()
+	defer func() {
+		t.log(slog.LevelInfo, "finished", "emitted", t.emitted.Load(), "error", err)
+	}()
+	t.mu.Lock()
+	t.replayWAL()
+	t.mu.Unlock()
+	t.Resume()
+	defer t.watchClose()()
+	// The inputs of WithInline that no Run read, see Run
+	if t.inlining() && t.waitForAll {
+		t.Run(t.ctx)
+	}
+	t.finishSubtrees()
+	if t.classes != nil {
+		return t.finishClassified()
+	}
+
+	if t.pool != nil {
+		return t.finishPooled()
+	}
+	if t.ordered && t.waitForAll {
+		return t.finishOrdered()
+	}
+
+	t.mu.Lock()
+	t.sealOpen()
+	t.mu.Unlock()
+
 	if !t.waitForAll {
 		t.cancel()
 		t.wg.Wait()
-		close(t.output)
-		return nil
+		// The batches of WithBatchIsolation
+		t.epochs.Wait()
+		t.closeOutput()
+		return t.error()
 	}
 
 	// WaitForAll assumes that inputs should eventually stop (and channels closed)
 	t.wg.Wait()
+	t.epochs.Wait()
 	t.cancel()
 
+	t.emitFinal()
+	t.closeOutput()
+	return t.error()
+}
+
+// deliver sends a value that reached the root towards the output. It returns
+// false if stop fired before the value could be delivered. With waitForAll
+// the values are folded right away instead, so that they don't pile up in
+// the output before Finish.
+func (t *tree[T]) deliver(v T, stop <-chan struct{}) bool {
+	w := t.weight(v)
+	if t.aborted.Load() {
+		t.leave()
+		t.unhold(w)
+		return true
+	}
+	if l := t.loop.Load(); l != nil && l.feed(v) {
+		return true
+	}
+	if t.waitForAll {
+		t.fold(v)
+		t.leave()
+		t.unhold(w)
+		return true
+	}
+	t.check(v)
+	if t.rootIn == t.output && t.opts.backpressure != Block {
+		t.send(v)
+		t.leave()
+		t.unhold(w)
+		return true
+	}
+
+	// The number is only taken once the value is on the output
+	stamped := t.stamp != nil && t.rootIn == t.output
+	if stamped {
+		t.seqMu.Lock()
+		defer t.seqMu.Unlock()
+		v = t.stamp(v, t.seq+1)
+	}
+	// Nobody reads the output of a cancelled tree anymore, but what fits is
+	// still delivered
+	delivered := true
 	select {
-	case final := <-t.output:
-	s:
-		for {
-			select {
-			case v := <-t.output:
-				final = t.combiner(final, v)
-			default:
-				break s
-			}
+	case t.rootIn <- v:
+	default:
+		select {
+		case t.rootIn <- v:
+		case <-stop:
+			return false
+		case <-t.life.Done():
+			delivered = false
+		}
+	}
+	if delivered {
+		if stamped {
+			t.seq++
+		}
+		if t.rootIn == t.output {
+			t.sent(v)
+		}
+	}
+	t.leave()
+	t.unhold(w)
+	return true
+}
+
+func (t *tree[T]) fold(v T) {
+	t.accMu.Lock()
+	defer t.accMu.Unlock()
+	if t.folded {
+		t.acc = t.combiner(t.acc, v)
+	} else {
+		t.acc, t.folded = v, true
+	}
+	t.check(t.acc)
+}
+
+func (t *tree[T]) final() (T, bool) {
+	t.accMu.Lock()
+	defer t.accMu.Unlock()
+	return t.acc, t.folded
+}
+
+// emitFinal puts the waitForAll result on the output, which always has room
+// for it.
+func (t *tree[T]) emitFinal() {
+	if final, ok := t.final(); ok {
+		if t.stamp != nil {
+			t.seqMu.Lock()
+			defer t.seqMu.Unlock()
+			t.seq++
+			final = t.stamp(final, t.seq)
 		}
 		t.output <- final
-	default:
+		t.sent(final)
+		t.validate(final, t.nextRecord(false))
+	}
+}
+
+// closeOutput closes the output once nothing can reach the root anymore.
+func (t *tree[T]) closeOutput() {
+	// The errors of the inputs are reported by Finish
+	t.cancel()
+	t.watchers.Wait()
+
+	if t.emitDone != nil {
+		close(t.rootIn)
+		<-t.emitDone
 	}
+	t.reportProgress()
 	close(t.output)
-	return nil
+	if t.sinkDone != nil {
+		<-t.sinkDone
+	}
+	if t.partitioned != nil {
+		<-t.partitioned
+	}
+	// The parent may be cancelled without its failure being recorded yet
+	if t.unwatch != nil && !t.unwatch() {
+		t.fail(context.Cause(t.opts.ctx))
+	}
+	if t.unstart != nil {
+		t.unstart()
+	}
+	t.kill()
 }
 
 func (t *tree[T]) updateCollectors() {
-	// Stop the previous select goroutings
-	close(t.stop)
-	t.stop = make(chan struct{})
-
-	for _, ch := range t.roots {
-		if ch == nil {
-			continue
+	if t.ordered {
+		// With waitForAll the runs are only read by Finish, so that every
+		// round is folded in order no matter how many Add calls came first
+		if !t.waitForAll && len(t.runs) > 0 {
+			t.collectRuns()
 		}
+		return
+	}
 
+	if len(t.lanes) > 0 {
+		t.collectLanes()
+	}
+	if t.opts.lockstep() {
+		t.collectInTurn()
+		return
+	}
+	for _, ch := range t.levels.roots() {
 		t.wg.Add(1)
-		go func(c <-chan T) {
+		c, stop := ch, t.stop
+		t.spawn(func() {
+			if !t.waitBatch(stop) {
+				t.wg.Done()
+				return
+			}
 		Inner:
 			for {
+				// A collector started late must not read from the new tree
+				select {
+				case <-stop:
+					break Inner
+				default:
+				}
 				select {
-				case <-t.stop:
+				case <-stop:
 					break Inner
 				case v, ok := <-c:
 					if !ok {
 						break Inner
 					}
-					t.output <- v
+					t.deliver(v, nil)
 				}
 			}
 			t.wg.Done()
-		}(ch)
+		}, "role", "collector")
 	}
 }
 
 func (t *tree[T]) addOne(root <-chan T, level int) {
-	// Extend the slice to the level
-	for i := len(t.roots); i <= level; i++ {
-		t.roots = append(t.roots, nil)
+	if !t.ordered && t.opts.arity() != 2 {
+		t.addChild(root, level)
+		return
 	}
 
-	if t.roots[level] == nil {
-		t.roots[level] = root
+	l := t.levels.get(level)
+	if l.root == nil {
+		l.root = root
 		return
 	}
 
-	prev := t.roots[level]
-	t.roots[level] = nil
+	prev := l.root
+	l.root = nil
+	t.log(slog.LevelDebug, "level promoted", "level", level+1)
 	var c <-chan T
-	if t.ordered {
-		c = t.orderedNode(prev, root)
+	if t.ordered || t.opts.lockstep() {
+		c = t.orderedNode(prev, root, level+1)
 	} else {
-		c = t.unorderedNode(prev, root)
+		c = t.unorderedNode(prev, root, level+1)
 	}
 	t.addOne(c, level+1)
 }
 
-func (t *tree[T]) unorderedNode(f <-chan T, s <-chan T) <-chan T {
-	c := make(chan T, t.bufSize)
-	go func() {
-		fanIn := make(chan T, t.bufSize)
-		var wg sync.WaitGroup
-		wg.Add(2)
-		go func() {
-			for v := range f {
-				fanIn <- v
+func (t *tree[T]) unorderedNode(f <-chan T, s <-chan T, level int) <-chan T {
+	if t.newNode != nil {
+		return t.customNode(f, s, level)
+	}
+	c := make(chan T, t.bufAt(level))
+	t.track(c, "unordered", f, s)
+	closed := t.nodeCreated()
+	labels := levelLabels(level)
+	t.spawn(func() {
+		fanIn := t.newResizable(t.bufAt(level))
+		first := fanIn.c
+		t.spawn(func() {
+			// A single goroutine forwards both children, as the tree has
+			// one per node
+			for f != nil || s != nil {
+				select {
+				case v, ok := <-f:
+					if !ok {
+						f = nil
+						continue
+					}
+					t.fill(fanIn, v)
+				case v, ok := <-s:
+					if !ok {
+						s = nil
+						continue
+					}
+					t.fill(fanIn, v)
+				}
 			}
-			wg.Done()
-		}()
+			t.closeResizable(fanIn)
+		}, labels...)
 
-		go func() {
-			for v := range s {
-				fanIn <- v
-			}
-			wg.Done()
-		}()
+		fanIn.drain(first, func(in <-chan T) {
+			t.combineNode(in, c, nil)
+		})
+		closed()
+		t.untrack(c)
+		close(c)
+	}, labels...)
 
-		go func() {
-			wg.Wait()
-			close(fanIn)
-		}()
+	return c
+}
 
-		for {
-			v1, ok := <-fanIn
-			if !ok {
-				break
-			}
+// combinePairs combines the values of fanIn two by two into c, until fanIn
+// is closed.
+func (t *tree[T]) combinePairs(fanIn <-chan T, c chan<- T, idle func() <-chan struct{}) {
+	for {
+		v1, ok := <-fanIn
+		if !ok {
+			return
+		}
+		var drained <-chan struct{}
+		if idle != nil {
+			drained = idle()
+		}
 
-			v2, ok := <-fanIn
-			if !ok {
-				c <- v1
-				break
+		var v2 T
+		alone := false
+		select {
+		case v2, ok = <-fanIn:
+		case <-t.pausing():
+			alone = true
+		case <-t.starving():
+			alone = true
+		case <-drained:
+			alone = true
+		}
+		if alone {
+			// Don't hold v1 back while the tree is paused, out of slots or
+			// the node has no open child
+			select {
+			case v2, ok = <-fanIn:
+			default:
+				t.put(c, v1)
+				continue
 			}
-			c <- t.combiner(v1, v2)
 		}
-
-		close(c)
-	}()
-
-	return c
+		if !ok {
+			t.put(c, v1)
+			return
+		}
+		if t.opts.perValue() {
+			t.put(c, v1)
+			t.put(c, v2)
+			continue
+		}
+		t.put(c, t.batch(t.combine(v1, v2), fanIn, 2))
+	}
 }
 
-func (t *tree[T]) orderedNode(f <-chan T, s <-chan T) <-chan T {
-	c := make(chan T, t.bufSize)
-	go func() {
+func (t *tree[T]) orderedNode(f <-chan T, s <-chan T, level int) <-chan T {
+	c := make(chan T, t.bufAt(level))
+	t.track(c, "ordered", f, s)
+	closed := t.nodeCreated()
+	t.spawn(func() {
+		if d := t.opts.pairingTimeout; d > 0 {
+			t.pairOrTimeout(f, s, c, d)
+		}
 		for {
 			v1, ok := <-f
 			if !ok {
+				// Pass the rest of the other side through
+				for v := range s {
+					t.put(c, t.padLeft(v))
+				}
 				break
 			}
 
 			v2, ok := <-s
 			if !ok {
-				c <- v1
+				t.put(c, t.padRight(v1))
+				for v := range f {
+					t.put(c, t.padRight(v))
+				}
 				break
 			}
+			if t.opts.perValue() {
+				t.put(c, v1)
+				t.put(c, v2)
+				continue
+			}
 
-			c <- t.combiner(v1, v2)
+			t.put(c, t.combine(v1, v2))
 		}
+		closed()
+		t.untrack(c)
 		close(c)
-	}()
+	}, levelLabels(level)...)
 
 	return c
 }
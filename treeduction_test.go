@@ -2,6 +2,8 @@ package treeduction_test
 
 import (
 	"fmt"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 	"treeduction"
@@ -343,6 +345,204 @@ func TestWaitForAllVsNonWaitForAll(t *testing.T) {
 	}
 }
 
+// TestConcurrentAdd tests adding channels from multiple goroutines.
+func TestConcurrentAdd(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ch := make(chan int, 1)
+			ch <- i
+			close(ch)
+			tree.Add(ch)
+		}()
+	}
+	wg.Wait()
+
+	tree.Finish()
+	if result := <-tree.Output(); result != 4950 {
+		t.Errorf("Expected result to be 4950, got %d", result)
+	}
+}
+
+// TestWaitForAllManyAdds tests that partial results from many separate Adds
+// don't have to fit in the output buffer before Finish.
+func TestWaitForAllManyAdds(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 2, true, false)
+
+	sum := 0
+	for i := range 200 {
+		ch := make(chan int, 3)
+		for j := range 3 {
+			ch <- i + j
+			sum += i + j
+		}
+		close(ch)
+		tree.Add(ch)
+	}
+
+	tree.Finish()
+	if result := <-tree.Output(); result != sum {
+		t.Errorf("Expected result to be %d, got %d", sum, result)
+	}
+}
+
+// TestWaitForAllInFlight tests that Finish folds the values that are still
+// buffered in the nodes or being combined when it is called.
+func TestWaitForAllInFlight(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		time.Sleep(10 * time.Microsecond)
+		return a + b
+	}, 1, true, false)
+
+	sum := 0
+	for i := range 64 {
+		ch := make(chan int, 50)
+		for j := range 50 {
+			ch <- i*50 + j
+			sum += i*50 + j
+		}
+		close(ch)
+		tree.Add(ch)
+	}
+
+	// Nothing had the time to reach the root yet
+	tree.Finish()
+	if result := <-tree.Output(); result != sum {
+		t.Errorf("Expected result to be %d, got %d", sum, result)
+	}
+}
+
+// TestOrderedAcrossAdds tests that ordered mode folds inputs from left to right
+// in the order they were added, even when they are added in several calls.
+func TestOrderedAcrossAdds(t *testing.T) {
+	for range 20 {
+		tree := treeduction.New(func(a, b string) string {
+			return a + b
+		}, 10, true, true)
+
+		send := func(vs ...string) <-chan string {
+			ch := make(chan string, len(vs))
+			for _, v := range vs {
+				ch <- v
+			}
+			close(ch)
+			return ch
+		}
+
+		tree.Add(send("a"), send("b"))
+		tree.Add(send("c"))
+		tree.Add(send("d"), send("e"), send("f"))
+		tree.Add(send("g"))
+
+		tree.Finish()
+
+		result := <-tree.Output()
+		if result != "abcdefg" {
+			t.Fatalf("Expected result to be %q, got %q", "abcdefg", result)
+		}
+	}
+}
+
+// TestOrderedRounds tests that ordered mode combines values round by round.
+func TestOrderedRounds(t *testing.T) {
+	tree := treeduction.New(func(a, b string) string {
+		return a + b
+	}, 10, true, true)
+
+	ch1 := make(chan string, 2)
+	ch1 <- "a"
+	ch1 <- "x"
+	close(ch1)
+	tree.Add(ch1)
+
+	ch2 := make(chan string, 2)
+	ch2 <- "b"
+	ch2 <- "y"
+	close(ch2)
+	tree.Add(ch2)
+
+	tree.Finish()
+
+	// Round 0 is "ab" and round 1 is "xy"
+	result := <-tree.Output()
+	if result != "abxy" {
+		t.Errorf("Expected result to be %q, got %q", "abxy", result)
+	}
+}
+
+// TestOrderedStreamReproducible tests that an ordered tree emits the same
+// stream of results on every run.
+func TestOrderedStreamReproducible(t *testing.T) {
+	var first []string
+	for run := range 20 {
+		tree := treeduction.New(func(a, b string) string {
+			return a + b
+		}, 10, false, true)
+		var inputs []<-chan string
+		for _, in := range []string{"abc", "def", "ghi", "jkl", "mno"} {
+			ch := make(chan string, len(in))
+			for _, r := range in {
+				ch <- string(r)
+			}
+			close(ch)
+			inputs = append(inputs, ch)
+		}
+		tree.Add(inputs...)
+
+		var stream []string
+		for range 3 {
+			stream = append(stream, <-tree.Output())
+		}
+		tree.Finish()
+		if run == 0 {
+			first = stream
+		} else if !slices.Equal(stream, first) {
+			t.Fatalf("Expected the stream %v, got %v", first, stream)
+		}
+	}
+	if !slices.Equal(first, []string{"adgjm", "behkn", "cfilo"}) {
+		t.Errorf("Expected the rounds, got %v", first)
+	}
+}
+
+// TestOrderedStreamingAcrossAdds tests that a later Add in streaming ordered
+// mode continues the chain after everything that was already emitted.
+func TestOrderedStreamingAcrossAdds(t *testing.T) {
+	tree := treeduction.New(func(a, b string) string {
+		return a + b
+	}, 10, false, true)
+	defer tree.Finish()
+
+	ch1 := make(chan string, 1)
+	ch1 <- "a"
+	close(ch1)
+	tree.Add(ch1)
+
+	if result := <-tree.Output(); result != "a" {
+		t.Fatalf("Expected result to be %q, got %q", "a", result)
+	}
+
+	ch2 := make(chan string, 1)
+	ch3 := make(chan string, 1)
+	ch2 <- "b"
+	ch3 <- "c"
+	close(ch2)
+	close(ch3)
+	tree.Add(ch2, ch3)
+
+	if result := <-tree.Output(); result != "bc" {
+		t.Errorf("Expected result to be %q, got %q", "bc", result)
+	}
+}
+
 // Example usage.
 func ExampleNew() {
 	// Create a tree reducer that concatenates strings
@@ -400,3 +600,53 @@ func ExampleNew_waitForAll() {
 	fmt.Println(<-tree.Output())
 	// Output: 15
 }
+
+// TestDone tests that Done is closed once the tree finished and its
+// goroutines exited, for every goroutine waiting on it.
+func TestDone(t *testing.T) {
+	tree := treeduction.With(treeduction.WithSink(func(int) error {
+		return nil
+	}, treeduction.Retry{})).New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	tree.AddValues(1, 2, 3)
+	select {
+	case <-tree.Done():
+		t.Fatal("Expected Done to be open before Finish")
+	default:
+	}
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-tree.Done():
+			case <-time.After(time.Second):
+				t.Error("Expected Done to be closed")
+			}
+		}()
+	}
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if g := tree.Stats().Goroutines; g != 0 {
+		t.Errorf("Expected no goroutine, got %d", g)
+	}
+	if _, ok := <-tree.Output(); ok {
+		t.Error("Expected the output to be closed")
+	}
+
+	if err := tree.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-tree.Done():
+		t.Error("Expected Done to be open after Reset")
+	default:
+	}
+	tree.Abort()
+	<-tree.Done()
+}
@@ -0,0 +1,44 @@
+package treetest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrNotAssociative is reported by CheckCombiner when the grouping of
+	// the values changes the result, which any tree needs.
+	ErrNotAssociative = errors.New("treetest: combiner is not associative")
+	// ErrNotCommutative is reported by CheckCombiner when the order of the
+	// values changes the result, which only ordered trees allow.
+	ErrNotCommutative = errors.New("treetest: combiner is not commutative")
+)
+
+// CheckCombiner property-tests combiner on n triples of values made by gen,
+// compared with reflect.DeepEqual. It returns ErrNotAssociative and
+// ErrNotCommutative, joined, with the first counterexample of each: a
+// combiner for an ordered tree may ignore the latter. A float sum is
+// associative only up to rounding, so it fails unless gen avoids it (e.g.
+// with small integers).
+func CheckCombiner[T any](combiner func(f T, s T) T, gen func() T, n int) error {
+	var assoc, comm error
+	for range n {
+		a, b, c := gen(), gen(), gen()
+		if assoc == nil {
+			l, r := combiner(combiner(a, b), c), combiner(a, combiner(b, c))
+			if !reflect.DeepEqual(l, r) {
+				assoc = fmt.Errorf("%w: combiner(combiner(%v, %v), %v) = %v, combiner(%v, combiner(%v, %v)) = %v",
+					ErrNotAssociative, a, b, c, l, a, b, c, r)
+			}
+		}
+		if comm == nil {
+			l, r := combiner(a, b), combiner(b, a)
+			if !reflect.DeepEqual(l, r) {
+				comm = fmt.Errorf("%w: combiner(%v, %v) = %v, combiner(%v, %v) = %v",
+					ErrNotCommutative, a, b, l, b, a, r)
+			}
+		}
+	}
+	return errors.Join(assoc, comm)
+}
@@ -0,0 +1,31 @@
+package treetest_test
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"treeduction/treetest"
+)
+
+// TestCheckCombiner tests that broken combiners are caught.
+func TestCheckCombiner(t *testing.T) {
+	gen := func() int {
+		return rand.IntN(100)
+	}
+	if err := treetest.CheckCombiner(func(a, b int) int { return a + b }, gen, 100); err != nil {
+		t.Errorf("Expected the sum to pass, got %v", err)
+	}
+
+	// Ordered trees need associativity only
+	err := treetest.CheckCombiner(func(a, b []int) []int {
+		return append(append([]int{}, a...), b...)
+	}, func() []int { return []int{gen()} }, 100)
+	if errors.Is(err, treetest.ErrNotAssociative) || !errors.Is(err, treetest.ErrNotCommutative) {
+		t.Errorf("Expected concatenation not to be commutative only, got %v", err)
+	}
+
+	err = treetest.CheckCombiner(func(a, b int) int { return a - b }, gen, 100)
+	if !errors.Is(err, treetest.ErrNotAssociative) || !errors.Is(err, treetest.ErrNotCommutative) {
+		t.Errorf("Expected the difference to fail both, got %v", err)
+	}
+}
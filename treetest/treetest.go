@@ -0,0 +1,108 @@
+// Package treetest helps testing code built on reduction trees without
+// sleeping: a Combiner holds every combine of a tree until the test runs
+// it, and WaitStats waits for the tree to reach a state. CheckCombiner
+// checks that a combiner can be trusted with a tree at all.
+package treetest
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"treeduction"
+)
+
+// Timeout is how long Next and WaitStats wait before failing the test.
+var Timeout = 10 * time.Second
+
+// Combiner wraps a combiner so that every combine waits for the test to
+// run it, one at a time, with Next or Step. Pass Func to the constructor of
+// the tree.
+type Combiner[T any] struct {
+	f       func(f T, s T) T
+	calls   chan *Call[T]
+	once    sync.Once
+	release chan struct{}
+}
+
+// Call is a combine waiting to be run.
+type Call[T any] struct {
+	F, S   T
+	result T
+	f      func(f T, s T) T
+	done   chan struct{}
+}
+
+// NewCombiner wraps f.
+func NewCombiner[T any](f func(f T, s T) T) *Combiner[T] {
+	return &Combiner[T]{f: f, calls: make(chan *Call[T]), release: make(chan struct{})}
+}
+
+// Func returns the combiner to give to the tree.
+func (c *Combiner[T]) Func() func(f T, s T) T {
+	return func(f T, s T) T {
+		call := &Call[T]{F: f, S: s, f: c.f, done: make(chan struct{})}
+		select {
+		case c.calls <- call:
+			<-call.done
+			return call.result
+		case <-c.release:
+			return c.f(f, s)
+		}
+	}
+}
+
+// Next returns the next combine of the tree, which waits until it is run.
+// It fails the test if no combine comes within Timeout.
+func (c *Combiner[T]) Next(tb testing.TB) *Call[T] {
+	tb.Helper()
+	select {
+	case call := <-c.calls:
+		return call
+	case <-time.After(Timeout):
+		tb.Fatalf("treetest: no combine within %v", Timeout)
+		return nil
+	}
+}
+
+// Step runs the next combine of the tree, and returns its arguments and its
+// result.
+func (c *Combiner[T]) Step(tb testing.TB) (f T, s T, r T) {
+	tb.Helper()
+	call := c.Next(tb)
+	return call.F, call.S, call.Run()
+}
+
+// Release lets the combines run without waiting for the test from now on,
+// e.g. before finishing the tree.
+func (c *Combiner[T]) Release() {
+	c.once.Do(func() {
+		close(c.release)
+	})
+}
+
+// Run runs the combine and lets the tree go on with its result.
+func (call *Call[T]) Run() T {
+	call.result = call.f(call.F, call.S)
+	close(call.done)
+	return call.result
+}
+
+// WaitStats waits until cond holds for the stats of the tree, and fails
+// the test if it doesn't within Timeout. The stats are polled, yielding to
+// the goroutines of the tree in between.
+func WaitStats[T any](tb testing.TB, tree treeduction.Tree[T], cond func(treeduction.Stats[T]) bool) treeduction.Stats[T] {
+	tb.Helper()
+	deadline := time.Now().Add(Timeout)
+	for {
+		s := tree.Stats()
+		if cond(s) {
+			return s
+		}
+		if time.Now().After(deadline) {
+			tb.Fatalf("treetest: the tree didn't reach the state within %v: %+v", Timeout, s)
+		}
+		runtime.Gosched()
+	}
+}
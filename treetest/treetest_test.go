@@ -0,0 +1,70 @@
+package treetest_test
+
+import (
+	"testing"
+	"treeduction"
+	"treeduction/treetest"
+)
+
+// TestStep tests that the combines of an ordered tree can be run one by
+// one.
+func TestStep(t *testing.T) {
+	c := treetest.NewCombiner(func(a, b string) string {
+		return a + b
+	})
+	tree := treeduction.New(c.Func(), 0, false, true)
+
+	left, right := make(chan string), make(chan string)
+	tree.Add(left, right)
+	left <- "a"
+	right <- "b"
+	if f, s, r := c.Step(t); f != "a" || s != "b" || r != "ab" {
+		t.Errorf("Expected a + b = ab, got %s + %s = %s", f, s, r)
+	}
+	if v := <-tree.Output(); v != "ab" {
+		t.Errorf("Expected ab, got %s", v)
+	}
+
+	// Nothing is combined until the test runs it
+	left <- "c"
+	right <- "d"
+	call := c.Next(t)
+	select {
+	case v := <-tree.Output():
+		t.Errorf("Expected no result before the combine runs, got %s", v)
+	default:
+	}
+	call.Run()
+	if v := <-tree.Output(); v != "cd" {
+		t.Errorf("Expected cd, got %s", v)
+	}
+
+	c.Release()
+	close(left)
+	close(right)
+	tree.Finish()
+}
+
+// TestWaitStats tests waiting for the tree to read the values.
+func TestWaitStats(t *testing.T) {
+	c := treetest.NewCombiner(func(a, b int) int {
+		return a + b
+	})
+	tree := treeduction.New(c.Func(), 10, true, false)
+
+	in := make(chan int, 3)
+	in <- 1
+	in <- 2
+	in <- 3
+	tree.Add(in)
+	treetest.WaitStats(t, tree, func(s treeduction.Stats[int]) bool {
+		return s.Consumed[in] == 3
+	})
+
+	c.Release()
+	close(in)
+	tree.Finish()
+	if v := <-tree.Output(); v != 6 {
+		t.Errorf("Expected 6, got %d", v)
+	}
+}
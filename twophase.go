@@ -0,0 +1,18 @@
+package treeduction
+
+// NewTwoPhase is like NewCounted with two combiners: local combines the
+// values of the same input, in the goroutine reading it (see
+// WithLocalFold), and merge combines the partials of different inputs in
+// the tree. local is often much cheaper (incrementing a count, inserting
+// into a sketch) than merge (merging two sketches), which then only runs
+// once per partial instead of once per value.
+func NewTwoPhase[T any](local func(f T, s T) T, merge func(f T, s T) T, bufferSize int, waitForAll bool, ordered bool, opts ...Option) *Folder[T, Reduced[T]] {
+	return Fold(Reduced[T]{}, func(r Reduced[T], v T) Reduced[T] {
+		if r.Count == 0 {
+			return Reduced[T]{Value: v, Count: 1}
+		}
+		return Reduced[T]{Value: local(r.Value, v), Count: r.Count + 1}
+	}, func(f Reduced[T], s Reduced[T]) Reduced[T] {
+		return Reduced[T]{Value: merge(f.Value, s.Value), Count: f.Count + s.Count}
+	}, bufferSize, waitForAll, ordered, append(opts, WithLocalFold())...)
+}
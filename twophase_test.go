@@ -0,0 +1,39 @@
+package treeduction_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"treeduction"
+)
+
+// TestTwoPhase tests that merge only combines the partials of different
+// inputs.
+func TestTwoPhase(t *testing.T) {
+	var merges atomic.Int64
+	tree := treeduction.NewTwoPhase(func(a, b int) int {
+		return a + b
+	}, func(a, b int) int {
+		merges.Add(1)
+		return a + b
+	}, 10, true, false)
+
+	inputs := make([]<-chan int, 4)
+	for i := range inputs {
+		c := make(chan int, 100)
+		for range 100 {
+			c <- 1
+		}
+		close(c)
+		inputs[i] = c
+	}
+	tree.Add(inputs...)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-tree.Output(); r.Value != 400 || r.Count != 400 {
+		t.Errorf("Expected 400 values summing to 400, got %d summing to %d", r.Count, r.Value)
+	}
+	if n := merges.Load(); n != 3 {
+		t.Errorf("Expected 3 merges of the partials of 4 inputs, got %d", n)
+	}
+}
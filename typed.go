@@ -0,0 +1,50 @@
+package treeduction
+
+import "time"
+
+// TypedOption configures a behaviour of a tree that takes its values, like
+// WithFilter. It is passed to the constructors of With, so that an option
+// for values of another type than the ones of the tree doesn't compile.
+type TypedOption[T any] func(*options, *typed[T])
+
+// typed are the options of a tree of T that take its values.
+type typed[T any] struct {
+	filter    func(T) bool
+	dedupKey  func(T) uint64
+	classify  func(T) string
+	equal     func(a, b T) bool
+	hook      func(a, b, result T, d time.Duration)
+	newNode   func(level int) Node[T]
+	satisfied func(T) bool
+	sink      func(T) error
+	partition func(T) int
+	padding   *T
+	walCodec  Codec[T]
+	weigh     func(T) int64
+	beat      func() T
+}
+
+// Typed makes trees of T with typed options, see With.
+type Typed[T any] struct {
+	opts []TypedOption[T]
+}
+
+// With returns the constructors of trees with the typed options opts, on
+// top of the options passed to each constructor:
+//
+//	tree := treeduction.With(treeduction.WithFilter(keep)).New(combiner, 10, true, false)
+func With[T any](opts ...TypedOption[T]) Typed[T] {
+	return Typed[T]{opts: opts}
+}
+
+// applyTyped applies the typed options to o.
+func applyTyped[T any](o *options, opts []TypedOption[T]) typed[T] {
+	var ty typed[T]
+	for _, opt := range opts {
+		opt(o, &ty)
+	}
+	if ty.partition != nil && ty.sink != nil {
+		panic("treeduction: the results can't go both to a sink and to partitions")
+	}
+	return ty
+}
@@ -0,0 +1,44 @@
+package treeduction
+
+// AddUntil reduces the values of ch until isLast returns true for one of
+// them, for sources that signal their end with a sentinel value instead of
+// closing a channel they share with others: the sentinel is reduced too
+// with keepLast, and ch isn't read past it. Like a producer passed to Go,
+// Finish waits for the sentinel with waitForAll.
+func (t *tree[T]) AddUntil(ch <-chan T, isLast func(T) bool, keepLast bool) error {
+	return t.Go(untilLast(ch, isLast, keepLast, t.ctx.Done(), same[T]))
+}
+
+// AddUntil reduces the values of ch until the sentinel isLast reports, see
+// Tree.AddUntil.
+func (f *Folder[T, A]) AddUntil(ch <-chan T, isLast func(T) bool, keepLast bool) error {
+	return f.Go(untilLast(ch, isLast, keepLast, f.ctx.Done(), same[T]))
+}
+
+func same[T any](v T) T {
+	return v
+}
+
+// untilLast returns a producer of the values of ch up to the sentinel,
+// converted by conv, which stops once done is closed.
+func untilLast[T, U any](ch <-chan U, isLast func(U) bool, keepLast bool, done <-chan struct{}, conv func(U) T) func(emit func(T)) error {
+	return func(emit func(T)) error {
+		for {
+			select {
+			case v, ok := <-ch:
+				if !ok {
+					return nil
+				}
+				last := isLast(v)
+				if !last || keepLast {
+					emit(conv(v))
+				}
+				if last {
+					return nil
+				}
+			case <-done:
+				return nil
+			}
+		}
+	}
+}
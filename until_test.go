@@ -0,0 +1,39 @@
+package treeduction_test
+
+import (
+	"testing"
+	"treeduction"
+)
+
+// TestAddUntil tests that a channel is read up to its sentinel, which is
+// only reduced with keepLast.
+func TestAddUntil(t *testing.T) {
+	for _, tt := range []struct {
+		keepLast bool
+		want     int
+	}{
+		{false, 6},
+		{true, 16},
+	} {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 10, true, false)
+		// Shared with another reader, so it isn't closed
+		c := make(chan int, 5)
+		for _, v := range []int{1, 2, 3, 10, 100} {
+			c <- v
+		}
+		tree.AddUntil(c, func(v int) bool {
+			return v == 10
+		}, tt.keepLast)
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		if v := <-tree.Output(); v != tt.want {
+			t.Errorf("Expected %d, got %d", tt.want, v)
+		}
+		if v := <-c; v != 100 {
+			t.Errorf("Expected 100 left in the channel, got %d", v)
+		}
+	}
+}
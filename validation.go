@@ -0,0 +1,137 @@
+package treeduction
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+)
+
+// WithValidation checks the combiner of a waitForAll tree while it runs: for
+// a sample fraction of the epochs (see Seal and WithIdleFlush, the values
+// reduced before Finish being the last one), the values read from the inputs
+// are recorded, folded from left to right in the order they were read once
+// the result of the epoch is out, and compared to it with equal. A
+// divergence, the sign of a combiner that isn't associative and commutative,
+// fails the tree with a *ValidationError, after the result was put on the
+// output. The recorded values are kept until the end of their epoch and the
+// fold runs the combiner (and WithCombineHook) once more per value, so a
+// small sample suits production. The values are recorded as they are read,
+// so it doesn't suit an in-place combiner. It only applies to unordered
+// trees without WithMaxWorkers.
+func WithValidation[T any](sample float64, equal func(a, b T) bool) TypedOption[T] {
+	if sample < 0 || sample > 1 {
+		panic("treeduction: validation sample must be between 0 and 1")
+	}
+	return func(o *options, ty *typed[T]) {
+		o.validation = sample
+		ty.equal = equal
+	}
+}
+
+// ValidationError is reported when the result of an epoch differs from the
+// sequential fold of its values, see WithValidation.
+type ValidationError[T any] struct {
+	// Result is the result of the tree, and Sequential the fold of the
+	// values of the epoch, of which there are Values.
+	Result     T
+	Sequential T
+	Values     int
+}
+
+func (e *ValidationError[T]) Error() string {
+	return fmt.Sprintf("treeduction: result %v differs from the sequential fold %v of %d values", e.Result, e.Sequential, e.Values)
+}
+
+// record holds the values read in an epoch that is validated.
+type record[T any] struct {
+	mu     sync.Mutex
+	values []T
+}
+
+func (t *tree[T]) validating() bool {
+	return t.equal != nil && t.waitForAll && !t.ordered && !t.opts.pooled()
+}
+
+// sampleRecord returns the record of a new epoch, or nil if it isn't
+// validated.
+func (t *tree[T]) sampleRecord() *record[T] {
+	if !t.validating() || rand.Float64() >= t.opts.validation {
+		return nil
+	}
+	return &record[T]{}
+}
+
+// nextRecord starts the record of the next epoch, and returns the one of
+// the current epoch. With all, the inputs being read move to the next
+// epoch, otherwise only the ones added later take part in it.
+func (t *tree[T]) nextRecord(all bool) *record[T] {
+	if !t.validating() {
+		return nil
+	}
+	t.recMu.Lock()
+	defer t.recMu.Unlock()
+	rec := t.record
+	t.record = t.sampleRecord()
+	if all {
+		for src := range t.recording {
+			t.recording[src] = t.record
+		}
+	}
+	return rec
+}
+
+// recordSource puts a new input in the current epoch.
+func (t *tree[T]) recordSource(src *source) {
+	if !t.validating() {
+		return
+	}
+	t.recMu.Lock()
+	defer t.recMu.Unlock()
+	t.recording[src] = t.record
+}
+
+// unrecordSource forgets an input that won't be read anymore.
+func (t *tree[T]) unrecordSource(src *source) {
+	if !t.validating() {
+		return
+	}
+	t.recMu.Lock()
+	defer t.recMu.Unlock()
+	delete(t.recording, src)
+}
+
+// remember records a value read from src, if its epoch is validated.
+func (t *tree[T]) remember(v T, src *source) {
+	if !t.validating() {
+		return
+	}
+	t.recMu.Lock()
+	rec := t.recording[src]
+	t.recMu.Unlock()
+	if rec != nil {
+		rec.mu.Lock()
+		rec.values = append(rec.values, v)
+		rec.mu.Unlock()
+	}
+}
+
+// validate compares the result of an epoch to the sequential fold of the
+// values of its record.
+func (t *tree[T]) validate(v T, rec *record[T]) {
+	if rec == nil {
+		return
+	}
+	rec.mu.Lock()
+	values := rec.values
+	rec.mu.Unlock()
+	if len(values) == 0 {
+		return
+	}
+	seq := values[0]
+	for _, x := range values[1:] {
+		seq = t.combiner(seq, x)
+	}
+	if !t.equal(v, seq) {
+		t.fail(&ValidationError[T]{Result: v, Sequential: seq, Values: len(values)})
+	}
+}
@@ -0,0 +1,74 @@
+package treeduction_test
+
+import (
+	"errors"
+	"testing"
+	"treeduction"
+)
+
+// depth is a sum that also counts the combines on the left of the longest
+// chain, which a left fold makes as long as it can be.
+type depth struct{ sum, depth int }
+
+func combineDepth(a, b depth) depth {
+	return depth{a.sum + b.sum, max(a.depth, b.depth) + 1}
+}
+
+func depths(n int) <-chan depth {
+	c := make(chan depth, n)
+	for range n {
+		c <- depth{sum: 1}
+	}
+	close(c)
+	return c
+}
+
+// TestValidation tests that a combiner that doesn't give the result of a
+// left fold fails the tree, unless the epoch isn't sampled.
+func TestValidation(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		sample float64
+		equal  func(a, b depth) bool
+		fails  bool
+	}{
+		{"sum", 1, func(a, b depth) bool { return a.sum == b.sum }, false},
+		{"depth", 1, func(a, b depth) bool { return a == b }, true},
+		{"not sampled", 0, func(a, b depth) bool { return a == b }, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := treeduction.With(treeduction.WithValidation(tt.sample, tt.equal)).New(combineDepth, 10, true, false)
+			tree.Add(depths(50), depths(50), depths(50), depths(50))
+			err := tree.Finish()
+			if v := <-tree.Output(); v.sum != 200 {
+				t.Errorf("Expected 200, got %d", v.sum)
+			}
+			var verr *treeduction.ValidationError[depth]
+			if tt.fails != errors.As(err, &verr) {
+				t.Fatalf("Expected a validation error: %v, got %v", tt.fails, err)
+			}
+			if tt.fails && (verr.Values != 200 || verr.Sequential.depth != 199) {
+				t.Errorf("Expected the fold of 200 values, got %+v", verr)
+			}
+		})
+	}
+}
+
+// TestValidationEpochs tests that every sealed epoch is validated on its
+// own values.
+func TestValidationEpochs(t *testing.T) {
+	tree := treeduction.With(treeduction.WithValidation(1, func(a, b int) bool {
+		return a == b
+	})).New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	tree.AddValues(1, 2, 3)
+	tree.Seal()
+	tree.AddValues(4, 5)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if a, b := <-tree.Output(), <-tree.Output(); a != 6 || b != 9 {
+		t.Errorf("Expected 6 and 9, got %d and %d", a, b)
+	}
+}
@@ -0,0 +1,16 @@
+package treeduction
+
+// AddSlice adds the values of s as a single input of the tree.
+func (t *tree[T]) AddSlice(s []T) error {
+	c := make(chan T, len(s))
+	for _, v := range s {
+		c <- v
+	}
+	close(c)
+	return t.Add(c)
+}
+
+// AddValues adds the given values as a single input of the tree.
+func (t *tree[T]) AddValues(vs ...T) error {
+	return t.AddSlice(vs)
+}
@@ -0,0 +1,36 @@
+package treeduction_test
+
+import (
+	"testing"
+	"treeduction"
+)
+
+// TestAddSlice tests reducing in-memory values.
+func TestAddSlice(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+
+	tree.AddSlice([]int{1, 2, 3})
+	tree.AddValues(4, 5)
+	tree.AddValues()
+
+	tree.Finish()
+	if result := <-tree.Output(); result != 15 {
+		t.Errorf("Expected result to be 15, got %d", result)
+	}
+}
+
+// TestAddSliceOrdered tests that the values of a slice are folded in order.
+func TestAddSliceOrdered(t *testing.T) {
+	tree := treeduction.New(func(a, b string) string {
+		return a + b
+	}, 10, true, true)
+
+	tree.AddSlice([]string{"a", "b", "c"})
+
+	tree.Finish()
+	if result := <-tree.Output(); result != "abc" {
+		t.Errorf("Expected result to be %q, got %q", "abc", result)
+	}
+}
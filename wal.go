@@ -0,0 +1,171 @@
+package treeduction
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// WithWAL writes every value read from the inputs to a log in dir, encoded
+// with codec, before it enters the tree, so that a job that crashed doesn't
+// lose what it consumed since its last checkpoint. A tree created with the
+// log of a crashed one reduces the values it finds there (without logging
+// them again) like an input added by its first Add or Finish, so Restore of
+// the last checkpoint and the replay reconstruct the reduction, and the
+// inputs can be resumed from where they were. Checkpoint and Reset empty the
+// log, and a failed write fails the tree. A record is written to the file
+// before the value is combined, which survives a crash of the process but
+// not of the machine.
+func WithWAL[T any](dir string, codec Codec[T]) TypedOption[T] {
+	return func(o *options, ty *typed[T]) {
+		o.walDir = dir
+		ty.walCodec = codec
+	}
+}
+
+// walFile is the name of the log in the directory passed to WithWAL.
+const walFile = "treeduction.wal"
+
+// wal is the log of the values of a tree, see WithWAL.
+type wal[T any] struct {
+	mu    sync.Mutex
+	f     *os.File
+	codec Codec[T]
+	buf   bytes.Buffer
+
+	// The values logged before the tree was created, reduced by its first
+	// Add or Finish
+	replay   <-chan T
+	replayed bool
+}
+
+// openWAL opens the log in dir, with the values that are already in it.
+func openWAL[T any](dir string, codec Codec[T]) (*wal[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, walFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	vs, n, err := readRecords(f, codec)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	// A record cut short by the crash is dropped, its value never entered
+	// the tree
+	if err := f.Truncate(n); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(n, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	c := make(chan T, len(vs))
+	for _, v := range vs {
+		c <- v
+	}
+	close(c)
+	return &wal[T]{f: f, codec: codec, replay: c}, nil
+}
+
+// ReadWAL returns the values in the log written to dir with WithWAL, e.g.
+// to find the offsets to resume the inputs from.
+func ReadWAL[T any](dir string, codec Codec[T]) ([]T, error) {
+	f, err := os.Open(filepath.Join(dir, walFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	vs, _, err := readRecords(f, codec)
+	return vs, err
+}
+
+// readRecords reads the records of a log up to the first incomplete one,
+// and returns the size of the complete ones.
+func readRecords[T any](r io.Reader, codec Codec[T]) ([]T, int64, error) {
+	br := bufio.NewReader(r)
+	var vs []T
+	var n int64
+	for {
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return vs, n, nil
+			}
+			return nil, 0, err
+		}
+		record := make([]byte, size)
+		if _, err := io.ReadFull(br, record); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return vs, n, nil
+			}
+			return nil, 0, err
+		}
+		v, err := codec.Decode(bytes.NewReader(record))
+		if err != nil {
+			return nil, 0, err
+		}
+		vs = append(vs, v)
+		n += int64(len(binary.AppendUvarint(nil, size))) + int64(size)
+	}
+}
+
+// append writes a record for v in a single write.
+func (w *wal[T]) append(v T) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Reset()
+	if err := w.codec.Encode(&w.buf, v); err != nil {
+		return err
+	}
+	record := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+w.buf.Len()), uint64(w.buf.Len()))
+	record = append(record, w.buf.Bytes()...)
+	_, err := w.f.Write(record)
+	return err
+}
+
+// truncate empties the log once what it holds is covered by a checkpoint.
+func (w *wal[T]) truncate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := w.f.Seek(0, io.SeekStart)
+	return err
+}
+
+// logValue writes a value read from src to the log, unless it comes from it.
+func (t *tree[T]) logValue(v T, src *source) {
+	if t.wal == nil || src.replayed {
+		return
+	}
+	if err := t.wal.append(v); err != nil {
+		t.fail(err)
+	}
+}
+
+// replayWAL adds the values found in the log when the tree was created as
+// an input, the first time it is called. t.mu must be held.
+func (t *tree[T]) replayWAL() {
+	if t.wal == nil || t.wal.replayed {
+		return
+	}
+	t.wal.replayed = true
+	t.add([]<-chan T{t.wal.replay}, 0)
+}
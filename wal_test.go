@@ -0,0 +1,94 @@
+package treeduction_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestWAL tests that a tree reduces the values logged by a tree that crashed,
+// on top of its last checkpoint.
+func TestWAL(t *testing.T) {
+	dir := t.TempDir()
+	codec := treeduction.GobCodec[int]{}
+	sum := func(a, b int) int {
+		return a + b
+	}
+
+	// The first job checkpoints after 1 and 2, then reads 3 and 4 and crashes
+	tree := treeduction.With(treeduction.WithWAL(dir, codec)).New(sum, 10, true, false)
+	tree.AddValues(1, 2)
+	waitLogged(t, dir, 2)
+	var checkpoint bytes.Buffer
+	if err := tree.Checkpoint(&checkpoint, codec); err != nil {
+		t.Fatal(err)
+	}
+	if vs, err := treeduction.ReadWAL(dir, codec); err != nil || len(vs) != 0 {
+		t.Fatalf("Expected an empty log after the checkpoint, got %v (%v)", vs, err)
+	}
+	tree.AddValues(3, 4)
+	waitLogged(t, dir, 2)
+	tree.Abort()
+
+	tree = treeduction.With(treeduction.WithWAL(dir, codec)).New(sum, 10, true, false)
+	if err := tree.Restore(&checkpoint, codec); err != nil {
+		t.Fatal(err)
+	}
+	tree.AddValues(5)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 15 {
+		t.Errorf("Expected 15, got %d", v)
+	}
+
+	// The replayed values aren't logged twice
+	if vs, err := treeduction.ReadWAL(dir, codec); err != nil || len(vs) != 3 {
+		t.Errorf("Expected 3 values in the log, got %v (%v)", vs, err)
+	}
+}
+
+// waitLogged waits for n values in the log in dir.
+func waitLogged(t *testing.T, dir string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		vs, err := treeduction.ReadWAL(dir, treeduction.GobCodec[int]{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(vs) == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d values in the log, got %v", n, vs)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestWALCutShort tests that a record cut short by a crash is dropped.
+func TestWALCutShort(t *testing.T) {
+	dir := t.TempDir()
+	codec := treeduction.GobCodec[int]{}
+	if err := os.WriteFile(filepath.Join(dir, "treeduction.wal"), []byte{10, 1, 2}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := treeduction.With(treeduction.WithWAL(dir, codec)).New(func(a, b int) int {
+		return a + b
+	}, 10, true, false)
+	tree.AddValues(2)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); v != 2 {
+		t.Errorf("Expected 2, got %d", v)
+	}
+	if vs, err := treeduction.ReadWAL(dir, codec); err != nil || len(vs) != 1 || vs[0] != 2 {
+		t.Errorf("Expected [2] in the log, got %v (%v)", vs, err)
+	}
+}
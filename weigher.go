@@ -0,0 +1,124 @@
+package treeduction
+
+import (
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// WithWeighter bounds the total weight of the values inside the tree to
+// maxWeight, each value weighing what weigh returns (e.g. its size in
+// bytes), so that the memory the tree uses is bounded when the sizes of the
+// values vary too much for WithMaxInFlight to mean anything. A value read
+// from an input waits for its weight to fit before it enters the tree, and
+// the weight is given back once the value is combined or reaches the output.
+// The result of a combine takes the weight of both sides, and what it weighs
+// on top of them is taken even if it doesn't fit, the inputs then wait until
+// the tree is below maxWeight again. A value heavier than maxWeight enters
+// an empty tree. While an input waits, the nodes pass their values on alone.
+// It only applies to unordered trees without WithMaxWorkers.
+func WithWeighter[T any](weigh func(T) int64, maxWeight int64) TypedOption[T] {
+	if maxWeight <= 0 {
+		panic("treeduction: max weight must be positive")
+	}
+	return func(o *options, ty *typed[T]) {
+		ty.weigh = weigh
+		o.maxWeight = maxWeight
+	}
+}
+
+// weights is the weight of the values inside a tree, see WithWeighter. The
+// semaphore holds up to max of it, the rest is owed and paid back first.
+type weights struct {
+	sem  *semaphore.Weighted
+	max  int64
+	mu   sync.Mutex
+	held int64
+	owed int64
+}
+
+func newWeights(max int64) *weights {
+	return &weights{sem: semaphore.NewWeighted(max), max: max}
+}
+
+// charge takes n without waiting, owing what doesn't fit.
+func (w *weights) charge(n int64) {
+	if n <= 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.sem.TryAcquire(n) {
+		w.held += n
+	} else {
+		w.owed += n
+	}
+}
+
+// release gives n back.
+func (w *weights) release(n int64) {
+	if n <= 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	paid := min(n, w.owed)
+	w.owed -= paid
+	// A weigher that doesn't always return the same weight for a value
+	// mustn't release what nobody holds
+	n = min(n-paid, w.held)
+	w.held -= n
+	w.sem.Release(n)
+}
+
+// weight returns the weight of v, or 0 without WithWeighter.
+func (t *tree[T]) weight(v T) int64 {
+	if t.weights == nil {
+		return 0
+	}
+	return max(t.weigh(v), 0)
+}
+
+// hold waits for the weight of a value read from an input to fit in the
+// tree. It returns false if the tree was cancelled first.
+func (t *tree[T]) hold(v T) bool {
+	if t.weights == nil {
+		return true
+	}
+	w := t.weights
+	n := t.weight(v)
+	fits := min(n, w.max)
+	if !w.sem.TryAcquire(fits) {
+		fed := t.hunger()
+		err := w.sem.Acquire(t.ctx, fits)
+		fed()
+		if err != nil {
+			return false
+		}
+	}
+	w.mu.Lock()
+	w.held += fits
+	w.mu.Unlock()
+	w.charge(n - fits)
+	return true
+}
+
+// unhold gives back the weight of a value that left the tree.
+func (t *tree[T]) unhold(n int64) {
+	if t.weights != nil {
+		t.weights.release(n)
+	}
+}
+
+// reweigh replaces the weight of the two sides of a combine with the one of
+// its result.
+func (t *tree[T]) reweigh(sides int64, v T) {
+	if t.weights == nil {
+		return
+	}
+	if n := t.weight(v); n > sides {
+		t.weights.charge(n - sides)
+	} else {
+		t.weights.release(sides - n)
+	}
+}
@@ -0,0 +1,74 @@
+package treeduction_test
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"treeduction"
+)
+
+// TestWeighter tests that the inputs aren't read while the values inside
+// the tree weigh too much.
+func TestWeighter(t *testing.T) {
+	weigh := func(s string) int64 {
+		return int64(len(s))
+	}
+	tree := treeduction.With(treeduction.WithWeighter(weigh, 10)).New(func(a, b string) string {
+		return a + b
+	}, 10, false, false, treeduction.WithOutputBuffer(0))
+
+	var sent atomic.Int64
+	c := make(chan string)
+	go func() {
+		for range 20 {
+			c <- "abcd"
+			sent.Add(1)
+		}
+		close(c)
+	}()
+	tree.Add(c)
+
+	// Nobody reads the output, so two values fill the tree, and the buffers
+	// stay empty while a third one waits for room
+	time.Sleep(50 * time.Millisecond)
+	if n := sent.Load(); n > 3 {
+		t.Errorf("Expected at most 3 values read, got %d", n)
+	}
+
+	total := 0
+	for total < 80 {
+		select {
+		case v := <-tree.Output():
+			total += len(v)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected 80 bytes, got %d", total)
+		}
+	}
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWeighterHeavy tests that a value heavier than the max weight still
+// enters the tree, once it is empty.
+func TestWeighterHeavy(t *testing.T) {
+	tree := treeduction.With(treeduction.WithWeighter(func(s string) int64 {
+		return int64(len(s))
+	}, 2)).New(func(a, b string) string {
+		return a + b
+	}, 10, true, false)
+
+	c := make(chan string, 3)
+	c <- "a"
+	c <- strings.Repeat("b", 10)
+	c <- "c"
+	close(c)
+	tree.Add(c)
+	if err := tree.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-tree.Output(); len(v) != 12 {
+		t.Errorf("Expected 12 bytes, got %q", v)
+	}
+}
@@ -0,0 +1,41 @@
+package treeduction
+
+// Weighted is a value with its weight, e.g. the number of records of the
+// shard it was computed from.
+type Weighted[T any] struct {
+	Value  T
+	Weight float64
+}
+
+// WeightedTree is a tree whose inputs have weights, see NewWeighted.
+type WeightedTree[T any] struct {
+	*Folder[T, Weighted[T]]
+}
+
+// NewWeighted is like New, but the combiner receives the values with their
+// weights, so that it can merge them proportionally (e.g. a weighted
+// average). The weight of a combination is the sum of the weights of both
+// sides. The values of the inputs passed to Add have a weight of 1, see
+// AddWeighted.
+func NewWeighted[T any](combiner func(f Weighted[T], s Weighted[T]) T, bufferSize int, waitForAll bool, ordered bool, opts ...Option) *WeightedTree[T] {
+	f := Fold(Weighted[T]{}, func(w Weighted[T], v T) Weighted[T] {
+		if w.Weight == 0 {
+			return Weighted[T]{Value: v, Weight: 1}
+		}
+		return Weighted[T]{Value: combiner(w, Weighted[T]{Value: v, Weight: 1}), Weight: w.Weight + 1}
+	}, func(f Weighted[T], s Weighted[T]) Weighted[T] {
+		return Weighted[T]{Value: combiner(f, s), Weight: f.Weight + s.Weight}
+	}, bufferSize, waitForAll, ordered, opts...)
+	return &WeightedTree[T]{f}
+}
+
+// AddWeighted starts reducing the values of the given channels, each value
+// with a weight of w. Like Add, it returns ErrFinished after Finish.
+func (t *WeightedTree[T]) AddWeighted(w float64, out ...<-chan T) error {
+	conv := func(v T) Weighted[T] {
+		return Weighted[T]{Value: v, Weight: w}
+	}
+	return t.add(0, out, conv, func(acc Weighted[T], v T) Weighted[T] {
+		return t.combiner(acc, conv(v))
+	})
+}
@@ -0,0 +1,29 @@
+package treeduction_test
+
+import (
+	"testing"
+	"treeduction"
+)
+
+// TestWeighted tests a weighted average across inputs of different weights.
+func TestWeighted(t *testing.T) {
+	tree := treeduction.NewWeighted(func(a, b treeduction.Weighted[float64]) float64 {
+		return (a.Value*a.Weight + b.Value*b.Weight) / (a.Weight + b.Weight)
+	}, 10, true, false)
+
+	// The averages of a shard of 3 records and of a shard of 1 record
+	c1 := make(chan float64, 1)
+	c1 <- 2
+	close(c1)
+	c2 := make(chan float64, 1)
+	c2 <- 6
+	close(c2)
+	tree.AddWeighted(3, c1)
+	tree.AddWeighted(1, c2)
+
+	tree.Finish()
+	r := <-tree.Output()
+	if r.Value != 3 || r.Weight != 4 {
+		t.Errorf("Expected 3 with a weight of 4, got %v", r)
+	}
+}
@@ -0,0 +1,60 @@
+package treeduction
+
+import (
+	"math/bits"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// The number of inputs from which an Add builds their subtrees at once
+	wideAdd = 1024
+	// The number of leaves of the subtrees built by each goroutine
+	wideChunk = 256
+)
+
+// addWide adds the leaves of a wide Add as perfect subtrees, one per bit set
+// in their number, like the tree would have grown one leaf at a time, but
+// without carrying each leaf up the levels. The subtrees are built in
+// chunks by up to GOMAXPROCS goroutines.
+func (t *tree[T]) addWide(leaves []<-chan T) {
+	for len(leaves) > 0 {
+		level := bits.Len(uint(len(leaves))) - 1
+		t.addOne(t.perfect(leaves[:1<<level], level), level)
+		leaves = leaves[1<<level:]
+	}
+}
+
+// perfect builds a perfect binary tree over 2^height leaves and returns its
+// root.
+func (t *tree[T]) perfect(leaves []<-chan T, height int) <-chan T {
+	chunk := min(wideChunk, len(leaves))
+	roots := make([]<-chan T, len(leaves)/chunk)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(roots)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1)) - 1; i < len(roots); i = int(next.Add(1)) - 1 {
+				roots[i] = t.pairUp(leaves[i*chunk:(i+1)*chunk], 0)
+			}
+		}()
+	}
+	wg.Wait()
+	return t.pairUp(roots, bits.Len(uint(chunk))-1)
+}
+
+// pairUp combines the channels two by two, level after level, until one is
+// left. level is the one of the channels.
+func (t *tree[T]) pairUp(level []<-chan T, height int) <-chan T {
+	for ; len(level) > 1; height++ {
+		up := make([]<-chan T, len(level)/2)
+		for i := range up {
+			up[i] = t.unorderedNode(level[2*i], level[2*i+1], height+1)
+		}
+		level = up
+	}
+	return level[0]
+}
@@ -0,0 +1,74 @@
+package treeduction_test
+
+import (
+	"fmt"
+	"testing"
+	"treeduction"
+)
+
+// TestWideAdd tests that a wide Add reduces every input, into the shape the
+// tree gets when the inputs are added one by one.
+func TestWideAdd(t *testing.T) {
+	const n = 3000
+	reduce := func(wide bool) int {
+		tree := treeduction.New(func(a, b int) int {
+			return a + b
+		}, 1, true, false)
+		inputs := make([]<-chan int, n)
+		for i := range inputs {
+			c := make(chan int, 1)
+			c <- 1
+			close(c)
+			inputs[i] = c
+			if !wide {
+				tree.Add(c)
+			}
+		}
+		if wide {
+			tree.Add(inputs...)
+		}
+		depth := tree.Stats().Depth
+		if err := tree.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		if v := <-tree.Output(); v != n {
+			t.Errorf("Expected %d, got %d", n, v)
+		}
+		return depth
+	}
+	if one, wide := reduce(false), reduce(true); one != wide {
+		t.Errorf("Expected a depth of %d, got %d", one, wide)
+	}
+}
+
+func BenchmarkWideAdd(b *testing.B) {
+	for _, n := range []int{1_000, 10_000, 100_000} {
+		inputs := make([]<-chan int, n)
+		for _, chunk := range []int{n, 512} {
+			b.Run(fmt.Sprintf("inputs=%d/chunk=%d", n, chunk), func(b *testing.B) {
+				for range b.N {
+					b.StopTimer()
+					for i := range inputs {
+						c := make(chan int, 1)
+						c <- 1
+						close(c)
+						inputs[i] = c
+					}
+					tree := treeduction.New(func(a, b int) int {
+						return a + b
+					}, 1, true, false)
+					b.StartTimer()
+					for i := 0; i < n; i += chunk {
+						tree.Add(inputs[i:min(i+chunk, n)]...)
+					}
+					b.StopTimer()
+					tree.Finish()
+					if v := <-tree.Output(); v != n {
+						b.Fatalf("Expected %d, got %d", n, v)
+					}
+					b.StartTimer()
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package treeduction
+
+import "time"
+
+// WithTumblingWindow makes the tree emit one reduced value per n input
+// values, instead of every partial result. A trailing incomplete window is
+// emitted by Finish. Windows are counted per input value, so the nodes only
+// fan the values in, and each window is folded at the root.
+func WithTumblingWindow(n int) Option {
+	return WithSlidingWindow(n, n)
+}
+
+// WithSlidingWindow makes the tree emit, after every step input values, the
+// reduction of the last size values. Like WithTumblingWindow, the windows
+// are folded at the root.
+func WithSlidingWindow(size int, step int) Option {
+	if size <= 0 || step <= 0 {
+		panic("treeduction: window size and step must be positive")
+	}
+	return func(o *options) {
+		o.windowSize, o.windowStep = size, step
+		o.timeWindow = 0
+	}
+}
+
+// WithTimeWindow makes the tree emit one reduced value per interval d. The
+// nodes keep combining, and a window is made of the partial results that
+// reached the root during that interval.
+func WithTimeWindow(d time.Duration) Option {
+	if d <= 0 {
+		panic("treeduction: window duration must be positive")
+	}
+	return func(o *options) {
+		o.timeWindow = d
+		o.windowSize, o.windowStep = 0, 0
+	}
+}
+
+// window keeps the values of the current window in arrival order, so that
+// they are always folded from left to right.
+type window[T any] struct {
+	size   int
+	step   int
+	values []T
+	fresh  int
+}
+
+// add records v and reports whether a window is complete.
+func (w *window[T]) add(v T) bool {
+	w.values = append(w.values, v)
+	if w.size > 0 && len(w.values) > w.size {
+		w.values = w.values[1:]
+	}
+	w.fresh++
+	return w.size > 0 && w.fresh >= w.step && len(w.values) == w.size
+}
+
+// fold reduces the values of the current window, dropping the ones that
+// can't belong to the next one.
+func (w *window[T]) fold(combiner func(T, T) T) (T, bool) {
+	var r T
+	if w.fresh == 0 {
+		return r, false
+	}
+	r = w.values[0]
+	for _, v := range w.values[1:] {
+		r = combiner(r, v)
+	}
+
+	w.fresh = 0
+	switch {
+	case w.size == 0 || w.step >= w.size:
+		w.values = w.values[:0]
+	default:
+		w.values = w.values[w.step:]
+	}
+	return r, true
+}
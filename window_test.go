@@ -0,0 +1,121 @@
+package treeduction_test
+
+import (
+	"testing"
+	"time"
+	"treeduction"
+)
+
+func collect(tree treeduction.Tree[int], n int) []int {
+	var results []int
+	for range n {
+		results = append(results, <-tree.Output())
+	}
+	return results
+}
+
+func equal(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestTumblingWindow tests that every n values are reduced into one output.
+func TestTumblingWindow(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithTumblingWindow(3))
+
+	// Every send returns once the tree has taken the value
+	ch := make(chan int)
+	tree.Add(ch)
+	for i := 1; i <= 7; i++ {
+		ch <- i
+	}
+
+	// Finish flushes the partial window
+	tree.Finish()
+	var results []int
+	for v := range tree.Output() {
+		results = append(results, v)
+	}
+	if !equal(results, []int{6, 15, 7}) {
+		t.Errorf("Expected windows to be %v, got %v", []int{6, 15, 7}, results)
+	}
+}
+
+// TestTumblingWindowPerInput tests that windows are counted per input value,
+// even when the values come from several channels of one Add.
+func TestTumblingWindowPerInput(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithTumblingWindow(2))
+	defer tree.Finish()
+
+	channels := make([]<-chan int, 8)
+	for i := range channels {
+		ch := make(chan int, 1)
+		ch <- i + 1
+		close(ch)
+		channels[i] = ch
+	}
+	tree.Add(channels...)
+
+	sum := 0
+	for _, v := range collect(tree, 4) {
+		sum += v
+	}
+	if sum != 36 {
+		t.Errorf("Expected the 4 windows to sum up to 36, got %d", sum)
+	}
+}
+
+// TestSlidingWindow tests that overlapping windows are emitted every step.
+func TestSlidingWindow(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithSlidingWindow(3, 1))
+	defer tree.Finish()
+
+	ch := make(chan int, 5)
+	for i := 1; i <= 5; i++ {
+		ch <- i
+	}
+	close(ch)
+	tree.Add(ch)
+
+	results := collect(tree, 3)
+	if !equal(results, []int{6, 9, 12}) {
+		t.Errorf("Expected windows to be %v, got %v", []int{6, 9, 12}, results)
+	}
+}
+
+// TestTimeWindow tests that values are reduced per time interval.
+func TestTimeWindow(t *testing.T) {
+	tree := treeduction.New(func(a, b int) int {
+		return a + b
+	}, 10, false, false, treeduction.WithTimeWindow(50*time.Millisecond))
+	defer tree.Finish()
+
+	ch := make(chan int, 5)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	tree.Add(ch)
+
+	if result := <-tree.Output(); result != 6 {
+		t.Errorf("Expected first window to be 6, got %d", result)
+	}
+
+	ch <- 4
+	if result := <-tree.Output(); result != 4 {
+		t.Errorf("Expected second window to be 4, got %d", result)
+	}
+	close(ch)
+}